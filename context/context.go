@@ -227,6 +227,7 @@ type cancelState struct {
 
 	mu       sync.Mutex
 	err      error                 // GUARDED_BY(mu)
+	reason   *cancelReason         // GUARDED_BY(mu)
 	children map[*cancelState]bool // GUARDED_BY(mu)
 }
 
@@ -255,38 +256,38 @@ func initLeakChecker() {
 	}
 }
 
-// makeCancelFunc returns a function that cancels cancelState *cs with err, and
-// it timer!=nil, stops *timer.  Requires that *cs has cancellation parent
-// *cancelParent if cancelParent != nil.  It may use an indirection through a
-// leakCheck if $VCONTEXT_LEAK_CHECK is set.
-func makeCancelFunc(cs *cancelState, cancelParent *cancelState, timer *time.Timer, err error) (cancelFunc CancelFunc) {
+// makeCancelFunc returns a function that cancels cancelState *cs with err and
+// the reason it is passed, and if timer!=nil, stops *timer.  Requires that *cs
+// has cancellation parent *cancelParent if cancelParent != nil.  It may use an
+// indirection through a leakCheck if $VCONTEXT_LEAK_CHECK is set.
+func makeCancelFunc(cs *cancelState, cancelParent *cancelState, timer *time.Timer, err error) (cancelFunc func(*cancelReason)) {
 	initLeakCheckerOnce.Do(initLeakChecker)
 	if leakedContextPCs > 0 && err != DeadlineExceeded { // the timer is allowed to leak its callbacks.
 		lc := &leakCheck{cs: cs, stack: make([]uintptr, leakedContextPCs)}
 		lc.stack = lc.stack[:runtime.Callers(2, lc.stack)]
 		runtime.SetFinalizer(lc, checkForLeaks)
-		cancelFunc = func() { // captures cancelParent, timer, err, and lc (not cs).
+		cancelFunc = func(reason *cancelReason) { // captures cancelParent, timer, err, and lc (not cs).
 			if cancelParent != nil {
 				cancelParent.removeChild(lc.cs)
 			}
 			if timer != nil {
 				timer.Stop()
 			}
-			lc.cs.cancel(err)
+			lc.cs.cancel(err, reason)
 			runtime.SetFinalizer(lc, nil)
 			lc.cs.mu.Lock()
 			lc.funcCalled = true
 			lc.cs.mu.Unlock()
 		}
 	} else {
-		cancelFunc = func() { // captures cancelParent, timer, err, and cs.
+		cancelFunc = func(reason *cancelReason) { // captures cancelParent, timer, err, and cs.
 			if cancelParent != nil {
 				cancelParent.removeChild(cs)
 			}
 			if timer != nil {
 				timer.Stop()
 			}
-			cs.cancel(err)
+			cs.cancel(err, reason)
 		}
 	}
 	return cancelFunc
@@ -317,9 +318,9 @@ func (c *cancelState) addChild(child *cancelState) {
 	c.mu.Lock()
 
 	if c.err != nil {
-		err := c.err
+		err, reason := c.err, c.reason
 		c.mu.Unlock()
-		child.cancel(err)
+		child.cancel(err, reason)
 		return
 	}
 
@@ -336,12 +337,23 @@ func (c *cancelState) removeChild(child *cancelState) {
 	c.mu.Unlock()
 }
 
-func (c *cancelState) cancel(err error) {
+// canceled returns true if c has been canceled.
+func (c *cancelState) canceled() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *cancelState) cancel(err error, reason *cancelReason) {
 	var children map[*cancelState]bool
 
 	c.mu.Lock()
 	if c.err == nil {
 		c.err = err
+		c.reason = reason
 		children = c.children
 		c.children = nil
 		close(c.done)
@@ -349,7 +361,7 @@ func (c *cancelState) cancel(err error) {
 	c.mu.Unlock()
 
 	for child, _ := range children {
-		child.cancel(err)
+		child.cancel(err, reason)
 	}
 }
 
@@ -386,13 +398,15 @@ func withCancelState(parent *T) (t *T, cs *cancelState, cancelParent *cancelStat
 // (and all context further derived from it) will be closed.
 func WithCancel(parent *T) (*T, CancelFunc) {
 	t, cs, cancelParent := withCancelState(parent)
-	return t, makeCancelFunc(cs, cancelParent, nil, Canceled)
+	return t, withCallerReason(cs, makeCancelFunc(cs, cancelParent, nil, Canceled))
 }
 
 func withDeadlineState(parent *T, deadline time.Time, timeout time.Duration) (*T, CancelFunc) {
 	t, cs, cancelParent := withCancelState(parent)
-	ds := &deadlineState{deadline, time.AfterFunc(timeout, makeCancelFunc(cs, cancelParent, nil, DeadlineExceeded))}
-	return WithValue(t, deadlineKey, ds), makeCancelFunc(cs, cancelParent, ds.timer, Canceled)
+	expired := &cancelReason{reason: CancelReason{Message: "deadline exceeded", Deadline: deadline}}
+	expire := makeCancelFunc(cs, cancelParent, nil, DeadlineExceeded)
+	ds := &deadlineState{deadline, time.AfterFunc(timeout, func() { expire(expired) })}
+	return WithValue(t, deadlineKey, ds), withCallerReason(cs, makeCancelFunc(cs, cancelParent, ds.timer, Canceled))
 }
 
 // WithRootContext returns a context derived from parent, but that is
@@ -411,7 +425,7 @@ func WithRootCancel(parent *T) (*T, CancelFunc) {
 	if root != nil {
 		root.addChild(cs)
 	}
-	return WithValue(parent, cancelKey, cs), makeCancelFunc(cs, root, nil, Canceled)
+	return WithValue(parent, cancelKey, cs), withCallerReason(cs, makeCancelFunc(cs, root, nil, Canceled))
 }
 
 // WithDeadline returns a child of the current context along with a
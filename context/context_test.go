@@ -6,7 +6,9 @@ package context_test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -157,6 +159,58 @@ func TestDeadlineContextWithRace(t *testing.T) {
 	}
 }
 
+func TestCancelReason(t *testing.T) {
+	root, _ := context.RootContext()
+	if _, ok := root.CancelReason(); ok {
+		t.Errorf("uncanceled context should have no reason")
+	}
+
+	// Cancellation via a CancelFunc is attributed to its caller, and shared
+	// with descendants.
+	parent, cancel := context.WithCancel(root)
+	child, _ := context.WithCancel(parent)
+	cancel()
+	<-child.Done()
+	reason, ok := child.CancelReason()
+	if !ok || !strings.Contains(reason.Canceler, "context_test.go") || reason.Message != "canceled" {
+		t.Errorf("unexpected reason %+v", reason)
+	}
+
+	// Expired deadlines are identified by the deadline, and the CancelFuncs
+	// called afterwards aren't recorded.
+	deadline, cancel := context.WithTimeout(root, time.Millisecond)
+	child, _ = context.WithCancel(deadline)
+	<-child.Done()
+	cancel()
+	reason, ok = child.CancelReason()
+	if d, _ := deadline.Deadline(); !ok || reason.Canceler != "" || !reason.Deadline.Equal(d) {
+		t.Errorf("unexpected reason %+v", reason)
+	}
+	if got, want := child.Err(), context.DeadlineExceeded; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Explicit reasons are recorded as given, and only the first counts.
+	ctx, cancel, cancelWithReason := context.WithCancelReason(root)
+	cause := errors.New("shutting down")
+	cancelWithReason(context.CancelReason{Canceler: "server", Message: "stopped", Err: cause})
+	cancel()
+	<-ctx.Done()
+	reason, _ = ctx.CancelReason()
+	if reason.Canceler != "server" || reason.Message != "stopped" || reason.Err != cause {
+		t.Errorf("unexpected reason %+v", reason)
+	}
+	if got, want := reason.String(), "stopped by server: shutting down"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Children derived after cancellation inherit the reason.
+	child, _ = context.WithCancel(ctx)
+	if reason, _ := child.CancelReason(); reason.Canceler != "server" {
+		t.Errorf("unexpected reason %+v", reason)
+	}
+}
+
 func TestValueContext(t *testing.T) {
 	type testContextKey int
 	const (
@@ -0,0 +1,116 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// CancelReason describes why a context was canceled.  Every canceled context
+// has a reason; it is shared by the context that was canceled directly and all
+// of its descendants.
+type CancelReason struct {
+	// Canceler identifies who canceled the context.  For contexts canceled via
+	// a CancelFunc it defaults to the file:line that called the CancelFunc.
+	// It is empty for expired deadlines, whose Deadline identifies the
+	// ancestor context that was responsible.
+	Canceler string
+	// Message describes why the context was canceled.
+	Message string
+	// Err, if non-nil, is the error, typically a verror, that caused the
+	// cancellation.
+	Err error
+	// Deadline is the deadline that expired, if the context was canceled because
+	// of a deadline.
+	Deadline time.Time
+}
+
+// String returns a human-readable description of the reason.
+func (r CancelReason) String() string {
+	s := r.Message
+	if s == "" {
+		s = "canceled"
+	}
+	if r.Canceler != "" {
+		s += " by " + r.Canceler
+	}
+	if !r.Deadline.IsZero() {
+		s += " at deadline " + r.Deadline.Format(time.RFC3339Nano)
+	}
+	if r.Err != nil {
+		s += ": " + r.Err.Error()
+	}
+	return s
+}
+
+// CancelWithReasonFunc is like CancelFunc, but records reason as the reason
+// that the paired context and its descendants were canceled.  Only the reason
+// passed to the first call, or to the first call of the paired CancelFunc, is
+// recorded.
+type CancelWithReasonFunc func(reason CancelReason)
+
+// cancelReason is the reason recorded by a cancelState.  The caller's
+// location is only captured when the reason is recorded, and resolved lazily,
+// since most reasons are never inspected.
+type cancelReason struct {
+	reason CancelReason
+	pc     [1]uintptr
+}
+
+func (r *cancelReason) resolve() CancelReason {
+	reason := r.reason
+	if reason.Canceler == "" && r.pc[0] != 0 {
+		frame, _ := runtime.CallersFrames(r.pc[:]).Next()
+		reason.Canceler = fmt.Sprintf("%s:%d", frame.File, frame.Line)
+	}
+	return reason
+}
+
+// withCallerReason returns a CancelFunc that cancels cs by calling cancel
+// with a reason attributed to its caller.
+func withCallerReason(cs *cancelState, cancel func(*cancelReason)) CancelFunc {
+	return func() {
+		r := &cancelReason{reason: CancelReason{Message: "canceled"}}
+		if !cs.canceled() {
+			// Most CancelFuncs are deferred, and called after the context
+			// was canceled some other way; their caller isn't recorded.
+			runtime.Callers(2, r.pc[:])
+		}
+		cancel(r)
+	}
+}
+
+// WithCancelReason is like WithCancel, but also returns a function that
+// cancels the new context with an explicit reason.  Calling either function
+// cancels the context.
+func WithCancelReason(parent *T) (*T, CancelFunc, CancelWithReasonFunc) {
+	t, cs, cancelParent := withCancelState(parent)
+	cancel := makeCancelFunc(cs, cancelParent, nil, Canceled)
+	return t, withCallerReason(cs, cancel), func(reason CancelReason) {
+		r := &cancelReason{reason: reason}
+		if reason.Canceler == "" && !cs.canceled() {
+			runtime.Callers(2, r.pc[:])
+		}
+		cancel(r)
+	}
+}
+
+// CancelReason returns the reason that t was canceled, and false if t has not
+// been canceled.
+func (t *T) CancelReason() (CancelReason, bool) {
+	if cancel, ok := t.Value(cancelKey).(*cancelState); ok {
+		cancel.mu.Lock()
+		defer cancel.mu.Unlock()
+		if cancel.err != nil {
+			if cancel.reason == nil {
+				return CancelReason{}, true
+			}
+			return cancel.reason.resolve(), true
+		}
+	}
+	return CancelReason{}, false
+}
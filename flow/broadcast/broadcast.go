@@ -0,0 +1,147 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package broadcast implements the delivery of flow.Events to the
+// subscribers of a flow.Manager.
+//
+// A Manager implementation publishes each event to a Broadcaster, which
+// queues it for every subscriber, so that a publisher never blocks on a slow
+// subscriber and no subscriber misses an event:
+//
+//   type manager struct {
+//     events *broadcast.Broadcaster
+//     ...
+//   }
+//
+//   func (m *manager) Subscribe(ctx *context.T) <-chan flow.Event {
+//     return m.events.Subscribe(ctx)
+//   }
+//
+//   m.events.Publish(flow.ConnEstablished{ID: id, ...})
+package broadcast
+
+import (
+	"sync"
+
+	"v.io/v23/context"
+	"v.io/v23/flow"
+)
+
+// Broadcaster delivers published events to all its subscribers, in the order
+// they were published.
+type Broadcaster struct {
+	mu     sync.Mutex
+	subs   map[*subscriber]bool // GUARDED_BY(mu)
+	closed bool                 // GUARDED_BY(mu)
+}
+
+type subscriber struct {
+	mu      sync.Mutex
+	queue   []flow.Event  // GUARDED_BY(mu)
+	done    bool          // GUARDED_BY(mu)
+	notify  chan struct{} // signaled when queue or done change.
+	events  chan flow.Event
+	stopped chan struct{} // closed when the subscription ends.
+}
+
+// New returns a Broadcaster without subscribers.
+func New() *Broadcaster {
+	return &Broadcaster{subs: make(map[*subscriber]bool)}
+}
+
+// Subscribe returns a channel on which the events published from now on are
+// sent, until ctx is done or the Broadcaster is closed, at which point the
+// channel is closed.  Events that were published before the end of the
+// subscription are still delivered unless ctx is done.
+func (b *Broadcaster) Subscribe(ctx *context.T) <-chan flow.Event {
+	s := &subscriber{
+		notify:  make(chan struct{}, 1),
+		events:  make(chan flow.Event),
+		stopped: make(chan struct{}),
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(s.events)
+		return s.events
+	}
+	b.subs[s] = true
+	b.mu.Unlock()
+	go s.run(ctx)
+	go func() {
+		select {
+		case <-ctx.Done():
+			b.mu.Lock()
+			delete(b.subs, s)
+			b.mu.Unlock()
+		case <-s.stopped:
+		}
+	}()
+	return s.events
+}
+
+// Publish queues e for delivery to all the current subscribers.  It does not
+// block.
+func (b *Broadcaster) Publish(e flow.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		s.mu.Lock()
+		s.queue = append(s.queue, e)
+		s.mu.Unlock()
+		s.signal()
+	}
+}
+
+// Close ends all subscriptions, once their queued events are delivered, and
+// makes later subscriptions end immediately.  It is idempotent.
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subs {
+		s.mu.Lock()
+		s.done = true
+		s.mu.Unlock()
+		s.signal()
+		delete(b.subs, s)
+	}
+}
+
+func (s *subscriber) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run sends the queued events of s on s.events.
+func (s *subscriber) run(ctx *context.T) {
+	defer close(s.stopped)
+	defer close(s.events)
+	for {
+		s.mu.Lock()
+		queue, done := s.queue, s.done
+		s.queue = nil
+		s.mu.Unlock()
+		for _, e := range queue {
+			select {
+			case s.events <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if len(queue) > 0 {
+			continue
+		}
+		if done {
+			return
+		}
+		select {
+		case <-s.notify:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package broadcast_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/flow"
+	"v.io/v23/flow/broadcast"
+)
+
+func collect(t *testing.T, events <-chan flow.Event) []flow.Event {
+	var got []flow.Event
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return got
+			}
+			got = append(got, e)
+		case <-timeout:
+			t.Fatalf("timed out waiting for the subscription to end, got %v", got)
+		}
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	b := broadcast.New()
+	b.Publish(flow.ListenerAdded{Protocol: "tcp", Address: ":0"})
+	sub1 := b.Subscribe(ctx)
+	sub2 := b.Subscribe(ctx)
+
+	errClosed := errors.New("closed")
+	want := []flow.Event{
+		flow.ListenerAdded{Protocol: "tcp", Address: ":1234"},
+		flow.ConnEstablished{ID: 1, Dialed: true},
+		flow.ConnLameDuck{ID: 1, Remote: true},
+		flow.ConnClosed{ID: 1, Reason: flow.ClosedRemotely, Err: errClosed},
+		flow.ListenerRemoved{Protocol: "tcp", Address: ":1234"},
+	}
+	// Nobody reads from the subscriptions yet: Publish must not block.
+	for _, e := range want {
+		b.Publish(e)
+	}
+	b.Close()
+	b.Close()
+
+	for _, sub := range []<-chan flow.Event{sub1, sub2} {
+		if got := collect(t, sub); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+	if got := collect(t, b.Subscribe(ctx)); len(got) != 0 {
+		t.Errorf("got %v after Close, want no events", got)
+	}
+}
+
+func TestCanceledSubscription(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	b := broadcast.New()
+	defer b.Close()
+	sctx, scancel := context.WithCancel(ctx)
+	canceled := b.Subscribe(sctx)
+	other := b.Subscribe(ctx)
+	b.Publish(flow.ConnEstablished{ID: 1})
+	if e := <-canceled; !reflect.DeepEqual(e, flow.ConnEstablished{ID: 1}) {
+		t.Errorf("got %v", e)
+	}
+	scancel()
+	// The canceled subscription ends, without blocking the others.
+	collect(t, canceled)
+	for i := 0; i < 100; i++ {
+		b.Publish(flow.ConnClosed{ID: uint64(i)})
+	}
+	if e := <-other; !reflect.DeepEqual(e, flow.ConnEstablished{ID: 1}) {
+		t.Errorf("got %v", e)
+	}
+	for i := 0; i < 100; i++ {
+		if e := <-other; !reflect.DeepEqual(e, flow.ConnClosed{ID: uint64(i)}) {
+			t.Errorf("got %v, want ConnClosed %d", e, i)
+		}
+	}
+}
+
+func TestCloseReasonString(t *testing.T) {
+	if got, want := flow.ClosedUnhealthy.String(), "unhealthy"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := flow.CloseReason(42).String(), "CloseReason(42)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flow
+
+import (
+	"fmt"
+
+	"v.io/v23/naming"
+)
+
+// Event is a change of the listening addresses or of the connections of a
+// Manager, as delivered by Manager.Subscribe.  It is one of ListenerAdded,
+// ListenerRemoved, ProxyConnected, ProxyDisconnected, ConnEstablished,
+// ConnLameDuck and ConnClosed.
+type Event interface {
+	flowEvent()
+}
+
+// ListenerAdded is sent when the Manager starts listening on an address.
+type ListenerAdded struct {
+	// Protocol and Address are the arguments that were passed to Listen.
+	Protocol, Address string
+	// Endpoints are the endpoints at which the Manager accepts flows on the
+	// listener.
+	Endpoints []naming.Endpoint
+}
+
+// ListenerRemoved is sent when the Manager stops listening on an address,
+// either because StopListening was called or because the listener failed.
+type ListenerRemoved struct {
+	// Protocol and Address are the arguments that were passed to Listen.
+	Protocol, Address string
+	// Endpoints are the endpoints that were reported by ListenerAdded.
+	Endpoints []naming.Endpoint
+	// Err is the error that caused the listener to fail, or nil if
+	// StopListening was called.
+	Err error
+}
+
+// ProxyConnected is sent when a connection to a proxy that was passed to
+// ProxyListen is established, and again whenever the proxy changes the
+// endpoints it serves for the Manager.
+type ProxyConnected struct {
+	// Name is the name that was passed to ProxyListen.
+	Name string
+	// Proxy is the endpoint of the proxy.
+	Proxy naming.Endpoint
+	// Endpoints are the endpoints at which the Manager accepts flows through
+	// the proxy.
+	Endpoints []naming.Endpoint
+}
+
+// ProxyDisconnected is sent when the connection to a proxy is lost or closed.
+type ProxyDisconnected struct {
+	// Name is the name that was passed to ProxyListen.
+	Name string
+	// Proxy is the endpoint of the proxy.
+	Proxy naming.Endpoint
+	// Err is the error that caused the disconnection, or nil if
+	// StopListening was called.
+	Err error
+}
+
+// ConnEstablished is sent when a connection has been authenticated and added
+// to the Manager's cache.
+type ConnEstablished struct {
+	// ID is the ConnStats.ID of the connection.
+	ID uint64
+	// LocalEndpoint and RemoteEndpoint are the endpoints of the connection.
+	LocalEndpoint, RemoteEndpoint naming.Endpoint
+	// Dialed is true if the connection was dialed by the Manager, and false
+	// if it was accepted from a listener.
+	Dialed bool
+}
+
+// ConnLameDuck is sent when a connection enters lame duck mode, after which
+// no new flows are opened on it.
+type ConnLameDuck struct {
+	// ID is the ConnStats.ID of the connection.
+	ID uint64
+	// RemoteEndpoint is the remote endpoint of the connection.
+	RemoteEndpoint naming.Endpoint
+	// Remote is true if the remote end requested lame duck mode.
+	Remote bool
+}
+
+// ConnClosed is sent when a connection is closed.
+type ConnClosed struct {
+	// ID is the ConnStats.ID of the connection.
+	ID uint64
+	// RemoteEndpoint is the remote endpoint of the connection.
+	RemoteEndpoint naming.Endpoint
+	// Reason classifies why the connection was closed.
+	Reason CloseReason
+	// Err is the error the connection was closed with, if any.
+	Err error
+}
+
+func (ListenerAdded) flowEvent()     {}
+func (ListenerRemoved) flowEvent()   {}
+func (ProxyConnected) flowEvent()    {}
+func (ProxyDisconnected) flowEvent() {}
+func (ConnEstablished) flowEvent()   {}
+func (ConnLameDuck) flowEvent()      {}
+func (ConnClosed) flowEvent()        {}
+
+// CloseReason is the reason a connection was closed.
+type CloseReason int
+
+const (
+	// ClosedByError means that the connection failed, for example because
+	// of a network or protocol error.
+	ClosedByError CloseReason = iota
+	// ClosedLocally means that the local end closed the connection, for
+	// example because the Manager was closed.
+	ClosedLocally
+	// ClosedRemotely means that the remote end closed the connection.
+	ClosedRemotely
+	// ClosedIdle means that the connection was closed by the Manager's cache
+	// because it was idle.
+	ClosedIdle
+	// ClosedUnhealthy means that the connection was closed because the remote
+	// end didn't answer health checks in time.
+	ClosedUnhealthy
+)
+
+func (r CloseReason) String() string {
+	switch r {
+	case ClosedByError:
+		return "error"
+	case ClosedLocally:
+		return "local"
+	case ClosedRemotely:
+		return "remote"
+	case ClosedIdle:
+		return "idle"
+	case ClosedUnhealthy:
+		return "unhealthy"
+	}
+	return fmt.Sprintf("CloseReason(%d)", int(r))
+}
@@ -0,0 +1,198 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package flowstats publishes the statistics of a flow.Manager as stats
+// objects.
+//
+// The following objects are published, relative to the root of the stats
+// service:
+//
+//   flow/manager/conns          int64  open connections
+//   flow/manager/totalConns     int64  connections ever established
+//   flow/manager/reconnects     int64  reconnects of pinned connections
+//   flow/total/<counter>        int64  counters summed over all connections
+//   flow/conns/<id>/<counter>   int64  counters of an open connection
+//   flow/conns/<id>/localEndpoint   string
+//   flow/conns/<id>/remoteEndpoint  string
+//   flow/conns/<id>/rtt         int64  round-trip-time in microseconds
+//   flow/conns/<id>/mtu         int64
+//   flow/conns/<id>/sharedTokens int64
+//
+// where <counter> is one of bytesSent, bytesReceived, messagesSent,
+// messagesReceived, activeFlows, totalFlows, flowControlStalls and
+// healthCheckMisses, and <id> is the ConnStats.ID of the connection.
+//
+// A process serves the objects of its Manager with:
+//
+//   m, err := v23.NewFlowManager(ctx, channelTimeout)
+//   ...
+//   disp := flowstats.NewDispatcher(m, auth)
+package flowstats
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"v.io/v23/flow"
+	"v.io/v23/naming"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	"v.io/v23/services/stats/statsserver"
+	"v.io/v23/verror"
+)
+
+const pkgPath = "v.io/v23/flow/flowstats"
+
+var errNoObject = verror.Register(pkgPath+".errNoObject", verror.NoRetry, "{1:}{2:} no flow stats object named {3}{:_}")
+
+// Root is the name, relative to the root of the stats service, under which
+// all flow statistics are published.
+const Root = "flow"
+
+// The names of the nodes below Root.
+const (
+	ManagerNode = "manager"
+	TotalNode   = "total"
+	ConnsNode   = "conns"
+)
+
+// StatsProvider is implemented by flow.Manager.
+type StatsProvider interface {
+	Stats() flow.ManagerStats
+}
+
+// counters are the objects published both for each connection and for the
+// total over all connections.
+var counters = []struct {
+	name  string
+	value func(s *flow.ConnStats) int64
+}{
+	{"activeFlows", func(s *flow.ConnStats) int64 { return int64(s.ActiveFlows) }},
+	{"bytesReceived", func(s *flow.ConnStats) int64 { return int64(s.BytesReceived) }},
+	{"bytesSent", func(s *flow.ConnStats) int64 { return int64(s.BytesSent) }},
+	{"flowControlStalls", func(s *flow.ConnStats) int64 { return int64(s.FlowControlStalls) }},
+	{"healthCheckMisses", func(s *flow.ConnStats) int64 { return int64(s.HealthCheckMisses) }},
+	{"messagesReceived", func(s *flow.ConnStats) int64 { return int64(s.MessagesReceived) }},
+	{"messagesSent", func(s *flow.ConnStats) int64 { return int64(s.MessagesSent) }},
+	{"totalFlows", func(s *flow.ConnStats) int64 { return int64(s.TotalFlows) }},
+}
+
+// connObjects are the objects published only for each connection.
+var connObjects = []struct {
+	name  string
+	value func(s *flow.ConnStats) interface{}
+}{
+	{"localEndpoint", func(s *flow.ConnStats) interface{} { return endpointString(s.LocalEndpoint) }},
+	{"mtu", func(s *flow.ConnStats) interface{} { return int64(s.Mtu) }},
+	{"remoteEndpoint", func(s *flow.ConnStats) interface{} { return endpointString(s.RemoteEndpoint) }},
+	{"rtt", func(s *flow.ConnStats) interface{} { return int64(s.RTT / time.Microsecond) }},
+	{"sharedTokens", func(s *flow.ConnStats) interface{} { return int64(s.SharedTokens) }},
+}
+
+var managerObjects = []struct {
+	name  string
+	value func(s *flow.ManagerStats) int64
+}{
+	{"conns", func(s *flow.ManagerStats) int64 { return int64(len(s.Conns)) }},
+	{"reconnects", func(s *flow.ManagerStats) int64 { return int64(s.Reconnects) }},
+	{"totalConns", func(s *flow.ManagerStats) int64 { return int64(s.TotalConns) }},
+}
+
+func endpointString(ep naming.Endpoint) string {
+	if ep.IsZero() {
+		return ""
+	}
+	return ep.String()
+}
+
+// Source is a statsserver.Source of the statistics of a flow.Manager.  Each call
+// to Names or Value takes a new snapshot of the statistics.
+type Source struct {
+	p StatsProvider
+}
+
+// NewSource returns a Source of the statistics of p.
+func NewSource(p StatsProvider) *Source {
+	return &Source{p}
+}
+
+// NewDispatcher returns a dispatcher that serves the statistics of p via the
+// stats.Stats interface.  Like statsserver.NewDispatcher, it is meant to be
+// mounted at the root of the stats service.
+func NewDispatcher(p StatsProvider, auth security.Authorizer) rpc.Dispatcher {
+	return statsserver.NewDispatcher(NewSource(p), auth)
+}
+
+// Names returns the names of all the objects, relative to the root of the
+// stats service, in sorted order.
+func (s *Source) Names() []string {
+	stats := s.p.Stats()
+	var names []string
+	for _, o := range managerObjects {
+		names = append(names, naming.Join(Root, ManagerNode, o.name))
+	}
+	for _, c := range counters {
+		names = append(names, naming.Join(Root, TotalNode, c.name))
+	}
+	for _, conn := range stats.Conns {
+		prefix := naming.Join(Root, ConnsNode, strconv.FormatUint(conn.ID, 10))
+		for _, c := range counters {
+			names = append(names, naming.Join(prefix, c.name))
+		}
+		for _, o := range connObjects {
+			names = append(names, naming.Join(prefix, o.name))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Value returns the current value of the named object, where name is
+// relative to the root of the stats service.
+func (s *Source) Value(name string) (interface{}, error) {
+	elems := statsserver.SplitName(name)
+	stats := s.p.Stats()
+	switch {
+	case len(elems) == 3 && elems[0] == Root && elems[1] == ManagerNode:
+		for _, o := range managerObjects {
+			if o.name == elems[2] {
+				return o.value(&stats), nil
+			}
+		}
+	case len(elems) == 3 && elems[0] == Root && elems[1] == TotalNode:
+		if v, ok := counterValue(&stats.Total, elems[2]); ok {
+			return v, nil
+		}
+	case len(elems) == 4 && elems[0] == Root && elems[1] == ConnsNode:
+		id, err := strconv.ParseUint(elems[2], 10, 64)
+		if err != nil {
+			break
+		}
+		for i := range stats.Conns {
+			conn := &stats.Conns[i]
+			if conn.ID != id {
+				continue
+			}
+			if v, ok := counterValue(conn, elems[3]); ok {
+				return v, nil
+			}
+			for _, o := range connObjects {
+				if o.name == elems[3] {
+					return o.value(conn), nil
+				}
+			}
+		}
+	}
+	return nil, verror.New(errNoObject, nil, name)
+}
+
+func counterValue(s *flow.ConnStats, name string) (int64, bool) {
+	for _, c := range counters {
+		if c.name == name {
+			return c.value(s), true
+		}
+	}
+	return 0, false
+}
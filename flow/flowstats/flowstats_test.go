@@ -0,0 +1,105 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flowstats_test
+
+import (
+	"testing"
+	"time"
+
+	"v.io/v23/flow"
+	"v.io/v23/flow/flowstats"
+	"v.io/v23/naming"
+)
+
+type fakeManager struct {
+	stats flow.ManagerStats
+}
+
+func (m *fakeManager) Stats() flow.ManagerStats { return m.stats }
+
+func TestSource(t *testing.T) {
+	ep, err := naming.ParseEndpoint("@6@tcp@foo.com:1234@@00112233445566778899aabbccddeeff@m@@@")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1 := flow.ConnStats{
+		ID:             1,
+		RemoteEndpoint: ep,
+		BytesSent:      100,
+		MessagesSent:   2,
+		ActiveFlows:    3,
+		TotalFlows:     5,
+		RTT:            2 * time.Millisecond,
+		Mtu:            1 << 16,
+		SharedTokens:   1 << 20,
+	}
+	c2 := flow.ConnStats{ID: 7, BytesSent: 50, FlowControlStalls: 4}
+	m := &fakeManager{flow.ManagerStats{
+		Conns:      []flow.ConnStats{c1, c2},
+		TotalConns: 3,
+		Reconnects: 1,
+	}}
+	m.stats.Total.Add(c1)
+	m.stats.Total.Add(c2)
+	// A closed connection still counts towards the total.
+	m.stats.Total.Add(flow.ConnStats{BytesSent: 25})
+
+	src := flowstats.NewSource(m)
+	names := src.Names()
+	if got, want := len(names), 3+8+2*(8+5); got != want {
+		t.Errorf("got %d names, want %d: %v", got, want, names)
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i-1] >= names[i] {
+			t.Errorf("names are not sorted: %v", names)
+			break
+		}
+	}
+
+	tests := []struct {
+		name string
+		want interface{}
+	}{
+		{"flow/manager/conns", int64(2)},
+		{"flow/manager/totalConns", int64(3)},
+		{"flow/manager/reconnects", int64(1)},
+		{"flow/total/bytesSent", int64(175)},
+		{"flow/total/activeFlows", int64(3)},
+		{"flow/total/flowControlStalls", int64(4)},
+		{"flow/conns/1/bytesSent", int64(100)},
+		{"flow/conns/1/totalFlows", int64(5)},
+		{"flow/conns/1/rtt", int64(2000)},
+		{"flow/conns/1/mtu", int64(1 << 16)},
+		{"flow/conns/1/sharedTokens", int64(1 << 20)},
+		{"flow/conns/1/remoteEndpoint", ep.String()},
+		{"flow/conns/1/localEndpoint", ""},
+		{"flow/conns/7/flowControlStalls", int64(4)},
+	}
+	for _, test := range tests {
+		got, err := src.Value(test.name)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	for _, name := range []string{"flow", "flow/conns/1", "flow/conns/2/bytesSent", "flow/total/rtt", "flow/manager/foo"} {
+		if _, err := src.Value(name); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// The connections are reported as they come and go.
+	m.stats.Conns = m.stats.Conns[1:]
+	if _, err := src.Value("flow/conns/1/bytesSent"); err == nil {
+		t.Errorf("expected an error for a closed connection")
+	}
+	if got := len(src.Names()); got != 3+8+8+5 {
+		t.Errorf("got %d names, want %d", got, 3+8+8+5)
+	}
+}
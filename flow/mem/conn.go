@@ -0,0 +1,155 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mem
+
+import (
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"v.io/v23/verror"
+)
+
+// newPipe returns the two ends of a Conn between the given addresses, subject
+// to the given conditions.
+func newPipe(local, remote addr, c Conditions, r *rand.Rand) (*conn, *conn) {
+	size := c.BufferSize
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	ab, ba := newQueue(size, c, r.Int63()), newQueue(size, c, r.Int63())
+	return &conn{local, ba, ab}, &conn{remote, ab, ba}
+}
+
+// conn is one end of an in-memory Conn.  Messages written to out are read
+// from the in queue of the other end.
+type conn struct {
+	local   addr
+	in, out *queue
+}
+
+// WriteMsg implements flow.MsgWriter.  The buffers are sent as a single
+// message.
+func (c *conn) WriteMsg(data ...[]byte) (int, error) {
+	var size int
+	for _, d := range data {
+		size += len(d)
+	}
+	msg := make([]byte, 0, size)
+	for _, d := range data {
+		msg = append(msg, d...)
+	}
+	if err := c.out.put(msg); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// ReadMsg implements flow.MsgReader.  It returns the messages written by the
+// other end, one at a time, and io.EOF once the other end is closed and all
+// its messages have been read.
+func (c *conn) ReadMsg() ([]byte, error) {
+	return c.in.get()
+}
+
+// Close implements flow.MsgReadWriteCloser.
+func (c *conn) Close() error {
+	c.out.close()
+	c.in.close()
+	return nil
+}
+
+// LocalAddr implements flow.Conn.
+func (c *conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+type message struct {
+	data      []byte
+	deliverAt time.Time
+}
+
+// queue is a bounded queue of messages sent in one direction of a Conn.
+type queue struct {
+	conditions Conditions
+	size       int
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	rand     *rand.Rand // GUARDED_BY(mu)
+	msgs     []message  // GUARDED_BY(mu)
+	closed   bool       // GUARDED_BY(mu)
+	nextFree time.Time  // GUARDED_BY(mu), when the simulated link is next idle.
+}
+
+func newQueue(size int, c Conditions, seed int64) *queue {
+	q := &queue{
+		conditions: c,
+		size:       size,
+		rand:       rand.New(rand.NewSource(seed)),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// put adds data to the queue, blocking while the queue is full.
+func (q *queue) put(data []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for !q.closed && len(q.msgs) >= q.size {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return verror.New(errConnClosed, nil)
+	}
+	if q.conditions.Loss > 0 && q.rand.Float64() < q.conditions.Loss {
+		return nil
+	}
+	now := time.Now()
+	sent := now
+	if q.conditions.Bandwidth > 0 {
+		if q.nextFree.After(sent) {
+			sent = q.nextFree
+		}
+		sent = sent.Add(time.Duration(int64(len(data)) * int64(time.Second) / q.conditions.Bandwidth))
+		q.nextFree = sent
+	}
+	q.msgs = append(q.msgs, message{data, sent.Add(q.conditions.Latency)})
+	q.cond.Broadcast()
+	return nil
+}
+
+// get removes the next message from the queue, blocking until one is
+// available and due for delivery.
+func (q *queue) get() ([]byte, error) {
+	q.mu.Lock()
+	for !q.closed && len(q.msgs) == 0 {
+		q.cond.Wait()
+	}
+	if len(q.msgs) == 0 {
+		q.mu.Unlock()
+		return nil, io.EOF
+	}
+	msg := q.msgs[0]
+	q.msgs[0] = message{}
+	q.msgs = q.msgs[1:]
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	if d := msg.deliverAt.Sub(time.Now()); d > 0 {
+		time.Sleep(d)
+	}
+	return msg.data, nil
+}
+
+// close closes the queue.  Blocked and subsequent puts fail, but queued
+// messages can still be read.
+func (q *queue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+}
@@ -0,0 +1,287 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mem implements an in-memory flow.Protocol, registered as "mem".
+//
+// Conns dialed with the mem protocol are connected to the Listener of the
+// same address within the process, without using the network, so that clients
+// and servers can use the full flow stack inside a single process.  This is
+// useful for hermetic tests and for co-located components.
+//
+// Addresses have the form "<namespace>/<name>".  Each namespace is a separate
+// address space, so that, for example, tests running in parallel can use
+// their own namespace without conflicting over names.  An address without a
+// "/" is in the default namespace "".  Listening on an address with an empty
+// name, e.g. "test/", chooses an unused name, much like listening on TCP port
+// 0.
+//
+// Every namespace can be configured with Conditions that add latency, limit
+// bandwidth and drop messages, to test how the stack behaves over poor
+// networks.
+package mem
+
+import (
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/flow"
+	"v.io/v23/verror"
+)
+
+// Name is the name under which the protocol is registered.
+const Name = "mem"
+
+const pkgPath = "v.io/v23/flow/mem"
+
+var (
+	errNoListener     = verror.Register(pkgPath+".errNoListener", verror.NoRetry, "{1:}{2:} no listener at {3}{:_}")
+	errAddressInUse   = verror.Register(pkgPath+".errAddressInUse", verror.NoRetry, "{1:}{2:} address {3} is already in use{:_}")
+	errListenerClosed = verror.Register(pkgPath+".errListenerClosed", verror.NoRetry, "{1:}{2:} listener is closed{:_}")
+	errConnClosed     = verror.Register(pkgPath+".errConnClosed", verror.NoRetry, "{1:}{2:} connection is closed{:_}")
+	errDialTimeout    = verror.Register(pkgPath+".errDialTimeout", verror.NoRetry, "{1:}{2:} timed out dialing {3}{:_}")
+)
+
+// DefaultBufferSize is the number of messages that may be buffered in each
+// direction of a Conn before writes block, if not set by Conditions.
+const DefaultBufferSize = 64
+
+// backlog is the number of dialed Conns that may wait to be accepted by a
+// Listener before Dial blocks.
+const backlog = 16
+
+// Conditions describe the simulated network of a namespace.  The zero value
+// describes a perfect network.
+type Conditions struct {
+	// Latency is the one-way delay of each message.
+	Latency time.Duration
+	// Bandwidth is the number of bytes per second that may be sent in each
+	// direction of a Conn, or zero for unlimited bandwidth.
+	Bandwidth int64
+	// Loss is the probability, between 0 and 1, that a message is dropped.
+	// Since the flow stack expects conns to be reliable, loss is typically
+	// used to test how failures are handled.
+	Loss float64
+	// Seed seeds the random number generator used to decide which messages are
+	// dropped, so that lossy tests are repeatable.
+	Seed int64
+	// BufferSize is the number of messages that may be buffered in each
+	// direction of a Conn before writes block.  DefaultBufferSize is used if
+	// it is zero.
+	BufferSize int
+}
+
+func init() {
+	flow.RegisterProtocol(Name, Default)
+}
+
+// Default is the Protocol registered as "mem".
+var Default = NewProtocol()
+
+// Protocol is an in-memory flow.Protocol.  Each Protocol has its own set of
+// namespaces; Conns can only be dialed to Listeners of the same Protocol.
+type Protocol struct {
+	mu         sync.Mutex
+	namespaces map[string]*namespace // GUARDED_BY(mu)
+}
+
+// NewProtocol returns a new Protocol with no listeners.
+func NewProtocol() *Protocol {
+	return &Protocol{namespaces: make(map[string]*namespace)}
+}
+
+type namespace struct {
+	conditions Conditions
+	rand       *rand.Rand // seeds the random number generators of new Conns.
+	listeners  map[string]*listener
+	nextName   int
+}
+
+// namespaceLocked returns the named namespace, creating it if necessary.
+// REQUIRES: p.mu is held.
+func (p *Protocol) namespaceLocked(name string) *namespace {
+	ns := p.namespaces[name]
+	if ns == nil {
+		ns = &namespace{
+			rand:      rand.New(rand.NewSource(0)),
+			listeners: make(map[string]*listener),
+		}
+		p.namespaces[name] = ns
+	}
+	return ns
+}
+
+// SetConditions sets the conditions of the named namespace.  They apply to
+// Conns dialed after the call.
+func (p *Protocol) SetConditions(namespace string, c Conditions) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ns := p.namespaceLocked(namespace)
+	ns.conditions = c
+	ns.rand = rand.New(rand.NewSource(c.Seed))
+}
+
+// Conditions returns the conditions of the named namespace.
+func (p *Protocol) Conditions(namespace string) Conditions {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ns := p.namespaces[namespace]; ns != nil {
+		return ns.conditions
+	}
+	return Conditions{}
+}
+
+// splitAddress returns the namespace and name of address.
+func splitAddress(address string) (string, string) {
+	if i := strings.Index(address, "/"); i >= 0 {
+		return address[:i], address[i+1:]
+	}
+	return "", address
+}
+
+func joinAddress(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// Dial implements flow.Protocol.
+func (p *Protocol) Dial(ctx *context.T, protocol, address string, timeout time.Duration) (flow.Conn, error) {
+	nsName, name := splitAddress(address)
+	p.mu.Lock()
+	ns := p.namespaceLocked(nsName)
+	ln := ns.listeners[name]
+	if ln == nil {
+		p.mu.Unlock()
+		return nil, verror.New(errNoListener, ctx, address)
+	}
+	ns.nextName++
+	local := addr{protocol, joinAddress(nsName, "@"+strconv.Itoa(ns.nextName))}
+	client, server := newPipe(local, ln.addr, ns.conditions, ns.rand)
+	// Close waits for the Dial to be done before draining the conns that
+	// weren't accepted.
+	ln.dials.Add(1)
+	defer ln.dials.Done()
+	p.mu.Unlock()
+
+	var timer <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+	var err error
+	select {
+	case ln.conns <- server:
+		return client, nil
+	case <-ln.closed:
+		err = verror.New(errNoListener, ctx, address)
+	case <-ctx.Done():
+		err = verror.New(errDialTimeout, ctx, address, ctx.Err())
+	case <-timer:
+		err = verror.New(errDialTimeout, ctx, address)
+	}
+	client.Close()
+	server.Close()
+	return nil, err
+}
+
+// Resolve implements flow.Protocol.  Addresses resolve to themselves.
+func (p *Protocol) Resolve(_ *context.T, protocol, address string) (string, []string, error) {
+	return protocol, []string{address}, nil
+}
+
+// Listen implements flow.Protocol.
+func (p *Protocol) Listen(ctx *context.T, protocol, address string) (flow.Listener, error) {
+	nsName, name := splitAddress(address)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ns := p.namespaceLocked(nsName)
+	if name == "" {
+		for name == "" || ns.listeners[name] != nil {
+			ns.nextName++
+			name = strconv.Itoa(ns.nextName)
+		}
+	}
+	if ns.listeners[name] != nil {
+		return nil, verror.New(errAddressInUse, ctx, address)
+	}
+	ln := &listener{
+		p:      p,
+		ns:     ns,
+		name:   name,
+		addr:   addr{protocol, joinAddress(nsName, name)},
+		conns:  make(chan flow.Conn, backlog),
+		closed: make(chan struct{}),
+	}
+	ns.listeners[name] = ln
+	return ln, nil
+}
+
+type listener struct {
+	p      *Protocol
+	ns     *namespace
+	name   string
+	addr   addr
+	conns  chan flow.Conn
+	closed chan struct{}
+	once   sync.Once
+	// dials are the Dials in progress, which may still send on conns.
+	dials sync.WaitGroup
+}
+
+// Accept implements flow.Listener.
+func (l *listener) Accept(ctx *context.T) (flow.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+		return nil, verror.New(errListenerClosed, ctx)
+	case <-ctx.Done():
+		return nil, verror.New(errListenerClosed, ctx, ctx.Err())
+	}
+}
+
+// Addr implements flow.Listener.
+func (l *listener) Addr() net.Addr {
+	return l.addr
+}
+
+// Close implements flow.Listener.  Conns that were dialed but not accepted
+// are closed.
+func (l *listener) Close() error {
+	l.once.Do(func() {
+		l.p.mu.Lock()
+		if l.ns.listeners[l.name] == l {
+			delete(l.ns.listeners, l.name)
+		}
+		close(l.closed)
+		l.p.mu.Unlock()
+		// No Dial starts once the listener is removed, and the Dials in
+		// progress return promptly once closed is closed.
+		l.dials.Wait()
+		for {
+			select {
+			case c := <-l.conns:
+				c.Close()
+			default:
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// addr is the net.Addr of mem Conns and Listeners.
+type addr struct {
+	network, address string
+}
+
+func (a addr) Network() string { return a.network }
+func (a addr) String() string  { return a.address }
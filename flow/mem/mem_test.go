@@ -0,0 +1,254 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mem_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/flow"
+	"v.io/v23/flow/mem"
+	"v.io/v23/verror"
+)
+
+// pipe returns the dialed and accepted ends of a Conn to address.
+func pipe(t *testing.T, ctx *context.T, p flow.Protocol, address string) (flow.Conn, flow.Conn, flow.Listener) {
+	ln, err := p.Listen(ctx, mem.Name, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := p.Dial(ctx, mem.Name, ln.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ln.Accept(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, server, ln
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	p, _ := flow.RegisteredProtocol(mem.Name)
+	if p != mem.Default {
+		t.Fatalf("got protocol %v, want mem.Default", p)
+	}
+	client, server, ln := pipe(t, ctx, mem.NewProtocol(), "roundtrip/server")
+	defer ln.Close()
+
+	if got, want := server.LocalAddr().String(), "roundtrip/server"; got != want {
+		t.Errorf("got server address %q, want %q", got, want)
+	}
+	if n, err := client.WriteMsg([]byte("hello, "), []byte("world")); n != 12 || err != nil {
+		t.Fatalf("got (%d, %v), want (12, nil)", n, err)
+	}
+	if _, err := server.WriteMsg([]byte("reply")); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		c    flow.Conn
+		want string
+	}{{server, "hello, world"}, {client, "reply"}} {
+		if got, err := test.c.ReadMsg(); err != nil || string(got) != test.want {
+			t.Errorf("got (%q, %v), want %q", got, err, test.want)
+		}
+	}
+
+	// Messages written before Close can still be read, then ReadMsg returns
+	// io.EOF and writes fail.
+	if _, err := client.WriteMsg([]byte("last")); err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	if got, err := server.ReadMsg(); err != nil || string(got) != "last" {
+		t.Errorf("got (%q, %v), want \"last\"", got, err)
+	}
+	if _, err := server.ReadMsg(); err != io.EOF {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+	if _, err := server.WriteMsg([]byte("x")); err == nil {
+		t.Errorf("expected an error writing to a closed conn")
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	p := mem.NewProtocol()
+
+	a, err := p.Listen(ctx, mem.Name, "a/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := p.Listen(ctx, mem.Name, "b/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if got, want := a.Addr().String(), "a/1"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := b.Addr().String(), "b/1"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if _, err := p.Listen(ctx, mem.Name, "a/1"); err == nil {
+		t.Errorf("expected an error listening on an address in use")
+	}
+	if _, err := p.Dial(ctx, mem.Name, "c/1", time.Second); err == nil {
+		t.Errorf("expected an error dialing an address without a listener")
+	}
+	// Other protocols don't share the listeners.
+	if _, err := mem.NewProtocol().Dial(ctx, mem.Name, "a/1", time.Second); err == nil {
+		t.Errorf("expected an error dialing a listener of another protocol")
+	}
+
+	// Closing the listener frees the address and fails Accept.
+	a.Close()
+	if _, err := a.Accept(ctx); err == nil {
+		t.Errorf("expected an error accepting from a closed listener")
+	}
+	if _, err := p.Dial(ctx, mem.Name, "a/1", time.Second); err == nil {
+		t.Errorf("expected an error dialing a closed listener")
+	}
+	a, err = p.Listen(ctx, mem.Name, "a/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+}
+
+func TestDialTimeout(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	p := mem.NewProtocol()
+
+	ln, err := p.Listen(ctx, mem.Name, "timeout/server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	// Fill the backlog; a dial then times out since nothing is accepted.
+	var err2 error
+	for i := 0; i < 100 && err2 == nil; i++ {
+		_, err2 = p.Dial(ctx, mem.Name, "timeout/server", 10*time.Millisecond)
+	}
+	if err2 == nil || verror.ErrorID(err2) != "v.io/v23/flow/mem.errDialTimeout" {
+		t.Errorf("got %v, want a dial timeout", err2)
+	}
+}
+
+func TestCloseWhileDialing(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	p := mem.NewProtocol()
+
+	ln, err := p.Listen(ctx, mem.Name, "close/server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Dial more conns than the backlog holds, so that some Dials block
+	// until the listener is closed.
+	const dials = 100
+	conns := make(chan flow.Conn, dials)
+	for i := 0; i < dials; i++ {
+		go func() {
+			c, _ := p.Dial(ctx, mem.Name, "close/server", 0)
+			conns <- c
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	ln.Close()
+	// Every conn that was dialed was closed by the listener.
+	for i := 0; i < dials; i++ {
+		c := <-conns
+		if c == nil {
+			continue
+		}
+		read := make(chan error, 1)
+		go func() {
+			_, err := c.ReadMsg()
+			read <- err
+		}()
+		select {
+		case err := <-read:
+			if err == nil {
+				t.Errorf("read from a conn that wasn't accepted")
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("conn dialed to a closed listener is still open")
+		}
+	}
+}
+
+func TestConditions(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	p := mem.NewProtocol()
+
+	const latency = 50 * time.Millisecond
+	p.SetConditions("slow", mem.Conditions{Latency: latency, Bandwidth: 1000})
+	if got := p.Conditions("slow").Latency; got != latency {
+		t.Errorf("got latency %v, want %v", got, latency)
+	}
+	client, server, ln := pipe(t, ctx, p, "slow/server")
+	defer ln.Close()
+
+	// 100 bytes take 100ms at 1000 bytes per second, plus the latency.
+	start := time.Now()
+	if _, err := client.WriteMsg(make([]byte, 100)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.ReadMsg(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := time.Since(start), 150*time.Millisecond; got < want {
+		t.Errorf("message delivered after %v, want at least %v", got, want)
+	}
+}
+
+func TestLoss(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	// received returns the messages that arrive when 100 messages are sent
+	// over a lossy conn.
+	received := func(seed int64) []byte {
+		p := mem.NewProtocol()
+		p.SetConditions("lossy", mem.Conditions{Loss: 0.5, Seed: seed, BufferSize: 100})
+		client, server, ln := pipe(t, ctx, p, "lossy/server")
+		defer ln.Close()
+		for i := 0; i < 100; i++ {
+			if _, err := client.WriteMsg([]byte{byte(i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		client.Close()
+		var got []byte
+		for {
+			msg, err := server.ReadMsg()
+			if err == io.EOF {
+				return got
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, msg...)
+		}
+	}
+	a, b := received(1), received(1)
+	if !bytes.Equal(a, b) {
+		t.Errorf("the same seed dropped different messages: %v and %v", a, b)
+	}
+	if len(a) < 25 || len(a) > 75 {
+		t.Errorf("received %d of 100 messages with a loss of 0.5", len(a))
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package message_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"v.io/v23/context"
+	"v.io/v23/flow/message"
+)
+
+// The fuzz targets below feed arbitrary bytes to Read, starting from the
+// encodings in the golden file.  Read must not panic, and any message it
+// accepts must survive being encoded and read again.  To fuzz, for example,
+// Setup messages:
+//
+//   go test v.io/v23/flow/message -run NONE -fuzz FuzzSetup
+//
+// Inputs that fail are added to testdata/fuzz and are then run by go test.
+
+// Message types, as encoded in the first byte of each message.
+const (
+	setupType               = 0x7e
+	tearDownType            = 0x7d
+	enterLameDuckType       = 0x7c
+	ackLameDuckType         = 0x7b
+	authType                = 0x7a
+	openFlowType            = 0x79
+	releaseType             = 0x78
+	dataType                = 0x77
+	multiProxyType          = 0x76
+	proxyServerType         = 0x75
+	proxyResponseType       = 0x74
+	healthCheckRequestType  = 0x73
+	healthCheckResponseType = 0x72
+	proxyErrorResponseType  = 0x71
+)
+
+func FuzzRead(f *testing.F) {
+	fuzzMessage(f, -1)
+}
+
+func FuzzSetup(f *testing.F)               { fuzzMessage(f, setupType) }
+func FuzzTearDown(f *testing.F)            { fuzzMessage(f, tearDownType) }
+func FuzzEnterLameDuck(f *testing.F)       { fuzzMessage(f, enterLameDuckType) }
+func FuzzAckLameDuck(f *testing.F)         { fuzzMessage(f, ackLameDuckType) }
+func FuzzAuth(f *testing.F)                { fuzzMessage(f, authType) }
+func FuzzOpenFlow(f *testing.F)            { fuzzMessage(f, openFlowType) }
+func FuzzRelease(f *testing.F)             { fuzzMessage(f, releaseType) }
+func FuzzData(f *testing.F)                { fuzzMessage(f, dataType) }
+func FuzzMultiProxyRequest(f *testing.F)   { fuzzMessage(f, multiProxyType) }
+func FuzzProxyServerRequest(f *testing.F)  { fuzzMessage(f, proxyServerType) }
+func FuzzProxyResponse(f *testing.F)       { fuzzMessage(f, proxyResponseType) }
+func FuzzProxyErrorResponse(f *testing.F)  { fuzzMessage(f, proxyErrorResponseType) }
+func FuzzHealthCheckRequest(f *testing.F)  { fuzzMessage(f, healthCheckRequestType) }
+func FuzzHealthCheckResponse(f *testing.F) { fuzzMessage(f, healthCheckResponseType) }
+
+// fuzzMessage fuzzes messages of the given type, or of any type if msgType
+// is negative.  For a single type, the fuzzed bytes are the body of the
+// message, which follows the type byte.
+func fuzzMessage(f *testing.F, msgType int) {
+	names, golden := readGolden(f)
+	for _, name := range names {
+		data := golden[name]
+		switch {
+		case msgType < 0:
+			f.Add(data)
+		case len(data) > 0 && int(data[0]) == msgType:
+			f.Add(data[1:])
+		}
+	}
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if msgType >= 0 {
+			data = append([]byte{byte(msgType)}, data...)
+		}
+		m, err := message.Read(ctx, data)
+		if err != nil {
+			return
+		}
+		// Messages are logged, so formatting them must not panic either.
+		_ = fmt.Sprint(m)
+		encoded, err := message.Append(ctx, m, nil)
+		if err != nil {
+			t.Fatalf("Append(%#v) failed: %v", m, err)
+		}
+		got, err := message.Read(ctx, encoded)
+		if err != nil {
+			t.Fatalf("Read(%x), the encoding of %#v, failed: %v", encoded, m, err)
+		}
+		if !reflect.DeepEqual(got, m) {
+			t.Fatalf("got %#v after encoding %#v as %x", got, m, encoded)
+		}
+	})
+}
@@ -0,0 +1,249 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package message_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"v.io/v23/context"
+	"v.io/v23/flow/message"
+	"v.io/v23/naming"
+	"v.io/v23/rpc/version"
+	"v.io/v23/security"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/golden.txt from the messages in goldenCases")
+
+const goldenFile = "testdata/golden.txt"
+
+// unknownOptions are Setup options that are not known to this package:
+// option 7777 with payload "wat" and option 100 with an empty payload.
+var unknownOptions = []byte{0xfe, 0x1e, 0x61, 0x03, 'w', 'a', 't', 0x64, 0x00}
+
+type goldenCase struct {
+	name string
+	msg  message.Message
+	// extra is appended to the encoding of msg.
+	extra []byte
+}
+
+func goldenCases(t testing.TB) []goldenCase {
+	ep1, err := naming.ParseEndpoint("@6@tcp@foo.com:1234@a,b@00112233445566778899aabbccddeeff@m@v.io/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ep2, err := naming.ParseEndpoint("@6@unix@/tmp/bar.sock@@00112233445566778899aabbccddeeff@s@v.io/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key [32]byte
+	for i := range key {
+		key[i] = byte(i)
+	}
+	sig := security.Signature{
+		Purpose: []byte("purpose"),
+		Hash:    security.SHA256Hash,
+		R:       bytes.Repeat([]byte{0xaa}, 32),
+		S:       bytes.Repeat([]byte{0xbb}, 32),
+	}
+	versions := version.RPCVersionRange{Min: 10, Max: 14}
+	return []goldenCase{
+		{"setup-empty", &message.Setup{}, nil},
+		{"setup-versions", &message.Setup{Versions: versions}, nil},
+		{"setup-full", &message.Setup{
+			Versions:           versions,
+			PeerNaClPublicKey:  &key,
+			PeerRemoteEndpoint: ep1,
+			PeerLocalEndpoint:  ep2,
+			Mtu:                1 << 16,
+			SharedTokens:       1 << 20,
+		}, nil},
+		{"setup-priorities", &message.Setup{
+			Versions:             versions,
+			SharedTokens:         1 << 20,
+			PriorityWeights:      []uint64{4, 1, 16},
+			PrioritySharedTokens: []uint64{1 << 18, 1 << 16, 1 << 18},
+		}, nil},
+		{"setup-unknown-options", &message.Setup{Versions: versions, Mtu: 1 << 16}, unknownOptions},
+		{"teardown-empty", &message.TearDown{}, nil},
+		{"teardown", &message.TearDown{Message: "going away"}, nil},
+		{"enter-lame-duck", &message.EnterLameDuck{}, nil},
+		{"ack-lame-duck", &message.AckLameDuck{}, nil},
+		{"auth", &message.Auth{BlessingsKey: 1, DischargeKey: 5, ChannelBinding: sig}, nil},
+		{"open-flow", &message.OpenFlow{
+			ID:              23,
+			InitialCounters: 1 << 20,
+			BlessingsKey:    42,
+			DischargeKey:    55,
+			Flags:           message.CloseFlag | message.SideChannelFlag,
+			Payload:         [][]byte{[]byte("payload")},
+		}, nil},
+		{"open-flow-priority", &message.OpenFlow{
+			ID:              25,
+			InitialCounters: 1 << 20,
+			BlessingsKey:    42,
+			Flags:           message.CloseFlag,
+			Priority:        2,
+			Payload:         [][]byte{[]byte("payload")},
+		}, nil},
+		{"open-flow-no-payload", &message.OpenFlow{ID: 23, InitialCounters: 1 << 20, BlessingsKey: 42}, nil},
+		{"release-empty", &message.Release{}, nil},
+		{"release", &message.Release{Counters: map[uint64]uint64{4: 233}}, nil},
+		{"data", &message.Data{ID: 1123, Flags: message.CloseFlag, Payload: [][]byte{[]byte("payload")}}, nil},
+		{"data-unencrypted", &message.Data{ID: 1123, Flags: message.DisableEncryptionFlag}, nil},
+		{"multi-proxy-request", &message.MultiProxyRequest{}, nil},
+		{"proxy-server-request", &message.ProxyServerRequest{}, nil},
+		{"proxy-response-empty", &message.ProxyResponse{}, nil},
+		{"proxy-response", &message.ProxyResponse{Endpoints: []naming.Endpoint{ep1, ep2}}, nil},
+		{"proxy-error-response", &message.ProxyErrorResponse{Error: "error"}, nil},
+		{"health-check-request", &message.HealthCheckRequest{}, nil},
+		{"health-check-response", &message.HealthCheckResponse{}, nil},
+	}
+}
+
+// readGolden returns the encodings in the golden file, in order, and by name.
+func readGolden(t testing.TB) ([]string, map[string][]byte) {
+	f, err := os.Open(goldenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var names []string
+	encodings := make(map[string][]byte)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			t.Fatalf("invalid line in %s: %q", goldenFile, line)
+		}
+		data, err := hex.DecodeString(fields[1])
+		if err != nil {
+			t.Fatalf("invalid line in %s: %q: %v", goldenFile, line, err)
+		}
+		names = append(names, fields[0])
+		encodings[fields[0]] = data
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return names, encodings
+}
+
+func writeGolden(t *testing.T, cases []goldenCase, encodings map[string][]byte) {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "# Wire encodings of flow messages, checked by TestGolden.")
+	fmt.Fprintln(&buf, "# Each line is \"<name> <hex encoding>\".  Regenerate with:")
+	fmt.Fprintln(&buf, "#   go test v.io/v23/flow/message -run TestGolden -update")
+	for _, c := range cases {
+		fmt.Fprintf(&buf, "%s %x\n", c.name, encodings[c.name])
+	}
+	if err := ioutil.WriteFile(goldenFile, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestGolden checks that messages are encoded as recorded in the golden file,
+// and that the recorded encodings are read and re-encoded unchanged.  Any
+// change to the wire format must be made deliberately, by regenerating the
+// golden file.
+func TestGolden(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	cases := goldenCases(t)
+	encodings := make(map[string][]byte)
+	for _, c := range cases {
+		data, err := message.Append(ctx, c.msg, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		encodings[c.name] = append(data, c.extra...)
+	}
+	if *update {
+		writeGolden(t, cases, encodings)
+	}
+
+	_, golden := readGolden(t)
+	for _, c := range cases {
+		want, ok := golden[c.name]
+		if !ok {
+			t.Errorf("%s: missing from %s", c.name, goldenFile)
+			continue
+		}
+		if got := encodings[c.name]; !bytes.Equal(got, want) {
+			t.Errorf("%s: got encoding %x, want %x", c.name, got, want)
+		}
+		m, err := message.Read(ctx, want)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if c.extra == nil && !reflect.DeepEqual(m, c.msg) {
+			t.Errorf("%s: got %#v, want %#v", c.name, m, c.msg)
+		}
+		if got, err := message.Append(ctx, m, nil); err != nil || !bytes.Equal(got, want) {
+			t.Errorf("%s: got re-encoding (%x, %v), want %x", c.name, got, err, want)
+		}
+	}
+}
+
+// TestUnknownSetupOptions checks that Setup options that are not known to
+// this package are preserved, so that a peer that relays a Setup message
+// doesn't drop options introduced by newer versions of the protocol.
+// Unknown options are re-emitted in their original order, after the known
+// options.
+func TestUnknownSetupOptions(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	_, golden := readGolden(t)
+	canonical := golden["setup-unknown-options"]
+	// The message type and versions 10 and 14 are encoded in 3 bytes,
+	// followed by the mtu option and the unknown options.
+	if len(canonical) < 3 || !bytes.HasSuffix(canonical, unknownOptions) {
+		t.Fatalf("unexpected golden encoding %x", canonical)
+	}
+	header := canonical[:3]
+	mtu := canonical[3 : len(canonical)-len(unknownOptions)]
+
+	for _, data := range [][]byte{
+		concat(header, mtu, unknownOptions),
+		concat(header, unknownOptions, mtu),
+		concat(header, unknownOptions[:7], mtu, unknownOptions[7:]),
+	} {
+		m, err := message.Read(ctx, data)
+		if err != nil {
+			t.Errorf("%x: %v", data, err)
+			continue
+		}
+		if got := m.(*message.Setup).Mtu; got != 1<<16 {
+			t.Errorf("%x: got mtu %d, want %d", data, got, 1<<16)
+		}
+		if got, err := message.Append(ctx, m, nil); err != nil || !bytes.Equal(got, canonical) {
+			t.Errorf("%x: got re-encoding (%x, %v), want %x", data, got, err, canonical)
+		}
+	}
+}
+
+func concat(slices ...[]byte) []byte {
+	var out []byte
+	for _, s := range slices {
+		out = append(out, s...)
+	}
+	return out
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	"v.io/v23/context"
+	"v.io/v23/flow"
 	"v.io/v23/naming"
 	"v.io/v23/rpc/version"
 	"v.io/v23/security"
@@ -101,6 +102,8 @@ const (
 	peerLocalEndpointOption
 	mtuOption
 	sharedTokensOption
+	priorityWeightsOption
+	prioritySharedTokensOption
 )
 
 // data flags.
@@ -122,6 +125,10 @@ const (
 	// as a side channel. This means that the flow will not be counted
 	// towards the "idleness" of the underlying connection.
 	SideChannelFlag
+	// priorityFlag, when set on an OpenFlow message, indicates that the
+	// priority of the flow follows the flags.  It is set by Append when the
+	// Priority is non-zero, and cleared by Read.
+	priorityFlag
 )
 
 // random consts.
@@ -134,12 +141,20 @@ const (
 // New fields to Setup must be added in order of creation. i.e. the order of the fields
 // should not be changed.
 type Setup struct {
-	Versions             version.RPCVersionRange
-	PeerNaClPublicKey    *[32]byte
-	PeerRemoteEndpoint   naming.Endpoint
-	PeerLocalEndpoint    naming.Endpoint
-	Mtu                  uint64
-	SharedTokens         uint64
+	Versions           version.RPCVersionRange
+	PeerNaClPublicKey  *[32]byte
+	PeerRemoteEndpoint naming.Endpoint
+	PeerLocalEndpoint  naming.Endpoint
+	Mtu                uint64
+	SharedTokens       uint64
+	// PriorityWeights are the weights of the priority classes, indexed by
+	// priority, with which the sender schedules the flows of the connection.
+	// They are only sent by peers that support RPCVersion15.
+	PriorityWeights []uint64
+	// PrioritySharedTokens are the numbers of SharedTokens reserved for the
+	// flows of each priority class, indexed by priority, so that flows of one
+	// class can't exhaust the shared tokens of the others.
+	PrioritySharedTokens []uint64
 	uninterpretedOptions []option
 }
 
@@ -183,6 +198,12 @@ func (m *Setup) append(ctx *context.T, data []byte) ([]byte, error) {
 	if m.SharedTokens != 0 {
 		data = appendSetupOption(sharedTokensOption, writeVarUint64(m.SharedTokens, nil), data)
 	}
+	if len(m.PriorityWeights) > 0 {
+		data = appendSetupOption(priorityWeightsOption, writeVarUint64s(m.PriorityWeights, nil), data)
+	}
+	if len(m.PrioritySharedTokens) > 0 {
+		data = appendSetupOption(prioritySharedTokensOption, writeVarUint64s(m.PrioritySharedTokens, nil), data)
+	}
 	for _, o := range m.uninterpretedOptions {
 		data = appendSetupOption(o.opt, o.payload, data)
 	}
@@ -231,6 +252,14 @@ func (m *Setup) read(ctx *context.T, orig []byte) error {
 			} else {
 				return NewErrInvalidSetupOption(ctx, opt, field)
 			}
+		case priorityWeightsOption:
+			if m.PriorityWeights, valid = readVarUint64s(ctx, payload); !valid {
+				return NewErrInvalidSetupOption(ctx, opt, field)
+			}
+		case prioritySharedTokensOption:
+			if m.PrioritySharedTokens, valid = readVarUint64s(ctx, payload); !valid {
+				return NewErrInvalidSetupOption(ctx, opt, field)
+			}
 		default:
 			m.uninterpretedOptions = append(m.uninterpretedOptions, option{opt, payload})
 		}
@@ -241,12 +270,20 @@ func (m *Setup) read(ctx *context.T, orig []byte) error {
 	return nil
 }
 func (m *Setup) String() string {
-	return fmt.Sprintf("Versions:[%d,%d] PeerNaClPublicKey:%v PeerRemoteEndpoint:%v PeerLocalEndpoint:%v options:%v",
+	var key string
+	if m.PeerNaClPublicKey != nil {
+		key = hex.EncodeToString(m.PeerNaClPublicKey[:])
+	}
+	return fmt.Sprintf("Versions:[%d,%d] PeerNaClPublicKey:%v PeerRemoteEndpoint:%v PeerLocalEndpoint:%v Mtu:%d SharedTokens:%d PriorityWeights:%v PrioritySharedTokens:%v options:%v",
 		m.Versions.Min,
 		m.Versions.Max,
-		hex.EncodeToString(m.PeerNaClPublicKey[:]),
+		key,
 		m.PeerRemoteEndpoint,
 		m.PeerLocalEndpoint,
+		m.Mtu,
+		m.SharedTokens,
+		m.PriorityWeights,
+		m.PrioritySharedTokens,
 		m.uninterpretedOptions)
 }
 
@@ -302,6 +339,11 @@ func (m *Auth) append(ctx *context.T, data []byte) ([]byte, error) {
 	data = appendLenBytes([]byte(m.ChannelBinding.Hash), data)
 	data = appendLenBytes(m.ChannelBinding.R, data)
 	data = appendLenBytes(m.ChannelBinding.S, data)
+	// The Ed25519 signature is only sent when set, so that messages with
+	// ECDSA signatures can be read by older peers.
+	if len(m.ChannelBinding.Ed25519) != 0 {
+		data = appendLenBytes(m.ChannelBinding.Ed25519, data)
+	}
 	return data, nil
 }
 func (m *Auth) read(ctx *context.T, orig []byte) error {
@@ -329,6 +371,11 @@ func (m *Auth) read(ctx *context.T, orig []byte) error {
 	if m.ChannelBinding.S, data, valid = readLenBytes(ctx, data); !valid {
 		return NewErrInvalidMsg(ctx, openFlowType, uint64(len(orig)), 5, nil)
 	}
+	if len(data) > 0 {
+		if m.ChannelBinding.Ed25519, data, valid = readLenBytes(ctx, data); !valid {
+			return NewErrInvalidMsg(ctx, openFlowType, uint64(len(orig)), 6, nil)
+		}
+	}
 	return nil
 }
 
@@ -338,7 +385,11 @@ type OpenFlow struct {
 	InitialCounters            uint64
 	BlessingsKey, DischargeKey uint64
 	Flags                      uint64
-	Payload                    [][]byte
+	// Priority is the priority class of the flow, less than
+	// flow.NumPriorities.  It must be zero unless the common version of the
+	// connection is at least RPCVersion15.
+	Priority uint64
+	Payload  [][]byte
 }
 
 func (m *OpenFlow) append(ctx *context.T, data []byte) ([]byte, error) {
@@ -347,7 +398,12 @@ func (m *OpenFlow) append(ctx *context.T, data []byte) ([]byte, error) {
 	data = writeVarUint64(m.InitialCounters, data)
 	data = writeVarUint64(m.BlessingsKey, data)
 	data = writeVarUint64(m.DischargeKey, data)
-	data = writeVarUint64(m.Flags, data)
+	if m.Priority != 0 {
+		data = writeVarUint64(m.Flags|priorityFlag, data)
+		data = writeVarUint64(m.Priority, data)
+	} else {
+		data = writeVarUint64(m.Flags&^priorityFlag, data)
+	}
 	if m.Flags&DisableEncryptionFlag == 0 {
 		for _, p := range m.Payload {
 			data = append(data, p...)
@@ -378,18 +434,25 @@ func (m *OpenFlow) read(ctx *context.T, orig []byte) error {
 	if m.Flags, data, valid = readVarUint64(ctx, data); !valid {
 		return NewErrInvalidMsg(ctx, dataType, uint64(len(orig)), 1, nil)
 	}
+	if m.Flags&priorityFlag != 0 {
+		m.Flags &^= priorityFlag
+		if m.Priority, data, valid = readVarUint64(ctx, data); !valid || m.Priority >= flow.NumPriorities {
+			return NewErrInvalidMsg(ctx, openFlowType, uint64(len(orig)), 5, nil)
+		}
+	}
 	if m.Flags&DisableEncryptionFlag == 0 && len(data) > 0 {
 		m.Payload = [][]byte{data}
 	}
 	return nil
 }
 func (m *OpenFlow) String() string {
-	return fmt.Sprintf("ID:%d InitialCounters:%d BlessingsKey:0x%x DischargeKey:0x%x Flags:0x%x Payload:(%d bytes in %d slices)",
+	return fmt.Sprintf("ID:%d InitialCounters:%d BlessingsKey:0x%x DischargeKey:0x%x Flags:0x%x Priority:%d Payload:(%d bytes in %d slices)",
 		m.ID,
 		m.InitialCounters,
 		m.BlessingsKey,
 		m.DischargeKey,
 		m.Flags,
+		m.Priority,
 		payloadSize(m.Payload),
 		len(m.Payload))
 }
@@ -433,6 +496,10 @@ func (m *Release) read(ctx *context.T, orig []byte) error {
 }
 
 // Data carries encrypted data for a specific flow.
+//
+// Writers of flows of different priorities are scheduled at the granularity
+// of Data messages, whose payloads are at most Mtu bytes, so Mtu bounds the
+// delay that a write of a low priority flow imposes on higher priorities.
 type Data struct {
 	ID      uint64
 	Flags   uint64
@@ -575,6 +642,26 @@ func appendLenBytes(b []byte, buf []byte) []byte {
 	return append(buf, b...)
 }
 
+func writeVarUint64s(us []uint64, buf []byte) []byte {
+	for _, u := range us {
+		buf = writeVarUint64(u, buf)
+	}
+	return buf
+}
+
+func readVarUint64s(ctx *context.T, data []byte) ([]uint64, bool) {
+	var us []uint64
+	for len(data) > 0 {
+		u, rest, valid := readVarUint64(ctx, data)
+		if !valid {
+			return nil, false
+		}
+		us = append(us, u)
+		data = rest
+	}
+	return us, true
+}
+
 func readLenBytes(ctx *context.T, data []byte) (b, rest []byte, valid bool) {
 	l, data, valid := readVarUint64(ctx, data)
 	if !valid || uint64(len(data)) < l {
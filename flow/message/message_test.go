@@ -10,9 +10,11 @@ import (
 
 	"v.io/v23"
 	"v.io/v23/context"
+	"v.io/v23/flow"
 	"v.io/v23/flow/message"
 	"v.io/v23/naming"
 	"v.io/v23/rpc/version"
+	"v.io/v23/security"
 	"v.io/v23/verror"
 	_ "v.io/x/ref/runtime/factories/fake"
 	"v.io/x/ref/test"
@@ -87,8 +89,17 @@ func TestAuth(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	signer, err := security.GenerateEd25519Signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	edsig, err := signer.Sign([]byte(security.SignatureForMessageSigning), []byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
 	testMessages(t, ctx, []message.Message{
 		&message.Auth{BlessingsKey: 1, DischargeKey: 5, ChannelBinding: sig},
+		&message.Auth{BlessingsKey: 1, DischargeKey: 5, ChannelBinding: edsig},
 	})
 }
 
@@ -127,6 +138,19 @@ func TestMissingBlessings(t *testing.T) {
 	}
 }
 
+func TestInvalidPriority(t *testing.T) {
+	ctx, shutdown := v23.Init()
+	defer shutdown()
+	m := &message.OpenFlow{ID: 23, BlessingsKey: 42, Priority: flow.NumPriorities}
+	encoded, err := message.Append(ctx, m, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = message.Read(ctx, encoded); verror.ErrorID(err) != message.ErrInvalidMsg.ID {
+		t.Errorf("got %v, want InvalidMsg", err)
+	}
+}
+
 func TestAddReceiveBuffers(t *testing.T) {
 	ctx, shutdown := test.V23Init()
 	defer shutdown()
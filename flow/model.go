@@ -48,6 +48,28 @@ type Manager interface {
 	// Status returns the current ListenStatus of the manager.
 	Status() ListenStatus
 
+	// Subscribe returns a channel on which the Events of the manager are sent,
+	// in the order they occur, from the time of the call until ctx is done or
+	// the manager is closed, at which point the channel is closed.  Events are
+	// queued for slow subscribers rather than dropped, so a subscriber that
+	// applies them in order, starting from a Status snapshot taken after
+	// Subscribe, can maintain an up-to-date view of the manager.
+	//
+	// For example, a server can republish its endpoints precisely:
+	//   events := m.Subscribe(ctx)
+	//   for e := range events {
+	//     switch e := e.(type) {
+	//     case flow.ListenerAdded:
+	//       // mount e.Endpoints
+	//     case flow.ListenerRemoved:
+	//       // unmount e.Endpoints
+	//     }
+	//   }
+	Subscribe(ctx *context.T) <-chan Event
+
+	// Stats returns the statistics of the manager and its open connections.
+	Stats() ManagerStats
+
 	// StopListening stops listening on all currently listening addresses and proxies.
 	// All outstanding calls to Accept will return an error.
 	// It is safe to begin listening again.
@@ -76,11 +98,14 @@ type Manager interface {
 	// channelTimeout specifies the duration we are willing to wait before determining
 	// that connections managed by this Manager are unhealthy and should be
 	// closed.
-	Dial(ctx *context.T, remote naming.Endpoint, auth PeerAuthorizer, channelTimeout time.Duration) (Flow, error)
+	//
+	// A Priority may be passed in opts to set the scheduling class of the flow,
+	// which is NormalPriority otherwise.
+	Dial(ctx *context.T, remote naming.Endpoint, auth PeerAuthorizer, channelTimeout time.Duration, opts ...DialOpt) (Flow, error)
 
 	// DialSideChannel behaves the same as Dial, except that the returned flow is
 	// not factored in when deciding the underlying connection's idleness, etc.
-	DialSideChannel(ctx *context.T, remote naming.Endpoint, auth PeerAuthorizer, channelTimeout time.Duration) (Flow, error)
+	DialSideChannel(ctx *context.T, remote naming.Endpoint, auth PeerAuthorizer, channelTimeout time.Duration, opts ...DialOpt) (Flow, error)
 
 	// DialCached creates a Flow to the provided remote endpoint using only cached
 	// connections from previous Listen or Dial calls.
@@ -91,7 +116,7 @@ type Manager interface {
 	// channelTimeout specifies the duration we are willing to wait before determining
 	// that connections managed by this Manager are unhealthy and should be
 	// closed.
-	DialCached(ctx *context.T, remote naming.Endpoint, auth PeerAuthorizer, channelTimeout time.Duration) (Flow, error)
+	DialCached(ctx *context.T, remote naming.Endpoint, auth PeerAuthorizer, channelTimeout time.Duration, opts ...DialOpt) (Flow, error)
 
 	// RoutingID returns the naming.Routing of the flow.Manager.
 	// If the RoutingID of the manager is naming.NullRoutingID, the manager can
@@ -179,6 +204,11 @@ type ManagedConn interface {
 	RTT() time.Duration
 	// LastUsed returns the last time the connection had bytes read or written on it.
 	LastUsed() time.Time
+	// PriorityCounters returns the counters of each priority class of the
+	// flows on the connection, indexed by Priority.
+	PriorityCounters() []PriorityCounters
+	// Stats returns the statistics of the connection.
+	Stats() ConnStats
 	// Closed returns a channel that remains open until the connection has been closed.
 	Closed() <-chan struct{}
 }
@@ -262,6 +292,9 @@ type Flow interface {
 
 	// Conn returns the connection the flow is multiplexed on.
 	Conn() ManagedConn
+	// Priority returns the scheduling class of the flow, as chosen by the end
+	// that dialed it.
+	Priority() Priority
 
 	// Closed returns a channel that remains open until the flow has been closed or
 	// the ctx to the Dial or Accept call used to create the flow has been cancelled.
@@ -0,0 +1,232 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package netsim
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"v.io/v23/flow"
+	"v.io/v23/verror"
+)
+
+type message struct {
+	data      []byte
+	deliverAt time.Time
+}
+
+// conn wraps a Conn of the underlying protocol.  Messages written to it are
+// queued and written to the underlying Conn by the deliver goroutine once
+// they are due.
+type conn struct {
+	flow.Conn
+	n         *Network
+	done      chan struct{} // closed when the conn fails.
+	closing   chan struct{} // closed by Close.
+	closeOnce sync.Once
+
+	mu          sync.Mutex
+	cond        *sync.Cond
+	rand        *rand.Rand // GUARDED_BY(mu)
+	pending     []message  // GUARDED_BY(mu)
+	nextFree    time.Time  // GUARDED_BY(mu), when the simulated link is next idle.
+	lastDeliver time.Time  // GUARDED_BY(mu)
+	closed      bool       // GUARDED_BY(mu)
+	err         error      // GUARDED_BY(mu)
+}
+
+func (n *Network) newConn(base flow.Conn) *conn {
+	c := &conn{
+		Conn:    base,
+		n:       n,
+		done:    make(chan struct{}),
+		closing: make(chan struct{}),
+	}
+	c.cond = sync.NewCond(&c.mu)
+	n.mu.Lock()
+	c.rand = rand.New(rand.NewSource(n.rand.Int63()))
+	n.conns[c] = struct{}{}
+	n.mu.Unlock()
+	go c.deliver()
+	return c
+}
+
+// WriteMsg implements flow.MsgWriter.  The buffers are written to the
+// underlying Conn as a single message once it is due for delivery.
+func (c *conn) WriteMsg(data ...[]byte) (int, error) {
+	cond := c.n.Conditions()
+	var size int
+	for _, d := range data {
+		size += len(d)
+	}
+	if cond.MTU > 0 && size > cond.MTU {
+		return 0, verror.New(errMessageTooLarge, nil, size, cond.MTU)
+	}
+	msg := make([]byte, 0, size)
+	for _, d := range data {
+		msg = append(msg, d...)
+	}
+	bufferSize := cond.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+
+	c.mu.Lock()
+	for !c.closed && c.err == nil && len(c.pending) >= bufferSize {
+		c.cond.Wait()
+	}
+	switch {
+	case c.err != nil:
+		err := c.err
+		c.mu.Unlock()
+		return 0, err
+	case c.closed:
+		c.mu.Unlock()
+		return 0, verror.New(errConnClosed, nil)
+	}
+	if cond.DisconnectProbability > 0 && c.rand.Float64() < cond.DisconnectProbability {
+		c.mu.Unlock()
+		c.disconnect()
+		return 0, verror.New(errDisconnected, nil)
+	}
+	sent := time.Now()
+	if cond.Bandwidth > 0 {
+		if c.nextFree.After(sent) {
+			sent = c.nextFree
+		}
+		sent = sent.Add(time.Duration(int64(size) * int64(time.Second) / cond.Bandwidth))
+		c.nextFree = sent
+	}
+	deliverAt := sent
+	if cond.Latency != nil {
+		deliverAt = deliverAt.Add(cond.Latency(c.rand))
+	}
+	if deliverAt.Before(c.lastDeliver) {
+		deliverAt = c.lastDeliver
+	}
+	c.lastDeliver = deliverAt
+	c.pending = append(c.pending, message{msg, deliverAt})
+	c.cond.Broadcast()
+	c.mu.Unlock()
+	return size, nil
+}
+
+// ReadMsg implements flow.MsgReader.
+func (c *conn) ReadMsg() ([]byte, error) {
+	data, err := c.Conn.ReadMsg()
+	if err != nil {
+		c.mu.Lock()
+		if c.err != nil {
+			err = c.err
+		}
+		c.mu.Unlock()
+	}
+	return data, err
+}
+
+// Close implements flow.MsgReadWriteCloser.  Messages that were already
+// written are still delivered, unless the network is partitioned.
+func (c *conn) Close() error {
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		close(c.closing)
+		c.cond.Broadcast()
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// disconnect fails the conn and closes the underlying Conn.
+func (c *conn) disconnect() {
+	c.fail(verror.New(errDisconnected, nil))
+}
+
+func (c *conn) fail(err error) {
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = err
+		close(c.done)
+		c.cond.Broadcast()
+	}
+	c.mu.Unlock()
+	c.closeBase()
+}
+
+func (c *conn) closeBase() {
+	c.closeOnce.Do(func() {
+		c.Conn.Close()
+		c.n.mu.Lock()
+		delete(c.n.conns, c)
+		c.n.mu.Unlock()
+	})
+}
+
+// deliver writes the pending messages to the underlying Conn when they are
+// due, until the conn is closed or fails.
+func (c *conn) deliver() {
+	for {
+		c.mu.Lock()
+		for len(c.pending) == 0 && !c.closed && c.err == nil {
+			c.cond.Wait()
+		}
+		if c.err != nil || len(c.pending) == 0 {
+			c.mu.Unlock()
+			c.closeBase()
+			return
+		}
+		msg := c.pending[0]
+		c.pending[0] = message{}
+		c.pending = c.pending[1:]
+		c.cond.Broadcast()
+		c.mu.Unlock()
+
+		if !c.wait(msg.deliverAt) {
+			c.closeBase()
+			return
+		}
+		if _, err := c.Conn.WriteMsg(msg.data); err != nil {
+			c.fail(err)
+			return
+		}
+	}
+}
+
+// wait waits until t and until the network is not partitioned.  It returns
+// false if the conn fails, or is closed while the network is partitioned.
+func (c *conn) wait(t time.Time) bool {
+	if d := t.Sub(time.Now()); d > 0 {
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+		case <-c.done:
+			timer.Stop()
+			return false
+		}
+	}
+	for {
+		c.n.mu.Lock()
+		partitioned, healed, end := c.n.partitionedLocked(time.Now())
+		c.n.mu.Unlock()
+		if !partitioned {
+			return true
+		}
+		var timeout <-chan time.Time
+		if !end.IsZero() {
+			timer := time.NewTimer(end.Sub(time.Now()))
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case <-healed:
+		case <-timeout:
+		case <-c.done:
+			return false
+		case <-c.closing:
+			return false
+		}
+	}
+}
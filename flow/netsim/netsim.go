@@ -0,0 +1,278 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package netsim simulates poor network conditions on top of an existing
+// flow.Protocol.
+//
+// A Network wraps a Protocol, much like RegisterUnknownProtocol does, and
+// imposes its Conditions on the messages written to the Conns it dials and
+// accepts: latency drawn from a distribution, limited bandwidth, a maximum
+// message size, random disconnects and scheduled partitions.  All random
+// decisions are made with a seeded random number generator, so that a test
+// that dials and writes in the same order sees the same behavior every time.
+//
+// Conditions may be changed at any time, and apply to existing Conns as well
+// as new ones, so that a test can, for example, partition the network after
+// a connection has been established:
+//
+//   n := netsim.Register("sim", "tcp", 1)
+//   n.SetConditions(netsim.Conditions{Latency: netsim.Uniform(10*time.Millisecond, 50*time.Millisecond)})
+//   // Dial and listen on endpoints with the "sim" protocol.
+//   ...
+//   n.Partition()
+//   // Test that health checks time out.
+//   ...
+//   n.Heal()
+//
+// Conditions apply to messages written by the Conns of a Network.  Both ends
+// of a Conn must use the Network for both directions of the Conn to be
+// affected.
+package netsim
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/flow"
+	"v.io/v23/verror"
+)
+
+const pkgPath = "v.io/v23/flow/netsim"
+
+var (
+	errPartitioned     = verror.Register(pkgPath+".errPartitioned", verror.NoRetry, "{1:}{2:} network is partitioned{:_}")
+	errDisconnected    = verror.Register(pkgPath+".errDisconnected", verror.NoRetry, "{1:}{2:} connection was disconnected by the simulated network{:_}")
+	errMessageTooLarge = verror.Register(pkgPath+".errMessageTooLarge", verror.NoRetry, "{1:}{2:} message of {3} bytes exceeds the MTU of {4} bytes{:_}")
+	errConnClosed      = verror.Register(pkgPath+".errConnClosed", verror.NoRetry, "{1:}{2:} connection is closed{:_}")
+)
+
+// A Distribution returns random durations, using r as its source of
+// randomness.
+type Distribution func(r *rand.Rand) time.Duration
+
+// Fixed returns a Distribution that always returns d.
+func Fixed(d time.Duration) Distribution {
+	return func(*rand.Rand) time.Duration { return d }
+}
+
+// Uniform returns a Distribution of durations uniformly distributed in
+// [min, max).
+func Uniform(min, max time.Duration) Distribution {
+	if max <= min {
+		return Fixed(min)
+	}
+	return func(r *rand.Rand) time.Duration {
+		return min + time.Duration(r.Int63n(int64(max-min)))
+	}
+}
+
+// Normal returns a Distribution of normally distributed durations with the
+// given mean and standard deviation.  Negative durations are returned as
+// zero.
+func Normal(mean, stddev time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		if d := mean + time.Duration(r.NormFloat64()*float64(stddev)); d > 0 {
+			return d
+		}
+		return 0
+	}
+}
+
+// Partition is a period of time, relative to the time that Conditions were
+// set, during which the network is partitioned.
+type Partition struct {
+	After, For time.Duration
+}
+
+// Conditions describe a simulated network.  The zero value describes a
+// perfect network that adds no delay and never fails.
+type Conditions struct {
+	// Latency is the distribution of the one-way delay of messages.  Messages
+	// are always delivered in order, so a message may be delayed further
+	// until the previous message has been delivered.
+	Latency Distribution
+	// Bandwidth is the number of bytes per second that may be written to
+	// each Conn, or zero for unlimited bandwidth.
+	Bandwidth int64
+	// MTU is the maximum size of a message, or zero for no limit.  Writing a
+	// larger message fails.
+	MTU int
+	// DisconnectProbability is the probability, between 0 and 1, that the
+	// Conn is disconnected when a message is written to it.
+	DisconnectProbability float64
+	// Partitions is the schedule of partitions.  Dials fail while the network
+	// is partitioned, and messages are held until the partition ends.
+	Partitions []Partition
+	// BufferSize is the number of messages that may wait to be delivered on
+	// each Conn before writes block.  DefaultBufferSize is used if it is zero.
+	BufferSize int
+}
+
+// DefaultBufferSize is the number of messages that may wait to be delivered
+// on each Conn if not set by Conditions.
+const DefaultBufferSize = 64
+
+// Network is a flow.Protocol that simulates the Conditions of a network on
+// top of another Protocol.
+type Network struct {
+	base     flow.Protocol
+	protocol string
+
+	mu          sync.Mutex
+	conditions  Conditions         // GUARDED_BY(mu)
+	epoch       time.Time          // GUARDED_BY(mu), when conditions were set.
+	partitioned bool               // GUARDED_BY(mu)
+	healed      chan struct{}      // GUARDED_BY(mu), closed when a partition ends.
+	rand        *rand.Rand         // GUARDED_BY(mu)
+	conns       map[*conn]struct{} // GUARDED_BY(mu)
+}
+
+// New returns a Network that simulates a perfect network on top of base,
+// with the given seed for its random decisions.  The protocol names passed to
+// its methods are passed on to base.
+func New(base flow.Protocol, seed int64) *Network {
+	return &Network{
+		base:   base,
+		epoch:  time.Now(),
+		healed: make(chan struct{}),
+		rand:   rand.New(rand.NewSource(seed)),
+		conns:  make(map[*conn]struct{}),
+	}
+}
+
+// Register registers a Network under the name simProtocol that wraps the
+// Protocol registered as protocol.  Like RegisterUnknownProtocol, protocol is
+// passed to the wrapped Protocol in place of simProtocol.  The wrapped
+// Protocol must already be registered, otherwise Register panics.
+func Register(simProtocol, protocol string, seed int64) *Network {
+	base, p := flow.RegisteredProtocol(protocol)
+	if base == nil {
+		panic(protocol + " not registered")
+	}
+	n := New(base, seed)
+	n.protocol = protocol
+	flow.RegisterProtocol(simProtocol, n, p...)
+	return n
+}
+
+// SetConditions sets the conditions of the network.  They apply to existing
+// Conns as well as new ones.  The partition schedule starts at the time of
+// the call.
+func (n *Network) SetConditions(c Conditions) {
+	n.mu.Lock()
+	n.conditions = c
+	n.epoch = time.Now()
+	n.mu.Unlock()
+}
+
+// Conditions returns the conditions of the network.
+func (n *Network) Conditions() Conditions {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.conditions
+}
+
+// Partition partitions the network until Heal is called, in addition to
+// the scheduled partitions.
+func (n *Network) Partition() {
+	n.mu.Lock()
+	n.partitioned = true
+	n.mu.Unlock()
+}
+
+// Heal ends a partition started by Partition.
+func (n *Network) Heal() {
+	n.mu.Lock()
+	if n.partitioned {
+		n.partitioned = false
+		close(n.healed)
+		n.healed = make(chan struct{})
+	}
+	n.mu.Unlock()
+}
+
+// Disconnect disconnects all the Conns of the network.
+func (n *Network) Disconnect() {
+	n.mu.Lock()
+	conns := make([]*conn, 0, len(n.conns))
+	for c := range n.conns {
+		conns = append(conns, c)
+	}
+	n.mu.Unlock()
+	for _, c := range conns {
+		c.disconnect()
+	}
+}
+
+// partitionedLocked returns whether the network is partitioned at time t,
+// and if so, a channel that is closed when a manual partition ends and the
+// end of the scheduled partition, if any.
+// REQUIRES: n.mu is held.
+func (n *Network) partitionedLocked(t time.Time) (bool, <-chan struct{}, time.Time) {
+	var end time.Time
+	offset := t.Sub(n.epoch)
+	for _, p := range n.conditions.Partitions {
+		if offset >= p.After && offset < p.After+p.For {
+			if e := n.epoch.Add(p.After + p.For); e.After(end) {
+				end = e
+			}
+		}
+	}
+	if n.partitioned {
+		return true, n.healed, end
+	}
+	return !end.IsZero(), nil, end
+}
+
+func (n *Network) actual(protocol string) string {
+	if n.protocol != "" {
+		return n.protocol
+	}
+	return protocol
+}
+
+// Dial implements flow.Protocol.
+func (n *Network) Dial(ctx *context.T, protocol, address string, timeout time.Duration) (flow.Conn, error) {
+	n.mu.Lock()
+	partitioned, _, _ := n.partitionedLocked(time.Now())
+	n.mu.Unlock()
+	if partitioned {
+		return nil, verror.New(errPartitioned, ctx)
+	}
+	c, err := n.base.Dial(ctx, n.actual(protocol), address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return n.newConn(c), nil
+}
+
+// Resolve implements flow.Protocol.
+func (n *Network) Resolve(ctx *context.T, protocol, address string) (string, []string, error) {
+	return n.base.Resolve(ctx, n.actual(protocol), address)
+}
+
+// Listen implements flow.Protocol.
+func (n *Network) Listen(ctx *context.T, protocol, address string) (flow.Listener, error) {
+	ln, err := n.base.Listen(ctx, n.actual(protocol), address)
+	if err != nil {
+		return nil, err
+	}
+	return &listener{ln, n}, nil
+}
+
+type listener struct {
+	flow.Listener
+	n *Network
+}
+
+// Accept implements flow.Listener.
+func (l *listener) Accept(ctx *context.T) (flow.Conn, error) {
+	c, err := l.Listener.Accept(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return l.n.newConn(c), nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package netsim_test
+
+import (
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/flow"
+	"v.io/v23/flow/mem"
+	"v.io/v23/flow/netsim"
+)
+
+func pipe(t *testing.T, ctx *context.T, n *netsim.Network, address string) (flow.Conn, flow.Conn, flow.Listener) {
+	ln, err := n.Listen(ctx, "mem", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := n.Dial(ctx, "mem", ln.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ln.Accept(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, server, ln
+}
+
+// roundTrip returns the time taken to send a message from a to b.
+func roundTrip(t *testing.T, a, b flow.Conn, size int) time.Duration {
+	start := time.Now()
+	if _, err := a.WriteMsg(make([]byte, size)); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := b.ReadMsg(); err != nil || len(got) != size {
+		t.Fatalf("got (%d bytes, %v), want %d bytes", len(got), err, size)
+	}
+	return time.Since(start)
+}
+
+func TestLatencyAndBandwidth(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	n := netsim.New(mem.NewProtocol(), 1)
+	client, server, ln := pipe(t, ctx, n, "latency/")
+	defer ln.Close()
+
+	n.SetConditions(netsim.Conditions{Latency: netsim.Uniform(40*time.Millisecond, 60*time.Millisecond)})
+	if d := roundTrip(t, client, server, 10); d < 40*time.Millisecond {
+		t.Errorf("message delivered after %v, want at least 40ms", d)
+	}
+	n.SetConditions(netsim.Conditions{Bandwidth: 1000})
+	if d := roundTrip(t, server, client, 100); d < 100*time.Millisecond {
+		t.Errorf("message delivered after %v, want at least 100ms", d)
+	}
+	n.SetConditions(netsim.Conditions{MTU: 50})
+	if _, err := client.WriteMsg(make([]byte, 30), make([]byte, 30)); err == nil {
+		t.Errorf("expected an error writing a message larger than the MTU")
+	}
+	roundTrip(t, client, server, 50)
+}
+
+func TestPartition(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	n := netsim.New(mem.NewProtocol(), 1)
+	client, server, ln := pipe(t, ctx, n, "partition/")
+	defer ln.Close()
+
+	n.Partition()
+	if _, err := n.Dial(ctx, "mem", ln.Addr().String(), time.Second); err == nil {
+		t.Errorf("expected an error dialing while partitioned")
+	}
+	if _, err := client.WriteMsg([]byte("held")); err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan string)
+	go func() {
+		msg, _ := server.ReadMsg()
+		received <- string(msg)
+	}()
+	select {
+	case msg := <-received:
+		t.Fatalf("received %q while partitioned", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+	n.Heal()
+	if got := <-received; got != "held" {
+		t.Errorf("got %q, want \"held\"", got)
+	}
+
+	// Scheduled partitions.
+	n.SetConditions(netsim.Conditions{Partitions: []netsim.Partition{{After: 0, For: 100 * time.Millisecond}}})
+	if d := roundTrip(t, client, server, 1); d < 80*time.Millisecond {
+		t.Errorf("message delivered after %v, want it held until the partition ended", d)
+	}
+	if d := roundTrip(t, client, server, 1); d > 50*time.Millisecond {
+		t.Errorf("message delivered after %v after the partition ended", d)
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	// writes returns the number of messages that are written before the conn
+	// is randomly disconnected.
+	writes := func(seed int64) int {
+		n := netsim.New(mem.NewProtocol(), seed)
+		n.SetConditions(netsim.Conditions{DisconnectProbability: 0.1})
+		client, server, ln := pipe(t, ctx, n, "disconnect/")
+		defer ln.Close()
+		for i := 0; ; i++ {
+			if _, err := client.WriteMsg([]byte{1}); err != nil {
+				if _, err := server.ReadMsg(); err == nil {
+					// Messages written before the disconnect may be delivered.
+					for err == nil {
+						_, err = server.ReadMsg()
+					}
+				}
+				return i
+			}
+		}
+	}
+	if a, b := writes(3), writes(3); a != b {
+		t.Errorf("the same seed disconnected after %d and %d writes", a, b)
+	}
+
+	n := netsim.New(mem.NewProtocol(), 1)
+	client, server, ln := pipe(t, ctx, n, "disconnect/")
+	defer ln.Close()
+	n.Disconnect()
+	if _, err := client.WriteMsg([]byte{1}); err == nil {
+		t.Errorf("expected an error writing to a disconnected conn")
+	}
+	if _, err := server.ReadMsg(); err == nil {
+		t.Errorf("expected an error reading from a disconnected conn")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	n := netsim.Register("simmem", "mem", 1)
+	if p, _ := flow.RegisteredProtocol("simmem"); p != n {
+		t.Fatalf("got %v, want %v", p, n)
+	}
+	ln, err := n.Listen(ctx, "simmem", "register/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	if got, want := ln.Addr().Network(), "mem"; got != want {
+		t.Errorf("got network %q, want %q", got, want)
+	}
+	// Dialing the underlying protocol reaches the listener.
+	c, err := mem.Default.Dial(ctx, "mem", ln.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+}
@@ -0,0 +1,81 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flow
+
+import "fmt"
+
+// Priority is the scheduling class of a Flow.  Flows multiplexed on the same
+// ManagedConn share its bandwidth in proportion to the weights of their
+// classes, so that, for example, a large transfer on a BulkPriority flow does
+// not starve the control RPCs on InteractivePriority flows.
+//
+// The priority of a Flow is chosen by passing a Priority to Manager.Dial,
+// and is sent to the remote end when the flow is opened, so that it can
+// schedule its writes on the Flow in the same class.
+type Priority uint8
+
+const (
+	// NormalPriority is the priority of flows that don't specify one.
+	NormalPriority Priority = iota
+	// BulkPriority is for throughput-oriented flows, such as large transfers,
+	// that should yield to other flows.
+	BulkPriority
+	// InteractivePriority is for latency-sensitive flows, such as control
+	// RPCs.
+	InteractivePriority
+
+	// NumPriorities is the number of priority classes.
+	NumPriorities = iota
+)
+
+var priorityNames = [NumPriorities]string{"normal", "bulk", "interactive"}
+
+func (p Priority) String() string {
+	if int(p) < len(priorityNames) {
+		return priorityNames[p]
+	}
+	return fmt.Sprintf("Priority(%d)", uint8(p))
+}
+
+// FlowDialOpt makes Priority an option of Manager.Dial.
+func (Priority) FlowDialOpt() {}
+
+// DialOpt is the interface for options to Manager.Dial, Manager.DialCached
+// and Manager.DialSideChannel.
+type DialOpt interface {
+	FlowDialOpt()
+}
+
+// Weights are the relative shares of the bandwidth of a connection given to
+// each priority class, indexed by Priority.  A class without waiting writers
+// does not use its share, which is divided among the other classes.
+type Weights [NumPriorities]uint32
+
+// DefaultWeights are the weights used by implementations that aren't
+// configured otherwise.
+var DefaultWeights = Weights{
+	NormalPriority:      4,
+	BulkPriority:        1,
+	InteractivePriority: 16,
+}
+
+// PriorityCounters are the counters of the flows of one priority class on a
+// ManagedConn.
+type PriorityCounters struct {
+	Priority Priority
+	// Weight is the weight of the class.
+	Weight uint32
+	// Flows is the number of open flows in the class.
+	Flows int
+	// BytesSent and MessagesSent count the payload written by the flows of
+	// the class.
+	BytesSent, MessagesSent uint64
+	// BytesReceived and MessagesReceived count the payload read by the flows
+	// of the class.
+	BytesReceived, MessagesReceived uint64
+	// Stalls is the number of times a write of the class had to wait for
+	// writes of other classes.
+	Stalls uint64
+}
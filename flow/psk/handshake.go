@@ -0,0 +1,348 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package psk
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/flow"
+	"v.io/v23/verror"
+)
+
+// The key exchange is SPAKE2 over P-256, as described in RFC 9382.  The
+// dialer, A, and the listener, B, share the password scalar w, and pick
+// random scalars x and y:
+//
+//   A -> B: pA = x*G + w*M
+//   B -> A: pB = y*G + w*N, confirmB
+//   A -> B: confirmA
+//
+// Both compute K = x*(pB - w*N) = y*(pA - w*M) = x*y*G, and derive the keys
+// of the confirmations and of both directions of the Conn from the
+// transcript of the exchange.  Each confirmation is a MAC of the transcript,
+// proving that the sender computed the same K.
+
+// handshakeVersion is the first byte of the dialer's message.
+const handshakeVersion = 1
+
+var curve = elliptic.P256()
+
+// M and N are the points of RFC 9382 for P-256, whose discrete logarithms
+// are unknown.
+var (
+	mx, my = point("886e2f97ace46e55ba9dd7242579f2993b64e16ef3dcab95afd497333d8fa12f", "5ff355163e43ce224e0b0e65ff02ac8e5c7be09419c785e0ca547d55a12e2d20")
+	nx, ny = point("d8bbd6c639c62937b04d997f38c3770719c629d7014d49a24b4f98baa1292b49", "07d60aa6bfade45008a636337f5168c64d9bd36034808cd564490b1e656edbe7")
+)
+
+func point(x, y string) (*big.Int, *big.Int) {
+	xb, err := hex.DecodeString(x)
+	if err != nil {
+		panic(err)
+	}
+	yb, err := hex.DecodeString(y)
+	if err != nil {
+		panic(err)
+	}
+	px, py := new(big.Int).SetBytes(xb), new(big.Int).SetBytes(yb)
+	if !curve.IsOnCurve(px, py) {
+		panic("point is not on the curve")
+	}
+	return px, py
+}
+
+// passwordScalar maps the password to a scalar.
+func passwordScalar(password []byte) *big.Int {
+	h := sha256.New()
+	h.Write([]byte(pkgPath))
+	h.Write(password)
+	w := new(big.Int).SetBytes(h.Sum(nil))
+	return w.Mod(w, curve.Params().N)
+}
+
+func randomScalar() (*big.Int, error) {
+	for {
+		s, err := rand.Int(rand.Reader, curve.Params().N)
+		if err != nil {
+			return nil, err
+		}
+		if s.Sign() > 0 {
+			return s, nil
+		}
+	}
+}
+
+// blind returns s*G + w*(px, py), encoded.
+func blind(s, w, px, py *big.Int) []byte {
+	x1, y1 := curve.ScalarBaseMult(s.Bytes())
+	x2, y2 := curve.ScalarMult(px, py, w.Bytes())
+	x, y := curve.Add(x1, y1, x2, y2)
+	return elliptic.Marshal(curve, x, y)
+}
+
+// unblind returns s*(p - w*(px, py)), encoded, where p is the encoded point
+// received from the peer.
+func unblind(ctx *context.T, p []byte, s, w, px, py *big.Int) ([]byte, error) {
+	x, y := elliptic.Unmarshal(curve, p)
+	if x == nil {
+		return nil, verror.New(errBadMessage, ctx)
+	}
+	x2, y2 := curve.ScalarMult(px, py, w.Bytes())
+	y2.Sub(curve.Params().P, y2)
+	x, y = curve.Add(x, y, x2, y2)
+	x, y = curve.ScalarMult(x, y, s.Bytes())
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, verror.New(errBadMessage, ctx)
+	}
+	return elliptic.Marshal(curve, x, y), nil
+}
+
+// keys are derived from the transcript of a key exchange.
+type keys struct {
+	transcript                   []byte
+	dialerConfirm, listenConfirm []byte
+	dialerWrite, listenWrite     []byte
+}
+
+func deriveKeys(pA, pB, k []byte, w *big.Int) keys {
+	h := sha256.New()
+	for _, b := range [][]byte{pA, pB, k, w.Bytes()} {
+		var n [8]byte
+		binary.LittleEndian.PutUint64(n[:], uint64(len(b)))
+		h.Write(n[:])
+		h.Write(b)
+	}
+	tt := h.Sum(nil)
+	derive := func(label string) []byte {
+		m := hmac.New(sha256.New, tt)
+		m.Write([]byte(label))
+		return m.Sum(nil)
+	}
+	return keys{
+		transcript:    tt,
+		dialerConfirm: derive("dialer confirmation"),
+		listenConfirm: derive("listener confirmation"),
+		dialerWrite:   derive("dialer encryption"),
+		listenWrite:   derive("listener encryption"),
+	}
+}
+
+func confirmation(key, transcript []byte) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write(transcript)
+	return m.Sum(nil)
+}
+
+// handshake runs the key exchange on c, as the dialer if dialer is true,
+// and returns a Conn that encrypts the messages of c.  c is closed if the
+// exchange fails, or doesn't complete within timeout, if it is not zero, or
+// before ctx is done.
+func handshake(ctx *context.T, c flow.Conn, password []byte, dialer bool, timeout time.Duration) (flow.Conn, error) {
+	// The watchdog closes c if the exchange takes too long, and sends
+	// whether it did on expired once done is closed, or when it closes c.
+	done := make(chan struct{})
+	expired := make(chan bool, 1)
+	var timer <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+	go func() {
+		select {
+		case <-timer:
+		case <-ctx.Done():
+		case <-done:
+			expired <- false
+			return
+		}
+		c.Close()
+		expired <- true
+	}()
+
+	var (
+		k   keys
+		err error
+	)
+	if dialer {
+		k, err = dial(ctx, c, passwordScalar(password))
+	} else {
+		k, err = accept(ctx, c, passwordScalar(password))
+	}
+	close(done)
+	if <-expired {
+		err = verror.New(errTimeout, ctx)
+	}
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	read, write := k.listenWrite, k.dialerWrite
+	if !dialer {
+		read, write = write, read
+	}
+	hc, err := newConn(c, read, write)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return hc, nil
+}
+
+func dial(ctx *context.T, c flow.Conn, w *big.Int) (keys, error) {
+	x, err := randomScalar()
+	if err != nil {
+		return keys{}, err
+	}
+	pA := blind(x, w, mx, my)
+	if _, err := c.WriteMsg([]byte{handshakeVersion}, pA); err != nil {
+		return keys{}, err
+	}
+	msg, err := c.ReadMsg()
+	if err != nil {
+		return keys{}, err
+	}
+	if len(msg) != len(pA)+sha256.Size {
+		return keys{}, verror.New(errBadMessage, ctx)
+	}
+	pB, confirmB := msg[:len(pA)], msg[len(pA):]
+	K, err := unblind(ctx, pB, x, w, nx, ny)
+	if err != nil {
+		return keys{}, err
+	}
+	k := deriveKeys(pA, pB, K, w)
+	if !hmac.Equal(confirmB, confirmation(k.listenConfirm, k.transcript)) {
+		return keys{}, verror.New(errHandshakeFailed, ctx)
+	}
+	if _, err := c.WriteMsg(confirmation(k.dialerConfirm, k.transcript)); err != nil {
+		return keys{}, err
+	}
+	return k, nil
+}
+
+func accept(ctx *context.T, c flow.Conn, w *big.Int) (keys, error) {
+	msg, err := c.ReadMsg()
+	if err != nil {
+		return keys{}, err
+	}
+	if len(msg) < 1 || msg[0] != handshakeVersion {
+		return keys{}, verror.New(errBadMessage, ctx)
+	}
+	pA := msg[1:]
+	y, err := randomScalar()
+	if err != nil {
+		return keys{}, err
+	}
+	K, err := unblind(ctx, pA, y, w, mx, my)
+	if err != nil {
+		return keys{}, err
+	}
+	pB := blind(y, w, nx, ny)
+	k := deriveKeys(pA, pB, K, w)
+	if _, err := c.WriteMsg(pB, confirmation(k.listenConfirm, k.transcript)); err != nil {
+		return keys{}, err
+	}
+	confirmA, err := c.ReadMsg()
+	if err != nil {
+		return keys{}, err
+	}
+	if !hmac.Equal(confirmA, confirmation(k.dialerConfirm, k.transcript)) {
+		return keys{}, verror.New(errHandshakeFailed, ctx)
+	}
+	return k, nil
+}
+
+// conn encrypts each message written to it into a single message of the
+// underlying Conn, with AES-GCM and a per-direction counter as nonce.
+type conn struct {
+	c flow.Conn
+
+	rmu   sync.Mutex
+	read  cipher.AEAD
+	rseq  uint64 // GUARDED_BY(rmu)
+	wmu   sync.Mutex
+	write cipher.AEAD
+	wseq  uint64 // GUARDED_BY(wmu)
+}
+
+func newConn(c flow.Conn, readKey, writeKey []byte) (*conn, error) {
+	read, err := newAEAD(readKey)
+	if err != nil {
+		return nil, err
+	}
+	write, err := newAEAD(writeKey)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{c: c, read: read, write: write}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func nonce(aead cipher.AEAD, seq uint64) []byte {
+	n := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(n[len(n)-8:], seq)
+	return n
+}
+
+// WriteMsg implements flow.MsgWriter.
+func (c *conn) WriteMsg(data ...[]byte) (int, error) {
+	var plain []byte
+	for _, d := range data {
+		plain = append(plain, d...)
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	sealed := c.write.Seal(nil, nonce(c.write, c.wseq), plain, nil)
+	c.wseq++
+	if _, err := c.c.WriteMsg(sealed); err != nil {
+		return 0, err
+	}
+	return len(plain), nil
+}
+
+// ReadMsg implements flow.MsgReader.
+func (c *conn) ReadMsg() ([]byte, error) {
+	c.rmu.Lock()
+	defer c.rmu.Unlock()
+	sealed, err := c.c.ReadMsg()
+	if err != nil {
+		return nil, err
+	}
+	plain, err := c.read.Open(nil, nonce(c.read, c.rseq), sealed, nil)
+	if err != nil {
+		c.c.Close()
+		return nil, verror.New(errDecrypt, nil)
+	}
+	c.rseq++
+	return plain, nil
+}
+
+// Close implements flow.Conn.
+func (c *conn) Close() error {
+	return c.c.Close()
+}
+
+// LocalAddr implements flow.Conn.
+func (c *conn) LocalAddr() net.Addr {
+	return c.c.LocalAddr()
+}
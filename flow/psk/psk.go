@@ -0,0 +1,218 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package psk implements a flow.Protocol that authenticates and encrypts the
+// Conns of another Protocol with a key derived from a shared secret, such as
+// the pairing token of a device that is being claimed.
+//
+// A device that has not been claimed has no blessings, so the Setup and Auth
+// exchange of its first connection can't authenticate it, and an active
+// attacker on the local network could intercept the Claim call.  When both
+// the device and the claimer wrap their transport with a Protocol created
+// from the pairing token, every Conn first runs a password-authenticated key
+// exchange (SPAKE2 over P-256), and the messages of the normal flow protocol
+// are then encrypted and authenticated with AES-GCM under the resulting keys:
+//
+//   // On the device:
+//   psk.Register("psk", "tcp", []byte(pairingToken))
+//   // Listen on the "psk" protocol and serve the Claimable interface.
+//
+//   // On the claimer:
+//   psk.Register("psk", "tcp", []byte(pairingToken))
+//   // Call Claim on an endpoint with the "psk" protocol.
+//
+// Each connection attempt by an attacker that doesn't know the token allows
+// it to test a single guess of the token: unlike a key derived by hashing the
+// token, the exchanged messages can't be used to test guesses offline.  A
+// Conn whose peer used a different token fails its key exchange, and is
+// closed; Dial returns an error, while Listener.Accept silently drops it.
+package psk
+
+import (
+	"sync"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/flow"
+	"v.io/v23/verror"
+)
+
+const pkgPath = "v.io/v23/flow/psk"
+
+var (
+	errHandshakeFailed = verror.Register(pkgPath+".errHandshakeFailed", verror.NoRetry, "{1:}{2:} key exchange failed; the peer may not know the key{:_}")
+	errBadMessage      = verror.Register(pkgPath+".errBadMessage", verror.NoRetry, "{1:}{2:} invalid key exchange message{:_}")
+	errDecrypt         = verror.Register(pkgPath+".errDecrypt", verror.NoRetry, "{1:}{2:} failed to authenticate message{:_}")
+	errListenerClosed  = verror.Register(pkgPath+".errListenerClosed", verror.NoRetry, "{1:}{2:} listener is closed{:_}")
+	errTimeout         = verror.Register(pkgPath+".errTimeout", verror.NoRetry, "{1:}{2:} key exchange timed out{:_}")
+)
+
+// HandshakeTimeout bounds the key exchange of accepted Conns, so that peers
+// that don't complete it don't hold resources forever.
+var HandshakeTimeout = 10 * time.Second
+
+// Protocol is a flow.Protocol that runs a key exchange on the Conns of
+// another Protocol and encrypts their messages.
+type Protocol struct {
+	base     flow.Protocol
+	protocol string
+
+	mu  sync.Mutex
+	key []byte // GUARDED_BY(mu)
+}
+
+// New returns a Protocol that wraps the Conns of base with the shared secret
+// key.  The protocol names passed to its methods are passed on to base.
+func New(base flow.Protocol, key []byte) *Protocol {
+	p := &Protocol{base: base}
+	p.SetKey(key)
+	return p
+}
+
+// Register registers a Protocol under the name pskProtocol that wraps the
+// Protocol registered as protocol.  Like RegisterUnknownProtocol, protocol is
+// passed to the wrapped Protocol in place of pskProtocol.  The wrapped
+// Protocol must already be registered, otherwise Register panics.
+func Register(pskProtocol, protocol string, key []byte) *Protocol {
+	base, names := flow.RegisteredProtocol(protocol)
+	if base == nil {
+		panic(protocol + " not registered")
+	}
+	p := New(base, key)
+	p.protocol = protocol
+	flow.RegisterProtocol(pskProtocol, p, names...)
+	return p
+}
+
+// SetKey changes the shared secret used for new Conns, for example when a
+// new pairing token is issued.
+func (p *Protocol) SetKey(key []byte) {
+	p.mu.Lock()
+	p.key = append([]byte(nil), key...)
+	p.mu.Unlock()
+}
+
+func (p *Protocol) password() []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.key
+}
+
+func (p *Protocol) actual(protocol string) string {
+	if p.protocol != "" {
+		return p.protocol
+	}
+	return protocol
+}
+
+// Dial implements flow.Protocol.  The key exchange is bounded by timeout, if
+// it is not zero, in addition to ctx.
+func (p *Protocol) Dial(ctx *context.T, protocol, address string, timeout time.Duration) (flow.Conn, error) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	c, err := p.base.Dial(ctx, p.actual(protocol), address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		// Dialing may have used up all the time for the key exchange.
+		if timeout = deadline.Sub(time.Now()); timeout <= 0 {
+			c.Close()
+			return nil, verror.New(errTimeout, ctx)
+		}
+	}
+	return handshake(ctx, c, p.password(), true, timeout)
+}
+
+// Resolve implements flow.Protocol.
+func (p *Protocol) Resolve(ctx *context.T, protocol, address string) (string, []string, error) {
+	return p.base.Resolve(ctx, p.actual(protocol), address)
+}
+
+// Listen implements flow.Protocol.
+func (p *Protocol) Listen(ctx *context.T, protocol, address string) (flow.Listener, error) {
+	ln, err := p.base.Listen(ctx, p.actual(protocol), address)
+	if err != nil {
+		return nil, err
+	}
+	l := &listener{
+		Listener: ln,
+		p:        p,
+		conns:    make(chan flow.Conn),
+		closed:   make(chan struct{}),
+	}
+	go l.acceptLoop(ctx)
+	return l, nil
+}
+
+// listener runs the key exchange of accepted Conns concurrently, so that a
+// slow or malicious peer doesn't block other peers.
+type listener struct {
+	flow.Listener
+	p      *Protocol
+	conns  chan flow.Conn
+	closed chan struct{}
+
+	mu  sync.Mutex
+	err error // GUARDED_BY(mu), the error that ended acceptLoop.
+}
+
+func (l *listener) acceptLoop(ctx *context.T) {
+	for {
+		c, err := l.Listener.Accept(ctx)
+		if err != nil {
+			l.mu.Lock()
+			l.err = err
+			l.mu.Unlock()
+			l.Close()
+			return
+		}
+		go func() {
+			hc, err := handshake(ctx, c, l.p.password(), false, HandshakeTimeout)
+			if err != nil {
+				ctx.VI(2).Infof("psk: dropping conn from %v: %v", c.LocalAddr(), err)
+				return
+			}
+			select {
+			case l.conns <- hc:
+			case <-l.closed:
+				hc.Close()
+			}
+		}()
+	}
+}
+
+// Accept implements flow.Listener.  It returns the next Conn whose key
+// exchange succeeded.
+func (l *listener) Accept(ctx *context.T) (flow.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+	case <-ctx.Done():
+		return nil, verror.New(verror.ErrCanceled, ctx, ctx.Err())
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return nil, l.err
+	}
+	return nil, verror.New(errListenerClosed, ctx)
+}
+
+// Close implements flow.Listener.
+func (l *listener) Close() error {
+	l.mu.Lock()
+	select {
+	case <-l.closed:
+		l.mu.Unlock()
+		return nil
+	default:
+		close(l.closed)
+	}
+	l.mu.Unlock()
+	return l.Listener.Close()
+}
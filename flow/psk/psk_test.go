@@ -0,0 +1,209 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package psk_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/flow"
+	"v.io/v23/flow/mem"
+	"v.io/v23/flow/psk"
+)
+
+// recorder is a flow.Protocol that records the messages written to the Conns
+// of a mem Protocol.
+type recorder struct {
+	*mem.Protocol
+	mu       sync.Mutex
+	messages [][]byte
+}
+
+func (r *recorder) Dial(ctx *context.T, protocol, address string, timeout time.Duration) (flow.Conn, error) {
+	c, err := r.Protocol.Dial(ctx, protocol, address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingConn{c, r}, nil
+}
+
+type recordingConn struct {
+	flow.Conn
+	r *recorder
+}
+
+func (c *recordingConn) WriteMsg(data ...[]byte) (int, error) {
+	var msg []byte
+	for _, d := range data {
+		msg = append(msg, d...)
+	}
+	c.r.mu.Lock()
+	c.r.messages = append(c.r.messages, msg)
+	c.r.mu.Unlock()
+	return c.Conn.WriteMsg(data...)
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	base := &recorder{Protocol: mem.NewProtocol()}
+	p := psk.New(base, []byte("pairing token"))
+	ln, err := p.Listen(ctx, mem.Name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	client, err := p.Dial(ctx, mem.Name, ln.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ln.Accept(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := []byte("the claim request")
+	for i := 0; i < 3; i++ {
+		if _, err := client.WriteMsg(secret[:5], secret[5:]); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := server.ReadMsg(); err != nil || !bytes.Equal(got, secret) {
+			t.Errorf("got (%q, %v), want %q", got, err, secret)
+		}
+		if _, err := server.WriteMsg([]byte("reply")); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := client.ReadMsg(); err != nil || string(got) != "reply" {
+			t.Errorf("got (%q, %v), want %q", got, err, "reply")
+		}
+	}
+	client.Close()
+	if _, err := server.ReadMsg(); err == nil {
+		t.Errorf("expected an error reading from a closed conn")
+	}
+
+	// The messages on the wire don't reveal the plaintext, and the same
+	// plaintext is encrypted differently each time.
+	base.mu.Lock()
+	defer base.mu.Unlock()
+	seen := map[string]bool{}
+	for _, m := range base.messages {
+		if bytes.Contains(m, secret[:8]) {
+			t.Errorf("plaintext sent on the wire: %x", m)
+		}
+		if seen[string(m)] {
+			t.Errorf("message %x sent twice", m)
+		}
+		seen[string(m)] = true
+	}
+	// The key exchange message and the confirmation, followed by the data.
+	if got, want := len(base.messages), 2+3; got != want {
+		t.Errorf("got %d messages on the wire, want %d", got, want)
+	}
+}
+
+func TestWrongKey(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	base := mem.NewProtocol()
+	server := psk.New(base, []byte("pairing token"))
+	ln, err := server.Listen(ctx, mem.Name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	attacker := psk.New(base, []byte("guess"))
+	if _, err := attacker.Dial(ctx, mem.Name, ln.Addr().String(), time.Second); err == nil {
+		t.Errorf("expected an error dialing with the wrong key")
+	}
+	// A peer that doesn't run the key exchange at all is dropped too.
+	raw, err := base.Dial(ctx, mem.Name, ln.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw.WriteMsg([]byte("hello"))
+	if _, err := raw.ReadMsg(); err == nil {
+		t.Errorf("expected the listener to close a conn without a key exchange")
+	}
+
+	// Neither is accepted, while a peer with the right key is.
+	client := psk.New(base, nil)
+	client.SetKey([]byte("pairing token"))
+	if _, err := client.Dial(ctx, mem.Name, ln.Addr().String(), time.Second); err != nil {
+		t.Fatal(err)
+	}
+	actx, acancel := context.WithTimeout(ctx, 5*time.Second)
+	defer acancel()
+	c, err := ln.Accept(actx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+}
+
+func TestHandshakeTimeout(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	base := mem.NewProtocol()
+	// Nobody runs the key exchange on the accepted conn.
+	ln, err := base.Listen(ctx, mem.Name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	p := psk.New(base, []byte("pairing token"))
+	start := time.Now()
+	if _, err := p.Dial(ctx, mem.Name, ln.Addr().String(), 100*time.Millisecond); err == nil {
+		t.Errorf("expected the key exchange to time out")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Dial took %v", d)
+	}
+}
+
+// slowDialer is a flow.Protocol whose Dials take at least delay.
+type slowDialer struct {
+	*mem.Protocol
+	delay time.Duration
+}
+
+func (s *slowDialer) Dial(ctx *context.T, protocol, address string, timeout time.Duration) (flow.Conn, error) {
+	time.Sleep(s.delay)
+	return s.Protocol.Dial(ctx, protocol, address, timeout)
+}
+
+func TestDialUsesUpTimeout(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	base := &slowDialer{mem.NewProtocol(), 100 * time.Millisecond}
+	// Nobody runs the key exchange on the accepted conn.
+	ln, err := base.Listen(ctx, mem.Name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	p := psk.New(base, []byte("pairing token"))
+	errc := make(chan error, 1)
+	go func() {
+		_, err := p.Dial(ctx, mem.Name, ln.Addr().String(), 50*time.Millisecond)
+		errc <- err
+	}()
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Errorf("expected the key exchange to time out")
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("the key exchange isn't bounded once dialing used up the timeout")
+	}
+}
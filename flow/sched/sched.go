@@ -0,0 +1,214 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sched implements weighted fair scheduling of the writes of flows
+// of different priorities onto a connection.
+//
+// A Scheduler serializes writes: a writer calls Acquire before writing a
+// message to the connection and Release after.  When writers of several
+// priority classes are waiting, the Scheduler grants them the connection
+// using deficit round robin, so that each class with waiting writers gets a
+// share of the bytes written in proportion to its weight:
+//
+//   s := sched.New(flow.DefaultWeights)
+//   ...
+//   if err := s.Acquire(ctx, f.Priority(), len(msg)); err != nil {
+//     return err
+//   }
+//   err := conn.WriteMsg(msg)
+//   s.Release()
+//
+// Connection implementations may use a Scheduler to implement the
+// scheduling described by flow.Priority, and to report its PriorityCounters.
+package sched
+
+import (
+	"sync"
+
+	"v.io/v23/context"
+	"v.io/v23/flow"
+	"v.io/v23/verror"
+)
+
+const pkgPath = "v.io/v23/flow/sched"
+
+var errBadPriority = verror.Register(pkgPath+".errBadPriority", verror.NoRetry, "{1:}{2:} invalid priority {3}{:_}")
+
+// quantum is the number of bytes added to the deficit of a class, per unit
+// of weight, each time its turn comes.
+const quantum = 1024
+
+type waiter struct {
+	size  int
+	ready chan struct{}
+}
+
+type class struct {
+	weight   uint32
+	waiters  []*waiter
+	deficit  int
+	counters flow.PriorityCounters
+}
+
+// Scheduler schedules writes of flows of different priorities.
+type Scheduler struct {
+	mu      sync.Mutex
+	classes [flow.NumPriorities]class // GUARDED_BY(mu)
+	busy    bool                      // GUARDED_BY(mu), whether a writer holds the connection.
+	next    int                       // GUARDED_BY(mu), the class whose turn it is.
+}
+
+// New returns a Scheduler with the given weights.  Classes with a zero weight
+// are given a weight of 1.
+func New(weights flow.Weights) *Scheduler {
+	s := &Scheduler{}
+	s.SetWeights(weights)
+	return s
+}
+
+// SetWeights changes the weights of the classes.
+func (s *Scheduler) SetWeights(weights flow.Weights) {
+	s.mu.Lock()
+	for p := range s.classes {
+		w := weights[p]
+		if w == 0 {
+			w = 1
+		}
+		s.classes[p].weight = w
+	}
+	s.mu.Unlock()
+}
+
+// Acquire blocks until a write of size bytes by a flow of priority p may
+// proceed, or ctx is done.  Release must be called once the write is done if,
+// and only if, Acquire returns nil.
+func (s *Scheduler) Acquire(ctx *context.T, p flow.Priority, size int) error {
+	if int(p) >= len(s.classes) {
+		return verror.New(errBadPriority, ctx, p)
+	}
+	s.mu.Lock()
+	c := &s.classes[p]
+	c.counters.MessagesSent++
+	c.counters.BytesSent += uint64(size)
+	if !s.busy && s.idleLocked() {
+		s.busy = true
+		s.mu.Unlock()
+		return nil
+	}
+	c.counters.Stalls++
+	w := &waiter{size: size, ready: make(chan struct{})}
+	c.waiters = append(c.waiters, w)
+	if !s.busy {
+		s.scheduleLocked()
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-w.ready:
+		// The write was granted while ctx was done, give up the connection.
+		s.busy = false
+		s.scheduleLocked()
+	default:
+		for i, x := range c.waiters {
+			if x == w {
+				c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+				break
+			}
+		}
+	}
+	c.counters.MessagesSent--
+	c.counters.BytesSent -= uint64(size)
+	return verror.New(verror.ErrCanceled, ctx, ctx.Err())
+}
+
+// Release ends a write that was allowed by Acquire, and lets the next
+// waiting writer proceed.
+func (s *Scheduler) Release() {
+	s.mu.Lock()
+	s.busy = false
+	s.scheduleLocked()
+	s.mu.Unlock()
+}
+
+// idleLocked returns true if there are no waiting writers.
+// REQUIRES: s.mu is held.
+func (s *Scheduler) idleLocked() bool {
+	for p := range s.classes {
+		if len(s.classes[p].waiters) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// scheduleLocked grants the connection to the next waiting writer, if any.
+// The class whose turn it is is served while its deficit covers the size of
+// its next write, otherwise the turn passes to the next class, whose deficit
+// grows by its quantum.
+// REQUIRES: s.mu is held and s.busy is false.
+func (s *Scheduler) scheduleLocked() {
+	if s.idleLocked() {
+		return
+	}
+	for {
+		c := &s.classes[s.next]
+		if len(c.waiters) == 0 {
+			c.deficit = 0
+		} else if w := c.waiters[0]; w.size <= c.deficit {
+			c.deficit -= w.size
+			c.waiters[0] = nil
+			c.waiters = c.waiters[1:]
+			s.busy = true
+			close(w.ready)
+			return
+		}
+		s.next = (s.next + 1) % len(s.classes)
+		if n := &s.classes[s.next]; len(n.waiters) > 0 {
+			n.deficit += quantum * int(n.weight)
+		}
+	}
+}
+
+// SetFlows sets the number of open flows of priority p that is reported by
+// Counters.
+func (s *Scheduler) SetFlows(p flow.Priority, flows int) {
+	if int(p) >= len(s.classes) {
+		return
+	}
+	s.mu.Lock()
+	s.classes[p].counters.Flows = flows
+	s.mu.Unlock()
+}
+
+// Received records that a message of size bytes was read by a flow of
+// priority p.
+func (s *Scheduler) Received(p flow.Priority, size int) {
+	if int(p) >= len(s.classes) {
+		return
+	}
+	s.mu.Lock()
+	s.classes[p].counters.MessagesReceived++
+	s.classes[p].counters.BytesReceived += uint64(size)
+	s.mu.Unlock()
+}
+
+// Counters returns the counters of each class, indexed by priority.
+func (s *Scheduler) Counters() []flow.PriorityCounters {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counters := make([]flow.PriorityCounters, len(s.classes))
+	for p := range s.classes {
+		counters[p] = s.classes[p].counters
+		counters[p].Priority = flow.Priority(p)
+		counters[p].Weight = s.classes[p].weight
+	}
+	return counters
+}
@@ -0,0 +1,117 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sched_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/flow"
+	"v.io/v23/flow/sched"
+)
+
+// waitForStalls waits until n writes have had to wait.
+func waitForStalls(t *testing.T, s *sched.Scheduler, n uint64) {
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+		var stalls uint64
+		for _, c := range s.Counters() {
+			stalls += c.Stalls
+		}
+		if stalls == n {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %d stalled writes", n)
+}
+
+func TestWeightedScheduling(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	s := sched.New(flow.DefaultWeights)
+	// Hold the connection while the writers queue up.
+	if err := s.Acquire(ctx, flow.NormalPriority, 1); err != nil {
+		t.Fatal(err)
+	}
+	const writes = 40
+	var (
+		mu    sync.Mutex
+		order []flow.Priority
+		wg    sync.WaitGroup
+	)
+	for _, p := range []flow.Priority{flow.BulkPriority, flow.InteractivePriority} {
+		for i := 0; i < writes; i++ {
+			wg.Add(1)
+			go func(p flow.Priority) {
+				defer wg.Done()
+				if err := s.Acquire(ctx, p, 1024); err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				order = append(order, p)
+				mu.Unlock()
+				s.Release()
+			}(p)
+		}
+	}
+	waitForStalls(t, s, 2*writes)
+	s.Release()
+	wg.Wait()
+
+	// Interactive writes get 16 times the share of bulk writes.
+	var bulk int
+	for _, p := range order[:34] {
+		if p == flow.BulkPriority {
+			bulk++
+		}
+	}
+	if bulk != 2 {
+		t.Errorf("got %d bulk writes in the first 34, want 2: %v", bulk, order)
+	}
+	// All the writes are eventually done.
+	counters := s.Counters()
+	for _, p := range []flow.Priority{flow.BulkPriority, flow.InteractivePriority} {
+		c := counters[p]
+		if c.Priority != p || c.MessagesSent != writes || c.BytesSent != writes*1024 || c.Stalls != writes {
+			t.Errorf("got counters %+v for %v", c, p)
+		}
+	}
+	if got, want := counters[flow.InteractivePriority].Weight, flow.DefaultWeights[flow.InteractivePriority]; got != want {
+		t.Errorf("got weight %d, want %d", got, want)
+	}
+}
+
+func TestCanceledAcquire(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	s := sched.New(flow.Weights{})
+	if err := s.Acquire(ctx, flow.NormalPriority, 10); err != nil {
+		t.Fatal(err)
+	}
+	cctx, ccancel := context.WithCancel(ctx)
+	errc := make(chan error)
+	go func() { errc <- s.Acquire(cctx, flow.BulkPriority, 10) }()
+	waitForStalls(t, s, 1)
+	ccancel()
+	if err := <-errc; err == nil {
+		t.Errorf("expected an error from a canceled Acquire")
+	}
+	s.Release()
+	// The canceled write doesn't hold up later writes.
+	if err := s.Acquire(ctx, flow.BulkPriority, 10); err != nil {
+		t.Fatal(err)
+	}
+	s.Release()
+	if got := s.Counters()[flow.BulkPriority].MessagesSent; got != 1 {
+		t.Errorf("got %d bulk messages sent, want 1", got)
+	}
+	if err := s.Acquire(ctx, flow.NumPriorities, 10); err == nil {
+		t.Errorf("expected an error for an invalid priority")
+	}
+}
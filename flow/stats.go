@@ -0,0 +1,73 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flow
+
+import (
+	"time"
+
+	"v.io/v23/naming"
+)
+
+// ConnStats are statistics of a ManagedConn.
+type ConnStats struct {
+	// ID identifies the connection among the connections of its Manager.
+	ID uint64
+	// LocalEndpoint and RemoteEndpoint are the endpoints of the connection.
+	LocalEndpoint, RemoteEndpoint naming.Endpoint
+	// Created is the time the connection was established.
+	Created time.Time
+
+	// BytesSent and BytesReceived count the bytes written to and read from
+	// the underlying network connection, including framing and encryption.
+	BytesSent, BytesReceived uint64
+	// MessagesSent and MessagesReceived count the messages written to and
+	// read from the underlying network connection.
+	MessagesSent, MessagesReceived uint64
+	// ActiveFlows is the number of open flows.
+	ActiveFlows int
+	// TotalFlows is the number of flows ever opened on the connection.
+	TotalFlows uint64
+	// FlowControlStalls is the number of times a flow had to wait for the
+	// remote end to release counters before writing.
+	FlowControlStalls uint64
+	// HealthCheckMisses is the number of health checks that were not answered
+	// in time.
+	HealthCheckMisses uint64
+	// RTT is the last round-trip-time of the health-check, as returned by
+	// ManagedConn.RTT.
+	RTT time.Duration
+
+	// Mtu and SharedTokens are the options negotiated in the Setup messages
+	// of the connection, see message.Setup.
+	Mtu, SharedTokens uint64
+}
+
+// Add adds the counters of o to s.  The fields that describe a single
+// connection, such as its endpoints and negotiated options, are not changed.
+func (s *ConnStats) Add(o ConnStats) {
+	s.BytesSent += o.BytesSent
+	s.BytesReceived += o.BytesReceived
+	s.MessagesSent += o.MessagesSent
+	s.MessagesReceived += o.MessagesReceived
+	s.ActiveFlows += o.ActiveFlows
+	s.TotalFlows += o.TotalFlows
+	s.FlowControlStalls += o.FlowControlStalls
+	s.HealthCheckMisses += o.HealthCheckMisses
+}
+
+// ManagerStats are statistics of a Manager.
+type ManagerStats struct {
+	// Conns are the statistics of the open connections of the Manager.
+	Conns []ConnStats
+	// TotalConns is the number of connections ever established by the
+	// Manager, including closed ones.
+	TotalConns uint64
+	// Reconnects is the number of times that a PinnedConn was reconnected
+	// after its connection was closed.
+	Reconnects uint64
+	// Total holds the sums of the counters of all the connections ever
+	// established by the Manager, including closed ones.
+	Total ConnStats
+}
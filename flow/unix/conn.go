@@ -0,0 +1,152 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unix
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"v.io/v23/verror"
+)
+
+const (
+	// maxStreamMessage is the size of the largest message that can be framed
+	// on a stream socket, whose length is sent in 3 bytes.
+	maxStreamMessage = 1<<24 - 1
+	// maxPacketMessage is the size of the largest message that is sent on a
+	// sequenced packet socket, which must fit in the socket's send buffer.
+	maxPacketMessage = 1 << 17
+)
+
+// streamConn frames messages on a stream socket by prefixing each with its
+// length as a 3 byte big-endian integer.
+type streamConn struct {
+	c       *net.UnixConn
+	writeMu sync.Mutex
+	readMu  sync.Mutex
+	header  [3]byte // GUARDED_BY(readMu)
+}
+
+// WriteMsg implements flow.MsgWriter.
+func (s *streamConn) WriteMsg(data ...[]byte) (int, error) {
+	var size int
+	for _, d := range data {
+		size += len(d)
+	}
+	if size > maxStreamMessage {
+		return 0, verror.New(errMessageTooLong, nil, size, maxStreamMessage)
+	}
+	msg := make([]byte, 3, 3+size)
+	msg[0], msg[1], msg[2] = byte(size>>16), byte(size>>8), byte(size)
+	for _, d := range data {
+		msg = append(msg, d...)
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if _, err := s.c.Write(msg); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// ReadMsg implements flow.MsgReader.
+func (s *streamConn) ReadMsg() ([]byte, error) {
+	s.readMu.Lock()
+	defer s.readMu.Unlock()
+	if _, err := io.ReadFull(s.c, s.header[:]); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, int(s.header[0])<<16|int(s.header[1])<<8|int(s.header[2]))
+	if _, err := io.ReadFull(s.c, msg); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return msg, nil
+}
+
+// Close implements flow.MsgReadWriteCloser.
+func (s *streamConn) Close() error {
+	return s.c.Close()
+}
+
+// LocalAddr implements flow.Conn.
+func (s *streamConn) LocalAddr() net.Addr {
+	return s.c.LocalAddr()
+}
+
+// PeerCredentials implements CredentialsConn.
+func (s *streamConn) PeerCredentials() (Credentials, error) {
+	return peerCredentials(s.c)
+}
+
+// packetConn sends each message as a single packet on a sequenced packet
+// socket.
+type packetConn struct {
+	c      *net.UnixConn
+	readMu sync.Mutex
+	// buf is larger than the largest message, so that truncated packets are
+	// detected.  It is allocated by the first ReadMsg.
+	buf []byte // GUARDED_BY(readMu)
+}
+
+// WriteMsg implements flow.MsgWriter.
+func (p *packetConn) WriteMsg(data ...[]byte) (int, error) {
+	var size int
+	for _, d := range data {
+		size += len(d)
+	}
+	switch {
+	case size == 0:
+		// An empty packet can't be told apart from the end of the stream.
+		return 0, verror.New(errEmptyMessage, nil)
+	case size > maxPacketMessage:
+		return 0, verror.New(errMessageTooLong, nil, size, maxPacketMessage)
+	}
+	msg := make([]byte, 0, size)
+	for _, d := range data {
+		msg = append(msg, d...)
+	}
+	return p.c.Write(msg)
+}
+
+// ReadMsg implements flow.MsgReader.
+func (p *packetConn) ReadMsg() ([]byte, error) {
+	p.readMu.Lock()
+	defer p.readMu.Unlock()
+	if p.buf == nil {
+		p.buf = make([]byte, maxPacketMessage+1)
+	}
+	n, err := p.c.Read(p.buf)
+	switch {
+	case err != nil:
+		return nil, err
+	case n == 0:
+		// The other end has closed the socket.
+		return nil, io.EOF
+	case n > maxPacketMessage:
+		return nil, verror.New(errTruncated, nil)
+	}
+	msg := make([]byte, n)
+	copy(msg, p.buf[:n])
+	return msg, nil
+}
+
+// Close implements flow.MsgReadWriteCloser.
+func (p *packetConn) Close() error {
+	return p.c.Close()
+}
+
+// LocalAddr implements flow.Conn.
+func (p *packetConn) LocalAddr() net.Addr {
+	return p.c.LocalAddr()
+}
+
+// PeerCredentials implements CredentialsConn.
+func (p *packetConn) PeerCredentials() (Credentials, error) {
+	return peerCredentials(p.c)
+}
@@ -0,0 +1,30 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unix
+
+import (
+	"net"
+	"syscall"
+)
+
+// peerCredentials returns the credentials recorded by the kernel when c was
+// connected, using SO_PEERCRED.
+func peerCredentials(c *net.UnixConn) (Credentials, error) {
+	raw, err := c.SyscallConn()
+	if err != nil {
+		return Credentials{}, err
+	}
+	var ucred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		ucred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return Credentials{}, err
+	}
+	if credErr != nil {
+		return Credentials{}, credErr
+	}
+	return Credentials{UID: ucred.Uid, GID: ucred.Gid, PID: ucred.Pid}, nil
+}
@@ -0,0 +1,17 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !linux
+
+package unix
+
+import (
+	"net"
+
+	"v.io/v23/verror"
+)
+
+func peerCredentials(*net.UnixConn) (Credentials, error) {
+	return Credentials{}, verror.New(ErrNoCredentials, nil)
+}
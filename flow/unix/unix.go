@@ -0,0 +1,180 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package unix implements flow.Protocols over unix domain sockets, registered
+// as "unix" and "unixpacket".
+//
+// "unix" uses stream sockets, on which each message is framed with its
+// length; "unixpacket" uses sequenced packet sockets, which preserve message
+// boundaries, so that each message is sent as a single packet.  Addresses are
+// socket paths.  On Linux, an address that starts with "@" is in the abstract
+// namespace and does not appear in the file system.
+//
+// Conns of these protocols implement CredentialsConn, which reports the
+// credentials of the process at the other end of the socket as verified by
+// the operating system.  Runtimes make them available to authorizers, in
+// addition to the blessings of the remote end, as attributes of the remote
+// endpoint set by WithPeerCredentials:
+//
+//   if creds, ok := unix.EndpointCredentials(call.RemoteEndpoint()); ok && creds.UID == 0 {
+//     ...
+//   }
+package unix
+
+import (
+	"net"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/flow"
+	"v.io/v23/naming"
+	"v.io/v23/verror"
+)
+
+const pkgPath = "v.io/v23/flow/unix"
+
+var (
+	// ErrNoCredentials indicates that the credentials of the peer of a socket
+	// are not available, e.g. because the system does not support them.
+	ErrNoCredentials  = verror.Register(pkgPath+".ErrNoCredentials", verror.NoRetry, "{1:}{2:} peer credentials are not available{:_}")
+	errMessageTooLong = verror.Register(pkgPath+".errMessageTooLong", verror.NoRetry, "{1:}{2:} message of {3} bytes exceeds the maximum of {4} bytes{:_}")
+	errEmptyMessage   = verror.Register(pkgPath+".errEmptyMessage", verror.NoRetry, "{1:}{2:} empty messages can't be sent as packets{:_}")
+	errTruncated      = verror.Register(pkgPath+".errTruncated", verror.NoRetry, "{1:}{2:} packet was truncated{:_}")
+)
+
+func init() {
+	for _, p := range []string{"unix", "unixpacket"} {
+		flow.RegisterProtocol(p, protocol{})
+	}
+}
+
+// Credentials are the credentials of the process at the other end of a
+// socket, as recorded by the operating system when the socket was connected.
+type Credentials struct {
+	UID, GID uint32
+	PID      int32
+}
+
+// CredentialsConn is implemented by the Conns of the unix protocols.
+type CredentialsConn interface {
+	flow.Conn
+	// PeerCredentials returns the credentials of the process at the other end
+	// of the Conn.  An error is returned on systems that do not support
+	// them.
+	PeerCredentials() (Credentials, error)
+}
+
+// PeerCredentials returns the credentials of the process at the other end of
+// conn, which must be a Conn of the unix protocols.
+func PeerCredentials(conn flow.Conn) (Credentials, error) {
+	if c, ok := conn.(CredentialsConn); ok {
+		return c.PeerCredentials()
+	}
+	return Credentials{}, verror.New(ErrNoCredentials, nil)
+}
+
+// SocketCredentials returns the credentials of the process at the other end
+// of c, for users of unix domain sockets that don't go through the flow
+// protocols.
+func SocketCredentials(c *net.UnixConn) (Credentials, error) {
+	return peerCredentials(c)
+}
+
+// The keys of the endpoint attributes set by WithPeerCredentials.
+const (
+	UIDAttr = "unix.uid"
+	GIDAttr = "unix.gid"
+	PIDAttr = "unix.pid"
+)
+
+// WithPeerCredentials returns ep, the endpoint of the remote end of conn,
+// with the peer credentials of conn as attributes, if it has any.  The
+// attributes of ep with the same keys are always removed first, since the
+// remote end may have advertised them itself: runtimes must pass every remote
+// endpoint through WithPeerCredentials for the attributes to be trusted.
+func WithPeerCredentials(ep naming.Endpoint, conn flow.Conn) (naming.Endpoint, error) {
+	var attrs []naming.Attribute
+	for _, a := range ep.Attributes() {
+		switch a.Key {
+		case UIDAttr, GIDAttr, PIDAttr:
+		default:
+			attrs = append(attrs, a)
+		}
+	}
+	if creds, err := PeerCredentials(conn); err == nil {
+		attrs = append(attrs,
+			naming.Attribute{Key: UIDAttr, Value: int64(creds.UID)},
+			naming.Attribute{Key: GIDAttr, Value: int64(creds.GID)},
+			naming.Attribute{Key: PIDAttr, Value: int64(creds.PID)})
+	}
+	return ep.WithAttributes(attrs...)
+}
+
+// EndpointCredentials returns the peer credentials recorded in ep by
+// WithPeerCredentials, and false if it has none.
+func EndpointCredentials(ep naming.Endpoint) (Credentials, bool) {
+	uid, ok1 := ep.IntAttribute(UIDAttr)
+	gid, ok2 := ep.IntAttribute(GIDAttr)
+	pid, ok3 := ep.IntAttribute(PIDAttr)
+	if !ok1 || !ok2 || !ok3 {
+		return Credentials{}, false
+	}
+	return Credentials{UID: uint32(uid), GID: uint32(gid), PID: int32(pid)}, true
+}
+
+type protocol struct{}
+
+// Dial implements flow.Protocol.
+func (protocol) Dial(ctx *context.T, protocol, address string, timeout time.Duration) (flow.Conn, error) {
+	c, err := net.DialTimeout(protocol, address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return newConn(protocol, c.(*net.UnixConn)), nil
+}
+
+// Resolve implements flow.Protocol.  Socket paths resolve to themselves.
+func (protocol) Resolve(ctx *context.T, protocol, address string) (string, []string, error) {
+	return protocol, []string{address}, nil
+}
+
+// Listen implements flow.Protocol.
+func (protocol) Listen(ctx *context.T, protocol, address string) (flow.Listener, error) {
+	ln, err := net.Listen(protocol, address)
+	if err != nil {
+		return nil, err
+	}
+	return &listener{protocol, ln.(*net.UnixListener)}, nil
+}
+
+type listener struct {
+	protocol string
+	ln       *net.UnixListener
+}
+
+// Accept implements flow.Listener.
+func (l *listener) Accept(ctx *context.T) (flow.Conn, error) {
+	c, err := l.ln.AcceptUnix()
+	if err != nil {
+		return nil, err
+	}
+	return newConn(l.protocol, c), nil
+}
+
+// Addr implements flow.Listener.
+func (l *listener) Addr() net.Addr {
+	return l.ln.Addr()
+}
+
+// Close implements flow.Listener.  The socket file, if any, is removed.
+func (l *listener) Close() error {
+	return l.ln.Close()
+}
+
+func newConn(protocol string, c *net.UnixConn) CredentialsConn {
+	if protocol == "unixpacket" {
+		return &packetConn{c: c}
+	}
+	return &streamConn{c: c}
+}
@@ -0,0 +1,143 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unix_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/flow"
+	"v.io/v23/flow/unix"
+	"v.io/v23/naming"
+)
+
+func pipe(t *testing.T, ctx *context.T, protocol, address string) (flow.Conn, flow.Conn, flow.Listener) {
+	p, _ := flow.RegisteredProtocol(protocol)
+	if p == nil {
+		t.Fatalf("%s is not registered", protocol)
+	}
+	ln, err := p.Listen(ctx, protocol, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := p.Dial(ctx, protocol, ln.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ln.Accept(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, server, ln
+}
+
+func TestProtocols(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	dir, err := ioutil.TempDir("", "unix_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, protocol := range []string{"unix", "unixpacket"} {
+		client, server, ln := pipe(t, ctx, protocol, filepath.Join(dir, protocol))
+		msgs := [][]byte{[]byte("hello"), bytes.Repeat([]byte{7}, 100000), []byte("world")}
+		go func() {
+			for _, msg := range msgs {
+				if _, err := client.WriteMsg(msg[:1], msg[1:]); err != nil {
+					t.Error(err)
+				}
+			}
+			client.Close()
+		}()
+		// The messages are compared once they have all been read, since
+		// they must not share the buffers of the conn.
+		var got [][]byte
+		for range msgs {
+			msg, err := server.ReadMsg()
+			if err != nil {
+				t.Errorf("%s: %v", protocol, err)
+			}
+			got = append(got, msg)
+		}
+		for i, want := range msgs {
+			if !bytes.Equal(got[i], want) {
+				t.Errorf("%s: got %d bytes, want %d bytes", protocol, len(got[i]), len(want))
+			}
+		}
+		if _, err := server.ReadMsg(); err == nil {
+			t.Errorf("%s: expected an error reading from a closed conn", protocol)
+		}
+		server.Close()
+		ln.Close()
+	}
+}
+
+func TestPeerCredentials(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only supported on linux")
+	}
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	// Use the abstract namespace, which is also specific to linux.
+	client, server, ln := pipe(t, ctx, "unix", fmt.Sprintf("@unix_test.%d", os.Getpid()))
+	defer ln.Close()
+	defer client.Close()
+	defer server.Close()
+	want := unix.Credentials{UID: uint32(os.Getuid()), GID: uint32(os.Getgid()), PID: int32(os.Getpid())}
+	for _, c := range []flow.Conn{client, server} {
+		if got, err := unix.PeerCredentials(c); err != nil || got != want {
+			t.Errorf("got (%+v, %v), want %+v", got, err, want)
+		}
+	}
+
+	// The credentials replace those advertised by the remote end in its
+	// endpoint.
+	ep, err := naming.Endpoint{Protocol: "unix"}.WithAttributes(
+		naming.Attribute{Key: unix.UIDAttr, Value: 12345},
+		naming.Attribute{Key: "region", Value: "local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ep, err = unix.WithPeerCredentials(ep, server); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := unix.EndpointCredentials(ep); !ok || got != want {
+		t.Errorf("got (%+v, %v), want %+v", got, ok, want)
+	}
+	if region, _ := ep.StringAttribute("region"); region != "local" {
+		t.Errorf("got region %q, want \"local\"", region)
+	}
+	// Or are removed if the conn has none.
+	if ep, err = unix.WithPeerCredentials(ep, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := unix.EndpointCredentials(ep); ok || len(ep.Attributes()) != 1 {
+		t.Errorf("got (%+v, %v) and attributes %v, want no credentials", got, ok, ep.Attributes())
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	for _, address := range []string{"/tmp/a@b/sock", "@abstract", "/tmp//x/", ""} {
+		ep := naming.Endpoint{Protocol: "unix", Address: address}
+		addr, suffix := naming.SplitAddressName(naming.JoinAddressName(ep.String(), "a/b"))
+		if suffix != "a/b" {
+			t.Errorf("%q: got suffix %q, want \"a/b\"", address, suffix)
+		}
+		got, err := naming.ParseEndpoint(addr)
+		if err != nil || got.Address != address {
+			t.Errorf("%q: got (%q, %v)", address, got.Address, err)
+		}
+	}
+}
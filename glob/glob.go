@@ -12,13 +12,27 @@
 //   '*'         matches any sequence of non-Separator characters
 //   '?'         matches any single non-Separator character
 //   '[' [ '^' ] { character-range } ']'
+//   '{' alternative [ ',' alternative ]* '}'
+//               matches any of the alternatives, which are themselves
+//               sequences of terms, possibly empty
 //   // Character classes (must be non-empty):
-//   c           matches character c (c != '*', '?', '\\', '[', '/')
+//   c           matches character c (c != '*', '?', '\\', '[', '{', '/')
 //   '\\' c      matches character c
 //   // Character-ranges:
 //   c           matches character c (c != '\\', '-', ']')
 //   '\\' c      matches character c
 //   lo '-' hi   matches character c for lo <= c <= hi
+//
+// In addition, the following elements have special meanings:
+//
+//   '!' element  matches any single element that element doesn't match
+//   '...'        matches any number of elements, including none
+//   '***'        the same as '...', but restricted (up to the caller to know
+//                what that means); only allowed as the last element
+//
+// For example, "{prod,staging}/*/logs" matches "prod/a/logs" and
+// "staging/b/logs", "!__debug/..." matches all the names that aren't below
+// "__debug", and ".../stats" matches "stats", "a/stats", "a/b/stats", etc.
 package glob
 
 import (
@@ -31,6 +45,11 @@ type Glob struct {
 	elems      []*Element
 	recursive  bool
 	restricted bool
+	// alts, if not nil, are the patterns whose union the Glob represents.
+	// They are suffixes of the same pattern, with the same recursive and
+	// restricted fields as the Glob.  Only MatchInitialSegment returns such
+	// Globs.
+	alts []*Glob
 }
 
 // Parse returns a new Glob.
@@ -57,23 +76,68 @@ func Parse(pattern string) (*Glob, error) {
 	}
 	g.elems = make([]*Element, len(elems))
 	for i, elem := range elems {
-		g.elems[i] = &Element{pattern: elem}
-		if err := g.elems[i].validate(); err != nil {
+		e, err := newElement(elem)
+		if err != nil {
 			return nil, err
 		}
+		g.elems[i] = e
 	}
 
 	return g, nil
 }
 
+// branches returns the patterns whose union g represents, with the leading
+// globstar elements, which may match no element, expanded: "a/.../b" is
+// returned as is, but ".../b" is returned as ".../b" and "b".
+func (g *Glob) branches() []*Glob {
+	if g.alts != nil {
+		return g.alts
+	}
+	branches := []*Glob{g}
+	for b := g; len(b.elems) > 0 && b.elems[0].globstar; {
+		b = &Glob{elems: b.elems[1:], recursive: b.recursive, restricted: b.restricted}
+		branches = append(branches, b)
+	}
+	return branches
+}
+
+// union returns the Glob that represents the union of branches, which are
+// suffixes of the same pattern.
+func union(branches []*Glob) *Glob {
+	var alts []*Glob
+	seen := make(map[int]bool)
+	for _, b := range branches {
+		for _, a := range b.branches() {
+			if !seen[len(a.elems)] {
+				seen[len(a.elems)] = true
+				alts = append(alts, a)
+			}
+		}
+	}
+	if len(alts) == 1 {
+		return alts[0]
+	}
+	return &Glob{recursive: alts[0].recursive, restricted: alts[0].restricted, alts: alts}
+}
+
 // Len returns the number of path elements represented by the glob expression.
+// Globstar elements that may match no element aren't counted when they come
+// first, and a union counts its shortest pattern, so that Len returns 0 iff
+// the pattern (or, after Tail, one of the patterns that the rest of the name
+// may have to match) matches the empty name.
 func (g *Glob) Len() int {
-	return len(g.elems)
+	min := -1
+	for _, b := range g.branches() {
+		if min < 0 || len(b.elems) < min {
+			min = len(b.elems)
+		}
+	}
+	return min
 }
 
 // Empty returns true if the pattern cannot match anything.
 func (g *Glob) Empty() bool {
-	return !g.recursive && len(g.elems) == 0
+	return !g.recursive && g.Len() == 0 && len(g.branches()) == 1
 }
 
 // Recursive returns true if the pattern is recursive.
@@ -87,16 +151,69 @@ func (g *Glob) Restricted() bool {
 	return g.restricted
 }
 
-// Tail returns the suffix of g starting at the second element.
+// Tail returns the Glob that the rest of a name must match after its first
+// element matched Head.
+//
+// Servers walk the namespace with Len, Empty, Head and Tail: they report a
+// name if Len is 0, and descend into the children that Head matches with
+// Tail.  Since Tail doesn't know which segment Head matched, when the first
+// element of g may be a globstar element, Tail returns the union of all the
+// patterns that the rest of the name may have to match, as
+// MatchInitialSegment does for the segments that match every branch of g.
+// The walk then reports all the names that g matches, and may also report
+// names that g doesn't match when g has a globstar element before its last
+// element ("a/.../b" but not "a/b/...").  Such a walk can't be exact, since
+// all the children that Head matches get the same Tail; servers that descend
+// with MatchInitialSegment(child) instead of Tail report exactly the names
+// that g matches.
 func (g *Glob) Tail() *Glob {
+	if g.alts == nil && (len(g.elems) == 0 || !g.elems[0].globstar) {
+		return g.tail()
+	}
+	var tails []*Glob
+	for _, b := range g.branches() {
+		switch {
+		case len(b.elems) == 0:
+			if b.recursive {
+				tails = append(tails, b)
+			}
+		case b.elems[0].globstar:
+			tails = append(tails, b)
+		default:
+			tails = append(tails, b.tail())
+		}
+	}
+	if len(tails) == 0 {
+		// No branch can match another element.
+		return &Glob{elems: []*Element{{neverMatch: true}}}
+	}
+	return union(tails)
+}
+
+// tail returns the suffix of g starting at the second element, ignoring alts.
+func (g *Glob) tail() *Glob {
 	if len(g.elems) <= 1 {
 		return &Glob{elems: nil, recursive: g.recursive, restricted: g.restricted}
 	}
 	return &Glob{elems: g.elems[1:], recursive: g.recursive, restricted: g.restricted}
 }
 
-// Head returns an Element for the first element of the glob pattern.
+// Head returns an Element for the first element of the glob pattern.  The
+// Element matches all the segments that may start a name matched by the
+// pattern, so that servers can prune the names that don't.
 func (g *Glob) Head() *Element {
+	if branches := g.branches(); len(branches) > 1 {
+		e := &Element{}
+		for _, b := range branches {
+			e.or = append(e.or, b.head())
+		}
+		return e
+	}
+	return g.head()
+}
+
+// head returns an Element for the first element of g, ignoring alts.
+func (g *Glob) head() *Element {
 	if len(g.elems) == 0 {
 		if g.recursive {
 			return &Element{alwaysMatch: true}
@@ -106,15 +223,43 @@ func (g *Glob) Head() *Element {
 	return g.elems[0]
 }
 
+// MatchInitialSegment matches segment against the first element of g.  It
+// returns the Glob that the rest of the name must match, and whether segment
+// matched.  Unlike Head and Tail, it handles globstar elements anywhere in
+// the pattern.
+func (g *Glob) MatchInitialSegment(segment string) (*Glob, bool) {
+	var next []*Glob
+	for _, b := range g.branches() {
+		switch {
+		case len(b.elems) == 0:
+			if b.recursive {
+				next = append(next, b)
+			}
+		case !b.elems[0].Match(segment):
+		case b.elems[0].globstar:
+			next = append(next, b)
+		default:
+			next = append(next, b.tail())
+		}
+	}
+	if len(next) == 0 {
+		return nil, false
+	}
+	return union(next), true
+}
+
 // SplitFixedElements returns the part of the glob pattern that contains only
 // fixed elements, and the glob that follows it.
 func (g *Glob) SplitFixedElements() ([]string, *Glob) {
+	if g.alts != nil {
+		return nil, g
+	}
 	var prefix []string
 	tail := g
 	for _, elem := range g.elems {
 		if pfx, fixed := elem.FixedPrefix(); fixed {
 			prefix = append(prefix, pfx)
-			tail = tail.Tail()
+			tail = tail.tail()
 		} else {
 			break
 		}
@@ -122,8 +267,17 @@ func (g *Glob) SplitFixedElements() ([]string, *Glob) {
 	return prefix, tail
 }
 
-// String returns the string representation of the glob pattern.
+// String returns the string representation of the glob pattern.  The Globs
+// returned by MatchInitialSegment that represent several patterns are
+// represented as the list of the patterns, in braces.
 func (g *Glob) String() string {
+	if g.alts != nil {
+		alts := make([]string, len(g.alts))
+		for i, a := range g.alts {
+			alts[i] = a.String()
+		}
+		return "{" + strings.Join(alts, ",") + "}"
+	}
 	elems := make([]string, len(g.elems))
 	for i, e := range g.elems {
 		elems[i] = e.pattern
@@ -143,6 +297,37 @@ type Element struct {
 	pattern     string
 	alwaysMatch bool
 	neverMatch  bool
+	// globstar is true for the "..." element, which matches any number of
+	// elements.  As an Element, they match any segment.
+	globstar bool
+	// negated is true if the pattern starts with '!'.
+	negated bool
+	// alts are the patterns, in path.Match syntax, that the pattern matches
+	// the union of, after removing '!' and expanding braces.
+	alts []string
+	// or, if not nil, are the Elements whose union the Element represents.
+	or []*Element
+}
+
+func newElement(pattern string) (*Element, error) {
+	m := &Element{pattern: pattern}
+	if pattern == "..." {
+		m.globstar = true
+		return m, nil
+	}
+	if strings.HasPrefix(pattern, "!") {
+		m.negated = true
+		pattern = pattern[1:]
+	}
+	if err := validate(pattern); err != nil {
+		return nil, err
+	}
+	alts, err := expandBraces(pattern)
+	if err != nil {
+		return nil, err
+	}
+	m.alts = alts
+	return m, nil
 }
 
 // Match returns true iff this pattern element matches the given segment.
@@ -150,25 +335,67 @@ func (m *Element) Match(segment string) bool {
 	if m.neverMatch {
 		return false
 	}
-	if m.alwaysMatch {
+	if m.alwaysMatch || m.globstar {
 		return true
 	}
-	matches, err := path.Match(m.pattern, segment)
-	return err == nil && matches
+	if m.or != nil {
+		for _, e := range m.or {
+			if e.Match(segment) {
+				return true
+			}
+		}
+		return false
+	}
+	matched := false
+	for _, alt := range m.alts {
+		if ok, err := path.Match(alt, segment); err == nil && ok {
+			matched = true
+			break
+		}
+	}
+	return matched != m.negated
 }
 
 // FixedPrefix returns the unescaped fixed part of the pattern, and whether the
 // prefix is the whole pattern. The fixed part does not contain any wildcards.
+// The fixed part of a pattern with alternatives is their common prefix.
 func (m *Element) FixedPrefix() (string, bool) {
 	if m.neverMatch {
 		return "", true
 	}
-	if m.alwaysMatch {
+	if m.alwaysMatch || m.globstar || m.negated {
 		return "", false
 	}
+	var prefixes []string
+	full := true
+	if m.or != nil {
+		for _, e := range m.or {
+			p, f := e.FixedPrefix()
+			prefixes = append(prefixes, p)
+			full = full && f
+		}
+	} else {
+		for _, alt := range m.alts {
+			p, f := fixedPrefix(alt)
+			prefixes = append(prefixes, p)
+			full = full && f
+		}
+	}
+	prefix := commonPrefix(prefixes)
+	for _, p := range prefixes {
+		if p != prefix {
+			full = false
+		}
+	}
+	return prefix, full
+}
+
+// fixedPrefix returns the unescaped fixed part of pattern, in path.Match
+// syntax, and whether the prefix is the whole pattern.
+func fixedPrefix(pattern string) (string, bool) {
 	unescaped := ""
 	escape := false
-	for _, c := range m.pattern {
+	for _, c := range pattern {
 		if escape {
 			escape = false
 			unescaped += string(c)
@@ -183,13 +410,28 @@ func (m *Element) FixedPrefix() (string, bool) {
 	return unescaped, true
 }
 
-func (m *Element) validate() error {
-	if len(m.pattern) == 0 {
+func commonPrefix(strs []string) string {
+	if len(strs) == 0 {
+		return ""
+	}
+	prefix := strs[0]
+	for _, s := range strs[1:] {
+		i := 0
+		for i < len(prefix) && i < len(s) && prefix[i] == s[i] {
+			i++
+		}
+		prefix = prefix[:i]
+	}
+	return prefix
+}
+
+func validate(pattern string) error {
+	if len(pattern) == 0 {
 		return path.ErrBadPattern
 	}
 	escape := false
 	inrange := false
-	for _, c := range m.pattern {
+	for _, c := range pattern {
 		if escape {
 			escape = false
 			continue
@@ -209,3 +451,53 @@ func (m *Element) validate() error {
 	}
 	return nil
 }
+
+// expandBraces returns the patterns, in path.Match syntax, obtained by
+// replacing each brace expression of pattern by each of its alternatives.
+func expandBraces(pattern string) ([]string, error) {
+	open, close, commas := -1, -1, []int(nil)
+	depth := 0
+	escape, inrange := false, false
+scan:
+	for i, c := range pattern {
+		switch {
+		case escape:
+			escape = false
+		case c == '\\':
+			escape = true
+		case inrange:
+			inrange = c != ']'
+		case c == '[':
+			inrange = true
+		case c == '{':
+			if depth == 0 {
+				open = i
+			}
+			depth++
+		case c == '}' && depth > 0:
+			if depth--; depth == 0 {
+				close = i
+				break scan
+			}
+		case c == ',' && depth == 1:
+			commas = append(commas, i)
+		}
+	}
+	if depth != 0 {
+		return nil, path.ErrBadPattern
+	}
+	if open < 0 {
+		return []string{pattern}, nil
+	}
+	prefix, suffix := pattern[:open], pattern[close+1:]
+	bounds := append(append([]int{open}, commas...), close)
+	var patterns []string
+	for i := 1; i < len(bounds); i++ {
+		expanded, err := expandBraces(prefix + pattern[bounds[i-1]+1:bounds[i]] + suffix)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, expanded...)
+	}
+	return patterns, nil
+}
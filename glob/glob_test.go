@@ -5,6 +5,9 @@
 package glob
 
 import (
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -31,6 +34,11 @@ func TestStripFixedElements(t *testing.T) {
 		{"a/b/*/...", []string{"a", "b"}, "*/..."},
 		{"a/b/c/...", []string{"a", "b", "c"}, "..."},
 		{"a/the\\?rain.in\\*spain", []string{"a", "the?rain.in*spain"}, ""},
+		{"a/{b}/{c,d}/e", []string{"a", "b"}, "{c,d}/e"},
+		{"a/!b/c", []string{"a"}, "!b/c"},
+		{"a/.../c", []string{"a"}, ".../c"},
+		{"a/b/**", []string{"a", "b"}, "**"},
+		{"a/\\{b,c}", []string{"a", "{b,c}"}, ""},
 	}
 	for _, test := range tests {
 		g, err := Parse(test.pattern)
@@ -84,6 +92,28 @@ func TestMatch(t *testing.T) {
 		{"a/*", "b", false},
 		{"a/...", "a", true},
 		{"a/...", "b", false},
+		{"{a,b}", "a", true},
+		{"{a,b}", "b", true},
+		{"{a,b}", "c", false},
+		{"x{a,b*}y", "xby", true},
+		{"x{a,b*}y", "xbzzy", true},
+		{"x{a,b*}y", "xay", true},
+		{"x{a,b*}y", "xy", false},
+		{"x{,a}", "x", true},
+		{"{a,{b,c}d}", "cd", true},
+		{"{a,{b,c}d}", "c", false},
+		{"[{]", "{", true},
+		{"\\{a,b}", "{a,b}", true},
+		{"a}", "a}", true},
+		{"!a", "a", false},
+		{"!a", "b", true},
+		{"!__*", "__debug", false},
+		{"!{a,b}", "b", false},
+		{"!{a,b}", "c", true},
+		{"\\!a", "!a", true},
+		{".../b", "a", true},
+		{"**/b", "a", true},
+		{"**", "a", true},
 	}
 	for i, test := range tests {
 		g, err := Parse(test.pattern)
@@ -120,6 +150,14 @@ func TestFixedPrefix(t *testing.T) {
 		{"[abc]", "", false},
 		{"\\[abc]", "[abc]", true},
 		{"\\[abc]*", "[abc]", false},
+		{"{a}", "a", true},
+		{"{ab,ac}", "a", false},
+		{"{ab,ab}", "ab", true},
+		{"x{ab,ac}*", "xa", false},
+		{"\\{a,b}", "{a,b}", true},
+		{"!a", "", false},
+		{".../a", "", false},
+		{"**/a", "", false},
 	}
 	for i, test := range tests {
 		g, err := Parse(test.pattern)
@@ -134,10 +172,238 @@ func TestFixedPrefix(t *testing.T) {
 }
 
 func TestBadPattern(t *testing.T) {
-	tests := []string{"[", "[foo", "[^foo", "\\", "a\\", "abc[foo", "a//b"}
+	tests := []string{"[", "[foo", "[^foo", "\\", "a\\", "abc[foo", "a//b", "{a", "{a,{b}", "!", "a/!/b"}
 	for _, test := range tests {
 		if _, err := Parse(test); err == nil {
 			t.Errorf("Unexpected success for %q", test)
 		}
 	}
 }
+
+func TestMatchInitialSegment(t *testing.T) {
+	tests := []struct {
+		pattern string
+		matches []string
+		others  []string
+	}{
+		{"a/b", []string{"a/b"}, []string{"", "a", "a/b/c", "b"}},
+		{"a/...", []string{"a", "a/b", "a/b/c"}, []string{"", "b"}},
+		{"{prod,staging}/*/logs", []string{"prod/x/logs", "staging/y/logs"}, []string{"dev/x/logs", "prod/logs"}},
+		{"!__debug/...", []string{"a", "a/__debug", "b/c"}, []string{"", "__debug", "__debug/a"}},
+		{".../stats", []string{"stats", "a/stats", "a/b/stats", "stats/stats"}, []string{"", "a", "stats/a"}},
+		{"**/stats", []string{"a/stats", "stats/stats"}, []string{"stats", "a/b/stats", "a/stats/b"}},
+		{"a/.../b/...", []string{"a/b", "a/x/b", "a/b/c", "a/x/b/y/z"}, []string{"a", "a/x", "b"}},
+		{"a/.../b/.../c", []string{"a/b/c", "a/x/b/y/c", "a/b/b/c"}, []string{"a/c", "a/b", "a/b/c/d"}},
+		{".../...", []string{"", "a", "a/b"}, nil},
+		{"**", []string{"a", "b"}, []string{"", "a/b"}},
+	}
+	for _, test := range tests {
+		g, err := Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parsing %q: %v", test.pattern, err)
+		}
+		match := func(name string) bool {
+			h := g
+			if name != "" {
+				for _, elem := range strings.Split(name, "/") {
+					var ok bool
+					if !h.Head().Match(elem) {
+						// Head must match all the segments that may start a match.
+						if _, ok = h.MatchInitialSegment(elem); ok {
+							t.Errorf("%q: Head doesn't match %q in %q", test.pattern, elem, name)
+						}
+						return false
+					}
+					if h, ok = h.MatchInitialSegment(elem); !ok {
+						return false
+					}
+				}
+			}
+			return h.Len() == 0
+		}
+		for _, name := range test.matches {
+			if !match(name) {
+				t.Errorf("%q should match %q", test.pattern, name)
+			}
+		}
+		for _, name := range test.others {
+			if match(name) {
+				t.Errorf("%q should not match %q", test.pattern, name)
+			}
+		}
+	}
+}
+
+func TestUnionGlob(t *testing.T) {
+	g, err := Parse("a/.../b/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, _ = g.MatchInitialSegment("a")
+	if got, want := g.String(), "{.../b/c,b/c}"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := g.Len(), 2; got != want {
+		t.Errorf("got %d, want %d", got, want)
+	}
+	if f, rest := g.SplitFixedElements(); f != nil || rest != g {
+		t.Errorf("unexpected SplitFixedElements: %q, %q", f, rest)
+	}
+	if prefix, full := g.Head().FixedPrefix(); prefix != "" || full {
+		t.Errorf("unexpected FixedPrefix: %q, %v", prefix, full)
+	}
+	g, _ = g.MatchInitialSegment("b")
+	if got, want := g.String(), "{.../b/c,b/c,c}"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := g.Tail().String(), "{.../b/c,b/c,c,}"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// walkTree is a namespace, as a map from names to their children.
+var walkTree = map[string][]string{
+	"":          {"a", "b", "stats", "__debug"},
+	"a":         {"b", "stats", "x"},
+	"a/b":       {"stats", "c"},
+	"a/x":       {"y"},
+	"a/x/y":     {"b"},
+	"b":         {"b", "c"},
+	"b/b":       {"b"},
+	"stats":     {"stats", "a"},
+	"__debug":   {"stats"},
+	"a/b/stats": {"x"},
+}
+
+// serverWalk returns the names that a server walking walkTree with Len,
+// Empty, Head and Tail reports for g.
+func serverWalk(g *Glob) map[string]bool {
+	found := make(map[string]bool)
+	var walk func(name string, g *Glob)
+	walk = func(name string, g *Glob) {
+		if g.Len() == 0 {
+			found[name] = true
+		}
+		if g.Empty() {
+			return
+		}
+		head, tail := g.Head(), g.Tail()
+		for _, child := range walkTree[name] {
+			if head.Match(child) {
+				walk(strings.TrimPrefix(name+"/"+child, "/"), tail)
+			}
+		}
+	}
+	walk("", g)
+	return found
+}
+
+func TestServerWalk(t *testing.T) {
+	var all []string
+	for name, children := range walkTree {
+		if name == "" {
+			all = append(all, name)
+		}
+		for _, c := range children {
+			all = append(all, strings.TrimPrefix(name+"/"+c, "/"))
+		}
+	}
+	tests := []struct {
+		pattern string
+		// exact is false if the walk may report names that the pattern
+		// doesn't match, because of a globstar element before the last.
+		exact bool
+	}{
+		{"", true},
+		{"a/b", true},
+		{"{a,b}/b", true},
+		{"{a,{b,stats}}/*", true},
+		{"a/{,b}", true},
+		{"!a", true},
+		{"!a/...", true},
+		{"!{a,__*}/**", true},
+		{"a/...", true},
+		{"a/**", true},
+		{"a/***", true},
+		{"**", true},
+		{"**/stats", true},
+		{".../stats", false},
+		{"a/.../b", false},
+		{"a/.../b/...", false},
+		{"**/b/**/c", true},
+		{".../b/.../c", false},
+		{"{a,b}/.../b", false},
+		{"!a/.../stats", false},
+	}
+	for _, test := range tests {
+		g, err := Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parsing %q: %v", test.pattern, err)
+		}
+		m := g.Compile()
+		found := serverWalk(g)
+		for _, name := range all {
+			switch want := m.Match(name); {
+			case want && !found[name]:
+				t.Errorf("%q: walk missed %q", test.pattern, name)
+			case !want && found[name] && test.exact:
+				t.Errorf("%q: walk reported %q", test.pattern, name)
+			}
+		}
+	}
+}
+
+// segmentWalk returns the names that a server walking tree with Len, Empty,
+// Head and MatchInitialSegment reports for g.
+func segmentWalk(tree map[string][]string, g *Glob) []string {
+	var found []string
+	var walk func(name string, g *Glob)
+	walk = func(name string, g *Glob) {
+		if g.Len() == 0 {
+			found = append(found, name)
+		}
+		if g.Empty() {
+			return
+		}
+		head := g.Head()
+		for _, child := range tree[name] {
+			if !head.Match(child) {
+				continue
+			}
+			if next, ok := g.MatchInitialSegment(child); ok {
+				walk(strings.TrimPrefix(name+"/"+child, "/"), next)
+			}
+		}
+	}
+	walk("", g)
+	sort.Strings(found)
+	return found
+}
+
+func TestSegmentWalk(t *testing.T) {
+	tree := map[string][]string{
+		"":    {"a", "stats"},
+		"a":   {"b", "stats"},
+		"a/b": {"c"},
+	}
+	tests := []struct {
+		pattern string
+		found   []string
+	}{
+		{".../stats", []string{"a/stats", "stats"}},
+		{"a/.../c", []string{"a/b/c"}},
+		{"a/.../b", []string{"a/b"}},
+		{".../...", []string{"", "a", "a/b", "a/b/c", "a/stats", "stats"}},
+		{"!a/...", []string{"stats"}},
+		{"**", []string{"a", "stats"}},
+	}
+	for _, test := range tests {
+		g, err := Parse(test.pattern)
+		if err != nil {
+			t.Fatalf("parsing %q: %v", test.pattern, err)
+		}
+		if got := segmentWalk(tree, g); !reflect.DeepEqual(got, test.found) {
+			t.Errorf("%q: got %q, want %q", test.pattern, got, test.found)
+		}
+	}
+}
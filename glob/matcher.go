@@ -0,0 +1,215 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glob
+
+import (
+	"strings"
+)
+
+// Matcher matches whole slash-separated names against a glob pattern.
+// Unlike the methods of Glob, which servers use to match names one element
+// at a time while traversing the namespace, a Matcher lets clients filter
+// names, such as those of GlobReplies or watch changes, locally.
+//
+// A Matcher is safe for concurrent use.
+type Matcher struct {
+	g *Glob
+	// elems are the elements of the pattern.  The matcher is a
+	// nondeterministic automaton whose states are the indexes of elems:
+	// state i means that the next segment must match elems[i], and state
+	// len(elems) means that the name matched.
+	elems []matcherElem
+	// starts are the initial states.
+	starts []int
+	// recursive is true if state len(elems) matches any segment.
+	recursive bool
+	// traverse, if not nil, restricts the segments that state len(elems)
+	// matches for restricted patterns.
+	traverse func(prefix string) bool
+}
+
+type matcherElem struct {
+	*Element
+	// literals, if not nil, are the only segments that the element matches.
+	literals []string
+	// any is true if the element matches any segment.
+	any bool
+}
+
+func newMatcherElem(e *Element) matcherElem {
+	me := matcherElem{Element: e}
+	if e.globstar || e.negated {
+		return me
+	}
+	for _, alt := range e.alts {
+		if alt == "*" {
+			me.any = true
+		}
+	}
+	for _, alt := range e.alts {
+		literal, full := fixedPrefix(alt)
+		if !full {
+			me.literals = nil
+			break
+		}
+		me.literals = append(me.literals, literal)
+	}
+	return me
+}
+
+// Compile returns a Matcher for pattern.
+func Compile(pattern string) (*Matcher, error) {
+	g, err := Parse(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return g.Compile(), nil
+}
+
+// Compile returns a Matcher for g.
+func (g *Glob) Compile() *Matcher {
+	// All the patterns of a Glob that has alts are suffixes of the longest
+	// one.
+	longest := g
+	for _, a := range g.alts {
+		if len(a.elems) > len(longest.elems) {
+			longest = a
+		}
+	}
+	m := &Matcher{g: g, recursive: g.recursive}
+	for _, e := range longest.elems {
+		m.elems = append(m.elems, newMatcherElem(e))
+	}
+	if g.alts == nil {
+		m.starts = []int{0}
+	}
+	for _, a := range g.alts {
+		m.starts = append(m.starts, len(longest.elems)-len(a.elems))
+	}
+	return m
+}
+
+// Glob returns the Glob that m matches.
+func (m *Matcher) Glob() *Glob {
+	return m.g
+}
+
+// Traverse returns a Matcher like m that, if the pattern is restricted
+// (i.e. ends with "***"), only matches the names whose prefixes matched by
+// "***" are accepted by traverse.  For instance, with traverse returning
+// whether a name is a mount table, "a/***" matches "a/b/c" only if "a" and
+// "a/b" are mount tables, as namespace.T.Glob does.  Otherwise restricted
+// patterns match like recursive ones.
+func (m *Matcher) Traverse(traverse func(prefix string) bool) *Matcher {
+	c := *m
+	if m.g.restricted {
+		c.traverse = traverse
+	}
+	return &c
+}
+
+// Match returns true iff name matches the pattern.
+func (m *Matcher) Match(name string) bool {
+	return m.Mismatch(name) < 0
+}
+
+// Mismatch returns -1 if name matches the pattern.  Otherwise, it returns
+// the index of the element of name at which matching failed: that of the
+// first element that the pattern can't match, or the number of elements of
+// name if name is too short.  The empty name has no elements.
+func (m *Matcher) Mismatch(name string) int {
+	words := (len(m.elems) + 64) / 64
+	var buf [2]uint64
+	var cur, next bitset
+	if words == 1 {
+		cur, next = buf[:1], buf[1:]
+	} else {
+		cur, next = make(bitset, words), make(bitset, words)
+	}
+	for _, s := range m.starts {
+		m.add(cur, s)
+	}
+	if name == "" {
+		if cur.has(len(m.elems)) {
+			return -1
+		}
+		return 0
+	}
+	end := len(m.elems)
+	for i, start := 0, 0; ; i++ {
+		stop := strings.IndexByte(name[start:], '/')
+		if stop < 0 {
+			stop = len(name)
+		} else {
+			stop += start
+		}
+		segment := name[start:stop]
+		next.clear()
+		matched := false
+		for s := 0; s <= end; s++ {
+			if !cur.has(s) {
+				continue
+			}
+			switch {
+			case s == end:
+				if m.recursive && (m.traverse == nil || start == 0 && m.traverse("") || start > 0 && m.traverse(name[:start-1])) {
+					next.set(end)
+					matched = true
+				}
+			case m.elems[s].globstar:
+				m.add(next, s)
+				matched = true
+			case m.elems[s].match(segment):
+				m.add(next, s+1)
+				matched = true
+			}
+		}
+		if !matched {
+			return i
+		}
+		if stop == len(name) {
+			if next.has(end) {
+				return -1
+			}
+			return i + 1
+		}
+		cur, next = next, cur
+		start = stop + 1
+	}
+}
+
+// add adds state s to states, followed by the states that the globstar
+// elements at s and after it may skip to.
+func (m *Matcher) add(states bitset, s int) {
+	states.set(s)
+	for ; s < len(m.elems) && m.elems[s].globstar; s++ {
+		states.set(s + 1)
+	}
+}
+
+func (e *matcherElem) match(segment string) bool {
+	switch {
+	case e.any:
+		return true
+	case e.literals != nil:
+		for _, literal := range e.literals {
+			if segment == literal {
+				return true
+			}
+		}
+		return false
+	}
+	return e.Match(segment)
+}
+
+type bitset []uint64
+
+func (b bitset) set(i int)      { b[i/64] |= 1 << uint(i%64) }
+func (b bitset) has(i int) bool { return b[i/64]&(1<<uint(i%64)) != 0 }
+func (b bitset) clear() {
+	for i := range b {
+		b[i] = 0
+	}
+}
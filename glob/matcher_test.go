@@ -0,0 +1,200 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glob
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// matchBySegment matches name one element at a time, like servers do.
+func matchBySegment(g *Glob, name string) bool {
+	if name != "" {
+		for _, elem := range strings.Split(name, "/") {
+			var ok bool
+			if g, ok = g.MatchInitialSegment(elem); !ok {
+				return false
+			}
+		}
+	}
+	return g.Len() == 0
+}
+
+func TestMatcher(t *testing.T) {
+	patterns := []string{
+		"", "a", "a/b", "*", "*/b", "a/...", "a/***", "...", "{a,b}/c",
+		"!a/...", ".../b", "a/.../b", "a/.../b/...", "**/b/**/c", "a/*/...",
+	}
+	names := []string{
+		"", "a", "b", "c", "a/b", "a/c", "b/c", "a/b/c", "a/a/b", "b/b/b",
+		"a/b/b/c", "x/b/y/c", "a/x/y/b",
+	}
+	for _, pattern := range patterns {
+		g, err := Parse(pattern)
+		if err != nil {
+			t.Fatalf("parsing %q: %v", pattern, err)
+		}
+		m := g.Compile()
+		for _, name := range names {
+			if got, want := m.Match(name), matchBySegment(g, name); got != want {
+				t.Errorf("%q.Match(%q): got %v, want %v", pattern, name, got, want)
+			}
+		}
+	}
+}
+
+func TestMismatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          int
+	}{
+		{"a/b/c", "a/b/c", -1},
+		{"a/b/c", "x/b/c", 0},
+		{"a/b/c", "a/x/c", 1},
+		{"a/b/c", "a/b", 2},
+		{"a/b/c", "a/b/c/d", 3},
+		{"a/b/c", "", 0},
+		{"a/...", "a/b/c/d", -1},
+		{".../c", "a/b/c/d", 4},
+		{".../c", "a/b", 2},
+		{"{a,b}/!c", "b/c", 1},
+	}
+	for _, test := range tests {
+		m, err := Compile(test.pattern)
+		if err != nil {
+			t.Fatalf("compiling %q: %v", test.pattern, err)
+		}
+		if got := m.Mismatch(test.name); got != test.want {
+			t.Errorf("%q.Mismatch(%q): got %d, want %d", test.pattern, test.name, got, test.want)
+		}
+	}
+}
+
+func TestMatcherRestricted(t *testing.T) {
+	mountTables := map[string]bool{"": true, "a": true, "a/b": true}
+	traverse := func(prefix string) bool { return mountTables[prefix] }
+	for _, test := range []struct {
+		pattern string
+		matches []string
+		others  []string
+	}{
+		{"a/***", []string{"a", "a/b", "a/b/c", "a/x"}, []string{"a/x/y"}},
+		{"***", []string{"", "b", "a/b/c"}, []string{"b/c", "a/x/y"}},
+		{"a/...", []string{"a/b/c", "a/x/y"}, nil},
+	} {
+		m, err := Compile(test.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m = m.Traverse(traverse)
+		for _, name := range test.matches {
+			if !m.Match(name) {
+				t.Errorf("%q should match %q", test.pattern, name)
+			}
+		}
+		for _, name := range test.others {
+			if m.Match(name) {
+				t.Errorf("%q should not match %q", test.pattern, name)
+			}
+		}
+	}
+}
+
+func TestMatcherUnion(t *testing.T) {
+	g, err := Parse("a/.../b/c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, _ = g.MatchInitialSegment("a")
+	m := g.Compile()
+	for name, want := range map[string]bool{"b/c": true, "x/b/c": true, "b": false, "a/b/c": true, "c": false} {
+		if got := m.Match(name); got != want {
+			t.Errorf("%q.Match(%q): got %v, want %v", g, name, got, want)
+		}
+	}
+}
+
+func TestMatcherLong(t *testing.T) {
+	elems := make([]string, 100)
+	for i := range elems {
+		elems[i] = "a"
+	}
+	pattern := ".../" + strings.Join(elems, "/")
+	m, err := Compile(pattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := "x/" + strings.Join(elems, "/")
+	if !m.Match(name) || m.Match(name[2:len(name)-2]) {
+		t.Errorf("unexpected result for %q", pattern)
+	}
+}
+
+// benchNames returns names like "prod/s12/logs/3" and "dev/s4/stats".
+func benchNames() []string {
+	var names []string
+	for _, env := range []string{"prod", "staging", "dev", "__debug"} {
+		for s := 0; s < 250; s++ {
+			names = append(names, fmt.Sprintf("%s/s%d/stats", env, s))
+			for l := 0; l < 9; l++ {
+				names = append(names, fmt.Sprintf("%s/s%d/logs/%d", env, s, l))
+			}
+		}
+	}
+	return names
+}
+
+const (
+	benchPattern = "{prod,staging}/*/logs/..."
+	benchRegexp  = "^(prod|staging)/[^/]*/logs(/.*)?$"
+)
+
+func BenchmarkMatcher(b *testing.B) {
+	names := benchNames()
+	m, err := Compile(benchPattern)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Match(names[i%len(names)])
+	}
+}
+
+func BenchmarkMatchInitialSegment(b *testing.B) {
+	names := benchNames()
+	g, err := Parse(benchPattern)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matchBySegment(g, names[i%len(names)])
+	}
+}
+
+func BenchmarkRegexp(b *testing.B) {
+	names := benchNames()
+	re := regexp.MustCompile(benchRegexp)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		re.MatchString(names[i%len(names)])
+	}
+}
+
+func TestBenchmarkPatternsAgree(t *testing.T) {
+	m, err := Compile(benchPattern)
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(benchRegexp)
+	for _, name := range benchNames() {
+		if got, want := m.Match(name), re.MatchString(name); got != want {
+			t.Fatalf("%q: got %v, want %v", name, got, want)
+		}
+	}
+}
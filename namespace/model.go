@@ -47,7 +47,9 @@ type T interface {
 	// anything was flushed it returns true.
 	FlushCacheEntry(ctx *context.T, name string) bool
 
-	// CacheCtl sets controls and returns the current control values.
+	// CacheCtl sets controls and returns the current control values.  The
+	// controls are defined in v.io/v23/naming, and implementations may use
+	// the cache of v.io/v23/namespace/nscache.
 	CacheCtl(ctls ...naming.CacheCtl) []naming.CacheCtl
 
 	// Glob returns MountEntry's whose name matches the pattern and GlobError's
@@ -60,6 +62,11 @@ type T interface {
 	// The returned channel must be drained until it is closed, otherwise a goroutine
 	// (that publishes to the channel) may be leaked.
 	//
+	// The naming.GlobMaxResults, GlobMaxDepth, GlobStartAfter and
+	// GlobTimeBudget options bound the Glob.  When a bound is reached, the
+	// last reply is a GlobReplyTruncated whose ResumeToken, passed as
+	// GlobStartAfter, continues the Glob.
+	//
 	// Example:
 	//	rc, err := ns.Glob(ctx, pattern)
 	//	if err != nil {
@@ -75,12 +82,27 @@ type T interface {
 	//	}
 	Glob(ctx *context.T, pattern string, opts ...naming.NamespaceOpt) (<-chan naming.GlobReply, error)
 
+	// WatchGlob returns a stream of the changes to the names that match the
+	// pattern: names that are mounted, whose servers change, or that are
+	// unmounted.  The stream starts with an Added change for every name
+	// that matches the pattern, followed by a Synced change.
+	//
+	// Mount tables that implement v.io/v23/services/watch.GlobWatcher push
+	// their changes; the others are polled with Glob, every
+	// options.WatchPollInterval.  Implementations may use
+	// v.io/v23/namespace/nswatch.
+	//
+	// The channel is closed when ctx is canceled, and must be drained until
+	// then.
+	WatchGlob(ctx *context.T, pattern string, opts ...naming.NamespaceOpt) (<-chan Change, error)
+
 	// SetRoots sets the roots that the local Namespace is
 	// relative to. All relative names passed to the methods above
 	// will be interpreted as relative to these roots. The roots
 	// will be tried in the order that they are specified in the parameter
 	// list for SetRoots. Calling SetRoots with no arguments will clear the
-	// currently configured set of roots.
+	// currently configured set of roots.  See v.io/v23/namespace/multiroot
+	// for the options that control how several roots are used.
 	SetRoots(roots ...string) error
 
 	// Roots returns the currently configured roots. An empty slice is
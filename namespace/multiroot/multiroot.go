@@ -0,0 +1,477 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package multiroot defines how a namespace uses several roots, as set by
+// namespace.T.SetRoots, and implements it for namespace implementations.
+//
+// Operations that need a single root, such as resolving a name or globbing,
+// are run with Resolver.Do, according to a Policy:
+//
+//   - Failover tries the roots one at a time, in the order in which they
+//     were set.  With a HedgeDelay, the next root is also tried when a root
+//     hasn't answered within the delay, and the first answer is used.
+//   - Race tries all the roots at once, uses the first answer and cancels
+//     the other attempts.
+//
+// Only failures of a root, as defined by IsRootFailure, make a Resolver try
+// another root: an answer such as "this name doesn't exist" is final.
+//
+// The Resolver tracks the health of each root: its latency, and its
+// successive failures.  A root that fails CircuitBreaker.Failures times in a
+// row is skipped for CircuitBreaker.Cooldown, after which it is tried again,
+// once, before it is used normally.  Roots are only skipped while there are
+// others to use.
+//
+// Operations that change the namespace, such as mounting a server, are run
+// with Resolver.DoAll on all the roots, and succeed according to the
+// MountConsistency.
+//
+// All the options are naming.NamespaceOpts, so that they can be passed to the
+// methods of namespace.T, and on to the Resolver:
+//
+//   ns.Mount(ctx, name, server, ttl, multiroot.MountQuorum)
+//   ns.Resolve(ctx, name, multiroot.Failover, multiroot.HedgeDelay(50*time.Millisecond))
+package multiroot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/naming"
+	"v.io/v23/verror"
+)
+
+const pkgPath = "v.io/v23/namespace/multiroot"
+
+var (
+	errNoRoots     = verror.Register(pkgPath+".errNoRoots", verror.NoRetry, "{1:}{2:} no roots are set{:_}")
+	errConsistency = verror.Register(pkgPath+".errConsistency", verror.NoRetry, "{1:}{2:} {3} of {4} roots succeeded, {5} required: {6}{:_}")
+)
+
+// Policy specifies how Resolver.Do uses the roots.
+type Policy int
+
+const (
+	// Failover tries the roots one at a time, in order.  It is the
+	// default.
+	Failover Policy = iota
+	// Race tries all the roots at once.
+	Race
+)
+
+func (Policy) NSOpt() {}
+
+func (p Policy) String() string {
+	switch p {
+	case Failover:
+		return "Failover"
+	case Race:
+		return "Race"
+	}
+	return fmt.Sprintf("Policy(%d)", int(p))
+}
+
+// HedgeDelay makes the Failover policy try the next root when a root hasn't
+// answered within the delay, without canceling the first attempt.  Zero, the
+// default, disables hedging.
+type HedgeDelay time.Duration
+
+func (HedgeDelay) NSOpt() {}
+
+// MountConsistency specifies how many roots must succeed for Resolver.DoAll
+// to succeed.
+type MountConsistency int
+
+const (
+	// MountAll requires all the roots to succeed.  It is the default.
+	MountAll MountConsistency = iota
+	// MountQuorum requires a majority of the roots to succeed.
+	MountQuorum
+	// MountAny requires one root to succeed.
+	MountAny
+)
+
+func (MountConsistency) NSOpt() {}
+
+func (c MountConsistency) String() string {
+	switch c {
+	case MountAll:
+		return "MountAll"
+	case MountQuorum:
+		return "MountQuorum"
+	case MountAny:
+		return "MountAny"
+	}
+	return fmt.Sprintf("MountConsistency(%d)", int(c))
+}
+
+// required returns the number of roots, out of n, that must succeed.
+func (c MountConsistency) required(n int) int {
+	switch c {
+	case MountQuorum:
+		return n/2 + 1
+	case MountAny:
+		return 1
+	}
+	return n
+}
+
+// CircuitBreaker configures when roots are skipped.  A zero Failures
+// disables circuit breaking.
+type CircuitBreaker struct {
+	// Failures is the number of successive failures after which a root is
+	// skipped.
+	Failures int
+	// Cooldown is how long a root is skipped.
+	Cooldown time.Duration
+}
+
+func (CircuitBreaker) NSOpt() {}
+
+// DefaultCircuitBreaker is the CircuitBreaker of a Resolver, unless another
+// one is passed to New.
+var DefaultCircuitBreaker = CircuitBreaker{Failures: 3, Cooldown: 30 * time.Second}
+
+// IsRootFailure returns true if err means that a root couldn't answer,
+// rather than being its answer.  Errors that aren't verrors, and verrors
+// with the IDs ErrNoServers, ErrTimeout, ErrBadProtocol, ErrInternal or
+// ErrUnknown, or with the RetryConnection or RetryBackoff actions, are
+// failures.
+func IsRootFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch verror.ErrorID(err) {
+	case verror.ErrNoServers.ID, verror.ErrTimeout.ID, verror.ErrBadProtocol.ID, verror.ErrInternal.ID, verror.ErrUnknown.ID:
+		return true
+	}
+	switch verror.Action(err).RetryAction() {
+	case verror.RetryConnection, verror.RetryBackoff:
+		return true
+	}
+	return false
+}
+
+// State is the state of the circuit breaker of a root.
+type State int
+
+const (
+	// Closed means that the root is used.
+	Closed State = iota
+	// Open means that the root is skipped, until OpenUntil.
+	Open
+	// HalfOpen means that the root is being tried after being skipped.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "Closed"
+	case Open:
+		return "Open"
+	case HalfOpen:
+		return "HalfOpen"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Health is the health of a root.
+type Health struct {
+	Root string
+	// Latency is a moving average of the time the root takes to answer.
+	Latency time.Duration
+	// Successes and Failures count the answers and the failures of the
+	// root.
+	Successes, Failures uint64
+	// SuccessiveFailures is the number of failures since the last answer.
+	SuccessiveFailures int
+	State              State
+	OpenUntil          time.Time
+}
+
+// Op is an operation on a single root.
+type Op func(ctx *context.T, root string) error
+
+// Resolver runs operations on a set of roots.  It is safe for concurrent
+// use.
+type Resolver struct {
+	mu      sync.Mutex
+	roots   []*Health        // GUARDED_BY(mu)
+	breaker CircuitBreaker   // GUARDED_BY(mu)
+	now     func() time.Time // GUARDED_BY(mu)
+	policy  Policy
+	hedge   time.Duration
+	mount   MountConsistency
+}
+
+// New returns a Resolver for roots.  The options set the defaults of the
+// Resolver, which the options passed to Do and DoAll override, and its
+// CircuitBreaker.
+func New(roots []string, opts ...naming.NamespaceOpt) *Resolver {
+	r := &Resolver{breaker: DefaultCircuitBreaker, now: time.Now}
+	r.policy, r.hedge, r.mount = r.options(opts)
+	for _, o := range opts {
+		if b, ok := o.(CircuitBreaker); ok {
+			r.breaker = b
+		}
+	}
+	r.SetRoots(roots...)
+	return r
+}
+
+// SetClock sets the function used by the Resolver to tell the time, for
+// tests.
+func (r *Resolver) SetClock(now func() time.Time) {
+	r.mu.Lock()
+	r.now = now
+	r.mu.Unlock()
+}
+
+func (r *Resolver) options(opts []naming.NamespaceOpt) (Policy, time.Duration, MountConsistency) {
+	policy, hedge, mount := r.policy, r.hedge, r.mount
+	for _, o := range opts {
+		switch v := o.(type) {
+		case Policy:
+			policy = v
+		case HedgeDelay:
+			hedge = time.Duration(v)
+		case MountConsistency:
+			mount = v
+		}
+	}
+	return policy, hedge, mount
+}
+
+// SetRoots sets the roots, in order of preference.  The health of the roots
+// that were already set is kept.
+func (r *Resolver) SetRoots(roots ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := make(map[string]*Health)
+	for _, h := range r.roots {
+		old[h.Root] = h
+	}
+	r.roots = nil
+	for _, root := range roots {
+		h := old[root]
+		if h == nil {
+			h = &Health{Root: root}
+		}
+		r.roots = append(r.roots, h)
+	}
+}
+
+// Roots returns the roots, in order of preference.
+func (r *Resolver) Roots() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	roots := make([]string, len(r.roots))
+	for i, h := range r.roots {
+		roots[i] = h.Root
+	}
+	return roots
+}
+
+// Health returns the health of the roots, in order of preference.
+func (r *Resolver) Health() []Health {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	health := make([]Health, len(r.roots))
+	for i, h := range r.roots {
+		health[i] = *h
+	}
+	return health
+}
+
+// order returns the roots to try: the roots that aren't skipped, in order of
+// preference, followed by the others.  An Open root whose cooldown has
+// elapsed becomes HalfOpen, and is tried by a single operation.
+func (r *Resolver) order() []*Health {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	var usable, skipped []*Health
+	for _, h := range r.roots {
+		switch {
+		case h.State == Open && !now.Before(h.OpenUntil):
+			h.State = HalfOpen
+			usable = append(usable, h)
+		case h.State == Closed:
+			usable = append(usable, h)
+		default:
+			skipped = append(skipped, h)
+		}
+	}
+	return append(usable, skipped...)
+}
+
+// record updates the health of a root with the outcome of an operation that
+// started at start.
+func (r *Resolver) record(h *Health, start time.Time, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if !IsRootFailure(err) {
+		latency := now.Sub(start)
+		if h.Successes == 0 {
+			h.Latency = latency
+		} else {
+			h.Latency += (latency - h.Latency) / 8
+		}
+		h.Successes++
+		h.SuccessiveFailures = 0
+		h.State = Closed
+		return
+	}
+	h.Failures++
+	h.SuccessiveFailures++
+	if h.State == HalfOpen || r.breaker.Failures > 0 && h.SuccessiveFailures >= r.breaker.Failures {
+		h.State = Open
+		h.OpenUntil = now.Add(r.breaker.Cooldown)
+	}
+}
+
+// abandon returns the roots that were HalfOpen but weren't tried to the Open
+// state, so that the next operation tries them instead.  Their cooldown has
+// already elapsed.
+func (r *Resolver) abandon(hs ...*Health) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, h := range hs {
+		if h.State == HalfOpen {
+			h.State = Open
+		}
+	}
+}
+
+type result struct {
+	h   *Health
+	err error
+}
+
+// Do runs op on the roots, according to the Policy and the HedgeDelay, until
+// a root answers, i.e. until op returns nil or an error that isn't a root
+// failure.  It returns the root that answered and the error of op, or the
+// last root failure if no root answered.  The options override those of the
+// Resolver.
+func (r *Resolver) Do(ctx *context.T, op Op, opts ...naming.NamespaceOpt) (string, error) {
+	policy, hedge, _ := r.options(opts)
+	roots := r.order()
+	if len(roots) == 0 {
+		return "", verror.New(errNoRoots, ctx)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// The channel is large enough for the attempts that are abandoned.
+	results := make(chan result, len(roots))
+	next, inflight := 0, 0
+	start := func() {
+		h := roots[next]
+		next++
+		inflight++
+		go func() {
+			t := r.clock()
+			err := op(ctx, h.Root)
+			if ctx.Err() != nil {
+				// The outcome of an attempt that was canceled, by
+				// the caller or because another root answered, says
+				// nothing about the root.
+				r.abandon(h)
+			} else {
+				r.record(h, t, err)
+			}
+			results <- result{h, err}
+		}()
+	}
+	// The roots that aren't tried at all are abandoned too.
+	defer func() { r.abandon(roots[next:]...) }()
+	var lastErr error
+	for {
+		if inflight == 0 {
+			if next == len(roots) {
+				return "", lastErr
+			}
+			start()
+		}
+		if policy == Race && next < len(roots) {
+			start()
+			continue
+		}
+		var timer *time.Timer
+		var hedged <-chan time.Time
+		if policy == Failover && hedge > 0 && next < len(roots) {
+			timer = time.NewTimer(hedge)
+			hedged = timer.C
+		}
+		select {
+		case res := <-results:
+			inflight--
+			if !IsRootFailure(res.err) {
+				stopTimer(timer)
+				return res.h.Root, res.err
+			}
+			lastErr = res.err
+		case <-hedged:
+			start()
+		case <-ctx.Done():
+			stopTimer(timer)
+			return "", ctx.Err()
+		}
+		stopTimer(timer)
+	}
+}
+
+func stopTimer(t *time.Timer) {
+	if t != nil {
+		t.Stop()
+	}
+}
+
+func (r *Resolver) clock() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.now()
+}
+
+// DoAll runs op on all the roots at once, including the roots that Do would
+// skip, and returns when they have all returned.  It succeeds if enough roots
+// succeed for the MountConsistency.  The options override those of the
+// Resolver.
+func (r *Resolver) DoAll(ctx *context.T, op Op, opts ...naming.NamespaceOpt) error {
+	_, _, mount := r.options(opts)
+	roots := r.order()
+	if len(roots) == 0 {
+		return verror.New(errNoRoots, ctx)
+	}
+	results := make(chan result, len(roots))
+	for _, h := range roots {
+		go func(h *Health) {
+			t := r.clock()
+			err := op(ctx, h.Root)
+			if ctx.Err() != nil {
+				r.abandon(h)
+			} else {
+				r.record(h, t, err)
+			}
+			results <- result{h, err}
+		}(h)
+	}
+	succeeded := 0
+	var firstErr error
+	for range roots {
+		res := <-results
+		if res.err == nil {
+			succeeded++
+		} else if firstErr == nil {
+			firstErr = res.err
+		}
+	}
+	if required := mount.required(len(roots)); succeeded < required {
+		return verror.New(errConsistency, ctx, succeeded, len(roots), required, firstErr)
+	}
+	return nil
+}
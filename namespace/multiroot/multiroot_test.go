@@ -0,0 +1,379 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package multiroot_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/namespace/multiroot"
+	"v.io/v23/naming"
+	"v.io/v23/security"
+	"v.io/v23/security/access"
+	"v.io/v23/services/mounttable"
+	"v.io/v23/services/mounttable/memtable"
+	"v.io/v23/services/mounttable/mounttabletest"
+	"v.io/v23/verror"
+)
+
+// root is an in-memory mount table that can be made unreachable or slow.
+type root struct {
+	caller *mounttabletest.Caller
+
+	mu    sync.Mutex
+	down  bool // GUARDED_BY(mu)
+	slow  bool // GUARDED_BY(mu)
+	calls int  // GUARDED_BY(mu)
+}
+
+// env is a set of roots, named by their index.
+type env struct {
+	t     *testing.T
+	ctx   *context.T
+	roots map[string]*root
+	names []string
+	// canceled receives the roots whose slow calls were canceled.
+	canceled chan string
+}
+
+func newEnv(t *testing.T, names ...string) (*env, func()) {
+	ctx, cancel := context.RootContext()
+	e := &env{t: t, ctx: ctx, roots: make(map[string]*root), names: names, canceled: make(chan string, len(names))}
+	perms := access.Permissions{}.
+		Add(security.AllPrincipals, string(mounttable.Admin), string(mounttable.Resolve), string(mounttable.Read), string(mounttable.Create), string(mounttable.Mount))
+	for _, name := range names {
+		c, err := mounttabletest.NewCaller(ctx, memtable.New(perms), "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.roots[name] = &root{caller: c}
+	}
+	return e, cancel
+}
+
+func (e *env) set(name string, down, slow bool) {
+	r := e.roots[name]
+	r.mu.Lock()
+	r.down, r.slow = down, slow
+	r.mu.Unlock()
+}
+
+func (e *env) calls(name string) int {
+	r := e.roots[name]
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+// call calls f on the named root, failing if the root is down, and blocking
+// until ctx is canceled if it is slow.
+func (e *env) call(ctx *context.T, name string, f func(c *mounttabletest.Caller) error) error {
+	r := e.roots[name]
+	r.mu.Lock()
+	down, slow := r.down, r.slow
+	r.calls++
+	r.mu.Unlock()
+	if down {
+		return verror.New(verror.ErrNoServers, ctx, name)
+	}
+	if slow {
+		<-ctx.Done()
+		e.canceled <- name
+		return verror.New(verror.ErrCanceled, ctx, name)
+	}
+	return f(r.caller)
+}
+
+func (e *env) resolveStep(name string) multiroot.Op {
+	return func(ctx *context.T, root string) error {
+		return e.call(ctx, root, func(c *mounttabletest.Caller) error {
+			_, err := c.ResolveStep(name)
+			return err
+		})
+	}
+}
+
+func (e *env) mount(name, server string) multiroot.Op {
+	return func(ctx *context.T, root string) error {
+		return e.call(ctx, root, func(c *mounttabletest.Caller) error {
+			return c.Mount(name, server, 0, 0)
+		})
+	}
+}
+
+func (e *env) mountAll(name, server string) {
+	for _, r := range e.roots {
+		if err := r.caller.Mount(name, server, 0, 0); err != nil {
+			e.t.Fatal(err)
+		}
+	}
+}
+
+// mounted returns the roots on which server is mounted at name.
+func (e *env) mounted(name, server string) []string {
+	var roots []string
+	for _, root := range e.names {
+		entry, err := e.roots[root].caller.ResolveStep(name)
+		if err == nil && len(entry.Servers) == 1 && entry.Servers[0].Server == server {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+func TestIsRootFailure(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("oops"), true},
+		{verror.New(verror.ErrNoServers, nil), true},
+		{verror.New(verror.ErrTimeout, nil), true},
+		{verror.New(verror.ErrNoAccess, nil), false},
+		{verror.New(naming.ErrNoSuchName, nil, "a"), false},
+		{verror.New(verror.ErrBadState, nil), false},
+	} {
+		if got := multiroot.IsRootFailure(test.err); got != test.want {
+			t.Errorf("IsRootFailure(%v): got %v, want %v", test.err, got, test.want)
+		}
+	}
+}
+
+func TestFailover(t *testing.T) {
+	e, cancel := newEnv(t, "r0", "r1", "r2")
+	defer cancel()
+	e.mountAll("a", "/s:1")
+	r := multiroot.New(e.names)
+
+	e.set("r0", true, false)
+	if root, err := r.Do(e.ctx, e.resolveStep("a")); err != nil || root != "r1" {
+		t.Fatalf("got (%q, %v), want r1", root, err)
+	}
+	if e.calls("r2") != 0 {
+		t.Errorf("r2 was called")
+	}
+
+	// An answer, even an error, doesn't fail over.
+	if root, err := r.Do(e.ctx, e.resolveStep("b")); verror.ErrorID(err) != naming.ErrNoSuchName.ID || root != "r1" {
+		t.Fatalf("got (%q, %v), want (r1, %v)", root, err, naming.ErrNoSuchName.ID)
+	}
+	if e.calls("r2") != 0 {
+		t.Errorf("r2 was called")
+	}
+
+	e.set("r1", true, false)
+	e.set("r2", true, false)
+	if _, err := r.Do(e.ctx, e.resolveStep("a")); verror.ErrorID(err) != verror.ErrNoServers.ID {
+		t.Fatalf("got %v, want %v", err, verror.ErrNoServers.ID)
+	}
+
+	h := r.Health()
+	if h[0].Failures != 3 || h[1].Successes != 2 || h[1].SuccessiveFailures != 1 || h[2].Failures != 1 {
+		t.Errorf("unexpected health %+v", h)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	e, cancel := newEnv(t, "r0", "r1")
+	defer cancel()
+	e.mountAll("a", "/s:1")
+	now := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := multiroot.New(e.names, multiroot.CircuitBreaker{Failures: 2, Cooldown: time.Minute})
+	r.SetClock(func() time.Time { return now })
+
+	e.set("r0", true, false)
+	for i := 0; i < 2; i++ {
+		if root, err := r.Do(e.ctx, e.resolveStep("a")); err != nil || root != "r1" {
+			t.Fatalf("got (%q, %v), want r1", root, err)
+		}
+	}
+	if h := r.Health()[0]; h.State != multiroot.Open || !h.OpenUntil.Equal(now.Add(time.Minute)) {
+		t.Fatalf("unexpected health %+v", h)
+	}
+	// The open root is skipped.
+	if root, err := r.Do(e.ctx, e.resolveStep("a")); err != nil || root != "r1" || e.calls("r0") != 2 {
+		t.Fatalf("got (%q, %v) after %d calls to r0", root, err, e.calls("r0"))
+	}
+	// Unless all the roots fail.
+	e.set("r1", true, false)
+	if _, err := r.Do(e.ctx, e.resolveStep("a")); err == nil || e.calls("r0") != 3 {
+		t.Fatalf("got %v after %d calls to r0", err, e.calls("r0"))
+	}
+	e.set("r1", false, false)
+
+	// After the cooldown, the root is tried once, and opens again if it
+	// fails.
+	now = now.Add(time.Minute)
+	if root, err := r.Do(e.ctx, e.resolveStep("a")); err != nil || root != "r1" || e.calls("r0") != 4 {
+		t.Fatalf("got (%q, %v) after %d calls to r0", root, err, e.calls("r0"))
+	}
+	if h := r.Health()[0]; h.State != multiroot.Open {
+		t.Fatalf("unexpected health %+v", h)
+	}
+
+	// A half-open root that answers is used again.
+	now = now.Add(time.Minute)
+	e.set("r0", false, false)
+	if root, err := r.Do(e.ctx, e.resolveStep("a")); err != nil || root != "r0" {
+		t.Fatalf("got (%q, %v), want r0", root, err)
+	}
+	if h := r.Health()[0]; h.State != multiroot.Closed || h.SuccessiveFailures != 0 {
+		t.Fatalf("unexpected health %+v", h)
+	}
+
+	// Setting the roots keeps their health.
+	r.SetRoots("r1", "r0", "r2")
+	if h := r.Health(); h[0].Successes != 4 || h[1].Successes != 1 || h[2].Root != "r2" {
+		t.Errorf("unexpected health %+v", h)
+	}
+}
+
+func TestRace(t *testing.T) {
+	e, cancel := newEnv(t, "r0", "r1")
+	defer cancel()
+	e.mountAll("a", "/s:1")
+	r := multiroot.New(e.names, multiroot.Race)
+
+	e.set("r0", false, true)
+	if root, err := r.Do(e.ctx, e.resolveStep("a")); err != nil || root != "r1" {
+		t.Fatalf("got (%q, %v), want r1", root, err)
+	}
+	if got := <-e.canceled; got != "r0" {
+		t.Errorf("got %v canceled, want r0", got)
+	}
+	// The cancellation isn't a failure, or a success, of r0.
+	if h := r.Health()[0]; h.Failures != 0 || h.Successes != 0 {
+		t.Errorf("unexpected health %+v", h)
+	}
+}
+
+// waitOpen waits for the i'th root of r to be Open, since canceled
+// attempts may return after the operation.
+func waitOpen(t *testing.T, r *multiroot.Resolver, i int) {
+	deadline := time.Now().Add(10 * time.Second)
+	for h := r.Health()[i]; h.State != multiroot.Open; h = r.Health()[i] {
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected health %+v", h)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAbandonedHalfOpen(t *testing.T) {
+	e, cancel := newEnv(t, "r0", "r1")
+	defer cancel()
+	e.mountAll("a", "/s:1")
+	now := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := multiroot.New(e.names, multiroot.CircuitBreaker{Failures: 1, Cooldown: time.Minute})
+	r.SetClock(func() time.Time { return now })
+
+	e.set("r0", true, false)
+	if root, err := r.Do(e.ctx, e.resolveStep("a")); err != nil || root != "r1" {
+		t.Fatalf("got (%q, %v), want r1", root, err)
+	}
+	now = now.Add(time.Minute)
+
+	// The half-open root is canceled when the other root answers first, and
+	// is tried again by the next operation.
+	e.set("r0", false, true)
+	if root, err := r.Do(e.ctx, e.resolveStep("a"), multiroot.Race); err != nil || root != "r1" {
+		t.Fatalf("got (%q, %v), want r1", root, err)
+	}
+	<-e.canceled
+	waitOpen(t, r, 0)
+	if h := r.Health()[0]; h.Failures != 1 || h.Successes != 0 {
+		t.Fatalf("unexpected health %+v", h)
+	}
+	e.set("r0", false, false)
+	if root, err := r.Do(e.ctx, e.resolveStep("a")); err != nil || root != "r0" {
+		t.Fatalf("got (%q, %v), want r0", root, err)
+	}
+
+	// A half-open root that isn't reached because the operation is canceled
+	// is tried again too.
+	e.set("r0", true, false)
+	if root, err := r.Do(e.ctx, e.resolveStep("a")); err != nil || root != "r1" {
+		t.Fatalf("got (%q, %v), want r1", root, err)
+	}
+	r.SetRoots("r1", "r0")
+	now = now.Add(time.Minute)
+	e.set("r0", false, false)
+	e.set("r1", false, true)
+	ctx, cancelCtx := context.WithTimeout(e.ctx, 10*time.Millisecond)
+	defer cancelCtx()
+	if _, err := r.Do(ctx, e.resolveStep("a")); err == nil {
+		t.Fatalf("expected an error")
+	}
+	<-e.canceled
+	if h := r.Health()[1]; h.State != multiroot.Open || h.Failures != 2 {
+		t.Fatalf("unexpected health %+v", h)
+	}
+	e.set("r1", true, false)
+	if root, err := r.Do(e.ctx, e.resolveStep("a")); err != nil || root != "r0" || e.calls("r0") != 5 {
+		t.Fatalf("got (%q, %v) after %d calls to r0", root, err, e.calls("r0"))
+	}
+}
+
+func TestHedging(t *testing.T) {
+	e, cancel := newEnv(t, "r0", "r1")
+	defer cancel()
+	e.mountAll("a", "/s:1")
+	r := multiroot.New(e.names)
+
+	e.set("r0", false, true)
+	if root, err := r.Do(e.ctx, e.resolveStep("a"), multiroot.HedgeDelay(time.Millisecond)); err != nil || root != "r1" {
+		t.Fatalf("got (%q, %v), want r1", root, err)
+	}
+	if got := <-e.canceled; got != "r0" {
+		t.Errorf("got %v canceled, want r0", got)
+	}
+
+	// Without hedging, a slow root stalls until the deadline.
+	ctx, cancelCtx := context.WithTimeout(e.ctx, 10*time.Millisecond)
+	defer cancelCtx()
+	if _, err := r.Do(ctx, e.resolveStep("a")); err == nil {
+		t.Fatalf("expected an error")
+	}
+	<-e.canceled
+	if e.calls("r1") != 1 {
+		t.Errorf("got %d calls to r1, want 1", e.calls("r1"))
+	}
+}
+
+func TestDoAll(t *testing.T) {
+	e, cancel := newEnv(t, "r0", "r1", "r2")
+	defer cancel()
+	r := multiroot.New(e.names)
+
+	e.set("r2", true, false)
+	if err := r.DoAll(e.ctx, e.mount("a", "/s:1")); err == nil {
+		t.Errorf("expected an error")
+	}
+	if got := e.mounted("a", "/s:1"); len(got) != 2 {
+		t.Errorf("got mounts on %v, want r0 and r1", got)
+	}
+	if err := r.DoAll(e.ctx, e.mount("b", "/s:1"), multiroot.MountQuorum); err != nil {
+		t.Error(err)
+	}
+
+	e.set("r1", true, false)
+	if err := r.DoAll(e.ctx, e.mount("c", "/s:1"), multiroot.MountQuorum); err == nil {
+		t.Errorf("expected an error")
+	}
+	if err := r.DoAll(e.ctx, e.mount("d", "/s:1"), multiroot.MountAny); err != nil {
+		t.Error(err)
+	}
+	if got := e.mounted("d", "/s:1"); len(got) != 1 || got[0] != "r0" {
+		t.Errorf("got mounts on %v, want r0", got)
+	}
+
+	if err := multiroot.New(nil).DoAll(e.ctx, e.mount("e", "/s:1")); err == nil {
+		t.Errorf("expected an error")
+	}
+}
@@ -0,0 +1,372 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package nscache implements a resolution cache for namespace.T
+// implementations, controlled by the naming.CacheCtl values.
+//
+// The cache maps names to the mount entries that they resolve to.  An entry
+// remembered for a prefix, e.g. /mt:8101/a, also resolves the names below it,
+// e.g. /mt:8101/a/b/c, with the rest of the name appended to the Name of the
+// entry.  An entry expires at the earliest Deadline of its servers.
+//
+// A namespace uses the cache either with Lookup and Remember, around its own
+// resolution, or with Resolve, which also serves expired entries while they
+// are resolved again, as per naming.StaleWhileRevalidate:
+//
+//   cache := nscache.New(naming.MaxCacheEntries(1000), naming.NegativeCacheTTL(5*time.Second))
+//   entry, err := cache.Resolve(ctx, name, ns.resolveStep)
+package nscache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/naming"
+	"v.io/v23/verror"
+)
+
+const pkgPath = "v.io/v23/namespace/nscache"
+
+var errNotCached = verror.Register(pkgPath+".errNotCached", verror.NoRetry, "{1:}{2:} {3} is not in the cache{:_}")
+
+// ErrNotCached is the ID of the error returned by Lookup for names that
+// aren't in the cache.
+var ErrNotCached = errNotCached.ID
+
+// Resolver resolves a name that isn't in the cache.  It returns the entry of
+// name, and the prefix of name for which the entry is remembered: names
+// below prefix resolve to the same servers, with the rest of the name
+// appended to entry.Name.
+type Resolver func(ctx *context.T, name string) (prefix string, entry *naming.MountEntry, err error)
+
+// Stats are the counters of a Cache.
+type Stats struct {
+	// Entries is the number of entries in the cache, including the
+	// negative ones.
+	Entries int
+	// Hits counts the lookups that found an unexpired entry.
+	Hits uint64
+	// StaleHits counts the lookups by Resolve that found an expired entry
+	// within its StaleWhileRevalidate period.
+	StaleHits uint64
+	// NegativeHits counts the lookups that found that a name doesn't exist.
+	NegativeHits uint64
+	// Misses counts the lookups that found nothing.
+	Misses uint64
+	// Evictions counts the entries evicted to honor MaxCacheEntries.
+	Evictions uint64
+	// Expirations counts the entries removed because they had expired.
+	Expirations uint64
+	// Refreshes counts the background resolutions started by Resolve.
+	Refreshes uint64
+}
+
+type key struct {
+	name     string
+	negative bool
+}
+
+type entry struct {
+	key     key
+	entry   naming.MountEntry // For positive entries.
+	err     error             // For negative entries.
+	expires time.Time         // The zero time for entries that don't expire.
+	// refreshing is true while Resolve resolves the entry again.
+	refreshing bool
+}
+
+// Cache is a resolution cache.  It is safe for concurrent use.
+type Cache struct {
+	mu          sync.Mutex
+	disabled    bool                  // GUARDED_BY(mu)
+	maxEntries  int                   // GUARDED_BY(mu)
+	negativeTTL time.Duration         // GUARDED_BY(mu)
+	stale       time.Duration         // GUARDED_BY(mu)
+	now         func() time.Time      // GUARDED_BY(mu)
+	entries     map[key]*list.Element // GUARDED_BY(mu)
+	lru         *list.List            // GUARDED_BY(mu), most recently used first.
+	stats       Stats                 // GUARDED_BY(mu)
+}
+
+// New returns a cache with the given controls.
+func New(ctls ...naming.CacheCtl) *Cache {
+	c := &Cache{
+		now:     time.Now,
+		entries: make(map[key]*list.Element),
+		lru:     list.New(),
+	}
+	c.CacheCtl(ctls...)
+	return c
+}
+
+// SetClock sets the function used by the cache to tell the time, for tests.
+func (c *Cache) SetClock(now func() time.Time) {
+	c.mu.Lock()
+	c.now = now
+	c.mu.Unlock()
+}
+
+// CacheCtl implements namespace.T.CacheCtl: it applies the controls and
+// returns the current values of all of them.
+func (c *Cache) CacheCtl(ctls ...naming.CacheCtl) []naming.CacheCtl {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ctl := range ctls {
+		switch v := ctl.(type) {
+		case naming.DisableCache:
+			c.disabled = bool(v)
+			if c.disabled {
+				c.flushLocked(func(*entry) bool { return true })
+			}
+		case naming.MaxCacheEntries:
+			c.maxEntries = int(v)
+			c.evictLocked()
+		case naming.NegativeCacheTTL:
+			c.negativeTTL = time.Duration(v)
+			if c.negativeTTL <= 0 {
+				c.flushLocked(func(e *entry) bool { return e.key.negative })
+			}
+		case naming.StaleWhileRevalidate:
+			c.stale = time.Duration(v)
+		}
+	}
+	return []naming.CacheCtl{
+		naming.DisableCache(c.disabled),
+		naming.MaxCacheEntries(c.maxEntries),
+		naming.NegativeCacheTTL(c.negativeTTL),
+		naming.StaleWhileRevalidate(c.stale),
+	}
+}
+
+// Stats returns the current counters of the cache.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Entries = c.lru.Len()
+	return s
+}
+
+// isNotFound returns true for the errors that are cached negatively.
+func isNotFound(err error) bool {
+	switch verror.ErrorID(err) {
+	case naming.ErrNoSuchName.ID, naming.ErrNoSuchNameRoot.ID, verror.ErrNoExist.ID:
+		return true
+	}
+	return false
+}
+
+// Remember caches entry for prefix, until the earliest deadline of its
+// servers.  Entries without servers aren't cached.
+func (c *Cache) Remember(prefix string, e *naming.MountEntry) {
+	if e == nil || len(e.Servers) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disabled {
+		return
+	}
+	ce := &entry{key: key{name: naming.Clean(prefix)}, entry: copyEntry(e)}
+	for _, s := range e.Servers {
+		if d := s.Deadline.Time; !d.IsZero() && (ce.expires.IsZero() || d.Before(ce.expires)) {
+			ce.expires = d
+		}
+	}
+	c.removeLocked(key{name: ce.key.name, negative: true})
+	c.addLocked(ce)
+}
+
+// RememberError caches that name doesn't exist for NegativeCacheTTL, if err
+// is ErrNoSuchName, ErrNoSuchNameRoot or verror.ErrNoExist.  Other errors
+// aren't cached.
+func (c *Cache) RememberError(name string, err error) {
+	if !isNotFound(err) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disabled || c.negativeTTL <= 0 {
+		return
+	}
+	c.addLocked(&entry{key: key{naming.Clean(name), true}, err: err, expires: c.now().Add(c.negativeTTL)})
+}
+
+// Lookup returns the unexpired entry of the longest cached prefix of name,
+// with the rest of name appended to its Name, or the cached error if name
+// is known not to exist.  It returns an error with ID ErrNotCached
+// otherwise.
+func (c *Cache) Lookup(ctx *context.T, name string) (naming.MountEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ce, err := c.lookupLocked(ctx, name, false)
+	if ce != nil {
+		c.stats.Hits++
+	}
+	return e, err
+}
+
+// lookupLocked looks up name.  It returns the entry of name, and the entry
+// of the cache that it comes from, or an error.  Expired entries are
+// returned if stale is true, and if they expired less than the
+// StaleWhileRevalidate period ago.
+// REQUIRES: c.mu is held.
+func (c *Cache) lookupLocked(ctx *context.T, name string, stale bool) (naming.MountEntry, *entry, error) {
+	name = naming.Clean(name)
+	if c.disabled {
+		c.stats.Misses++
+		return naming.MountEntry{}, nil, verror.New(errNotCached, ctx, name)
+	}
+	now := c.now()
+	if elem, ok := c.entries[key{name, true}]; ok {
+		ce := elem.Value.(*entry)
+		if now.Before(ce.expires) {
+			c.lru.MoveToFront(elem)
+			c.stats.NegativeHits++
+			return naming.MountEntry{}, nil, ce.err
+		}
+		c.stats.Expirations++
+		c.removeLocked(ce.key)
+	}
+	for prefix := name; ; {
+		if elem, ok := c.entries[key{name: prefix}]; ok {
+			// The entries of shorter prefixes are not used if this one has
+			// expired: they resolve the name less far.
+			ce := elem.Value.(*entry)
+			fresh := ce.expires.IsZero() || now.Before(ce.expires)
+			inStalePeriod := !fresh && now.Before(ce.expires.Add(c.stale))
+			if fresh || stale && inStalePeriod {
+				c.lru.MoveToFront(elem)
+				e := copyEntry(&ce.entry)
+				if name != prefix {
+					e.Name = naming.Join(e.Name, name[len(prefix)+1:])
+				}
+				return e, ce, nil
+			}
+			if !inStalePeriod {
+				c.stats.Expirations++
+				c.removeLocked(ce.key)
+			}
+			break
+		}
+		i := strings.LastIndex(prefix, "/")
+		if i <= 0 {
+			break
+		}
+		prefix = prefix[:i]
+	}
+	c.stats.Misses++
+	return naming.MountEntry{}, nil, verror.New(errNotCached, ctx, name)
+}
+
+// Resolve returns the entry of name from the cache, or from r if it isn't
+// cached, in which case the result of r is remembered.  Entries that have
+// expired less than the StaleWhileRevalidate period ago are returned, and
+// resolved again with r in the background, once at a time.
+func (c *Cache) Resolve(ctx *context.T, name string, r Resolver) (*naming.MountEntry, error) {
+	c.mu.Lock()
+	e, ce, err := c.lookupLocked(ctx, name, true)
+	switch {
+	case ce != nil && (ce.expires.IsZero() || c.now().Before(ce.expires)):
+		c.stats.Hits++
+		c.mu.Unlock()
+		return &e, nil
+	case ce != nil:
+		c.stats.StaleHits++
+		if !ce.refreshing {
+			ce.refreshing = true
+			c.stats.Refreshes++
+			go c.refresh(ctx, ce, r)
+		}
+		c.mu.Unlock()
+		return &e, nil
+	case verror.ErrorID(err) != ErrNotCached:
+		c.mu.Unlock()
+		return nil, err
+	}
+	c.mu.Unlock()
+	return c.resolve(ctx, name, r)
+}
+
+func (c *Cache) resolve(ctx *context.T, name string, r Resolver) (*naming.MountEntry, error) {
+	prefix, e, err := r(ctx, name)
+	if err != nil {
+		c.RememberError(name, err)
+		return nil, err
+	}
+	c.Remember(prefix, e)
+	return e, nil
+}
+
+// refresh resolves the name of a stale entry again, independently of the
+// cancellation of ctx.
+func (c *Cache) refresh(ctx *context.T, ce *entry, r Resolver) {
+	ctx, cancel := context.WithRootCancel(ctx)
+	defer cancel()
+	_, err := c.resolve(ctx, ce.key.name, r)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ce.refreshing = false
+	if isNotFound(err) {
+		c.removeLocked(ce.key)
+	}
+}
+
+// Flush removes the entries of name and of the names below it.  It returns
+// true if anything was removed.
+func (c *Cache) Flush(name string) bool {
+	name = naming.Clean(name)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.flushLocked(func(e *entry) bool {
+		return e.key.name == name || strings.HasPrefix(e.key.name, name+"/")
+	}) > 0
+}
+
+// flushLocked removes the entries for which remove returns true, and returns
+// how many it removed.
+// REQUIRES: c.mu is held.
+func (c *Cache) flushLocked(remove func(*entry) bool) int {
+	n := 0
+	for k, elem := range c.entries {
+		if remove(elem.Value.(*entry)) {
+			c.removeLocked(k)
+			n++
+		}
+	}
+	return n
+}
+
+// REQUIRES: c.mu is held.
+func (c *Cache) addLocked(ce *entry) {
+	c.removeLocked(ce.key)
+	c.entries[ce.key] = c.lru.PushFront(ce)
+	c.evictLocked()
+}
+
+// REQUIRES: c.mu is held.
+func (c *Cache) removeLocked(k key) {
+	if elem, ok := c.entries[k]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, k)
+	}
+}
+
+// evictLocked evicts the least recently used entries beyond maxEntries.
+// REQUIRES: c.mu is held.
+func (c *Cache) evictLocked() {
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.removeLocked(c.lru.Back().Value.(*entry).key)
+		c.stats.Evictions++
+	}
+}
+
+func copyEntry(e *naming.MountEntry) naming.MountEntry {
+	c := *e
+	c.Servers = append([]naming.MountedServer(nil), e.Servers...)
+	return c
+}
@@ -0,0 +1,255 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nscache_test
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/namespace/nscache"
+	"v.io/v23/naming"
+	vdltime "v.io/v23/vdlroot/time"
+	"v.io/v23/verror"
+)
+
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func newCache(ctls ...naming.CacheCtl) (*nscache.Cache, *clock) {
+	clk := &clock{now: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := nscache.New(ctls...)
+	c.SetClock(clk.Now)
+	return c, clk
+}
+
+func mountEntry(name string, deadline time.Time, servers ...string) *naming.MountEntry {
+	e := &naming.MountEntry{Name: name}
+	for _, s := range servers {
+		e.Servers = append(e.Servers, naming.MountedServer{Server: s, Deadline: vdltime.Deadline{Time: deadline}})
+	}
+	return e
+}
+
+func expectEntry(t *testing.T, c *nscache.Cache, name, want string) {
+	t.Helper()
+	e, err := c.Lookup(nil, name)
+	if err != nil {
+		t.Fatalf("Lookup(%q): %v", name, err)
+	}
+	if e.Name != want {
+		t.Fatalf("Lookup(%q): got name %q, want %q", name, e.Name, want)
+	}
+}
+
+func expectMiss(t *testing.T, c *nscache.Cache, name string) {
+	t.Helper()
+	if _, err := c.Lookup(nil, name); verror.ErrorID(err) != nscache.ErrNotCached {
+		t.Fatalf("Lookup(%q): got %v, want a miss", name, err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	c, clk := newCache()
+	c.Remember("/mt:1/a", mountEntry("x", clk.Now().Add(time.Minute), "/s1:1"))
+	c.Remember("/mt:1/a/b", mountEntry("", clk.Now().Add(2*time.Minute), "/s2:1"))
+	expectEntry(t, c, "/mt:1/a", "x")
+	expectEntry(t, c, "/mt:1/a/c/d", "x/c/d")
+	expectEntry(t, c, "/mt:1/a/b/e", "e")
+	expectMiss(t, c, "/mt:1/ab")
+	expectMiss(t, c, "/mt:1")
+
+	// Entries expire at the deadline of their servers.  An expired entry
+	// doesn't fall back to shorter prefixes.
+	clk.advance(90 * time.Second)
+	expectMiss(t, c, "/mt:1/a")
+	expectEntry(t, c, "/mt:1/a/b", "")
+	clk.advance(time.Minute)
+	expectMiss(t, c, "/mt:1/a/b")
+
+	// Entries without deadlines don't expire, and entries without servers
+	// aren't cached.
+	c.Remember("/mt:1/z", mountEntry("", time.Time{}, "/s3:1"))
+	c.Remember("/mt:1/y", mountEntry("", time.Time{}))
+	clk.advance(1000 * time.Hour)
+	expectEntry(t, c, "/mt:1/z", "")
+	expectMiss(t, c, "/mt:1/y")
+
+	if !c.Flush("/mt:1") || c.Flush("/mt:1") {
+		t.Errorf("unexpected result of Flush")
+	}
+	expectMiss(t, c, "/mt:1/z")
+
+	want := nscache.Stats{Hits: 5, Misses: 6, Expirations: 2}
+	if got := c.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestNegative(t *testing.T) {
+	c, clk := newCache(naming.NegativeCacheTTL(time.Second))
+	notFound := verror.New(naming.ErrNoSuchName, nil, "a")
+	c.RememberError("/mt:1/a", notFound)
+	c.RememberError("/mt:1/b", errors.New("other"))
+	if _, err := c.Lookup(nil, "/mt:1/a"); verror.ErrorID(err) != naming.ErrNoSuchName.ID {
+		t.Errorf("got %v, want %v", err, notFound)
+	}
+	expectMiss(t, c, "/mt:1/b")
+	clk.advance(time.Second)
+	expectMiss(t, c, "/mt:1/a")
+
+	// Remembering an entry replaces the negative one.
+	c.RememberError("/mt:1/a", notFound)
+	c.Remember("/mt:1/a", mountEntry("", time.Time{}, "/s:1"))
+	expectEntry(t, c, "/mt:1/a", "")
+
+	c.CacheCtl(naming.NegativeCacheTTL(0))
+	c.RememberError("/mt:1/c", notFound)
+	expectMiss(t, c, "/mt:1/c")
+	if got := c.Stats(); got.NegativeHits != 1 || got.Expirations != 1 {
+		t.Errorf("unexpected stats %+v", got)
+	}
+}
+
+func TestCacheCtl(t *testing.T) {
+	c, _ := newCache(naming.MaxCacheEntries(2))
+	want := []naming.CacheCtl{naming.DisableCache(false), naming.MaxCacheEntries(2), naming.NegativeCacheTTL(0), naming.StaleWhileRevalidate(0)}
+	if got := c.CacheCtl(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for _, name := range []string{"/a", "/b", "/c"} {
+		c.Remember(name, mountEntry("", time.Time{}, "/s:1"))
+		if name == "/b" {
+			// Make /a the most recently used entry.
+			expectEntry(t, c, "/a", "")
+		}
+	}
+	expectEntry(t, c, "/a", "")
+	expectMiss(t, c, "/b")
+	expectEntry(t, c, "/c", "")
+	if got := c.Stats(); got.Evictions != 1 || got.Entries != 2 {
+		t.Errorf("unexpected stats %+v", got)
+	}
+
+	c.CacheCtl(naming.DisableCache(true))
+	expectMiss(t, c, "/a")
+	c.Remember("/d", mountEntry("", time.Time{}, "/s:1"))
+	expectMiss(t, c, "/d")
+	c.CacheCtl(naming.DisableCache(false))
+	expectMiss(t, c, "/a")
+}
+
+func TestResolve(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	c, clk := newCache(naming.StaleWhileRevalidate(time.Minute), naming.NegativeCacheTTL(time.Minute))
+
+	var mu sync.Mutex
+	calls := 0
+	refreshed := make(chan struct{}, 10)
+	resolver := func(ctx *context.T, name string) (string, *naming.MountEntry, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		defer func() { refreshed <- struct{}{} }()
+		if name == "/mt:1/missing" {
+			return "", nil, verror.New(verror.ErrNoExist, ctx, name)
+		}
+		return "/mt:1/a", mountEntry("", clk.Now().Add(time.Minute), "/s1:1"), nil
+	}
+	resolve := func(name string) (*naming.MountEntry, error) {
+		return c.Resolve(ctx, name, resolver)
+	}
+	if e, err := resolve("/mt:1/a/b"); err != nil || e.Name != "" {
+		t.Fatalf("got (%v, %v)", e, err)
+	}
+	<-refreshed
+	if e, err := resolve("/mt:1/a/b"); err != nil || e.Name != "b" {
+		t.Fatalf("got (%v, %v)", e, err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := resolve("/mt:1/missing"); verror.ErrorID(err) != verror.ErrNoExist.ID {
+			t.Fatalf("got %v, want %v", err, verror.ErrNoExist.ID)
+		}
+	}
+	<-refreshed
+
+	// Stale entries are used while they are refreshed, once.
+	clk.advance(90 * time.Second)
+	for i := 0; i < 3; i++ {
+		if e, err := resolve("/mt:1/a"); err != nil || len(e.Servers) != 1 {
+			t.Fatalf("got (%v, %v)", e, err)
+		}
+	}
+	<-refreshed
+	// The refreshed entry is remembered after the resolver returns.
+	for start := time.Now(); ; time.Sleep(time.Millisecond) {
+		e, err := c.Lookup(ctx, "/mt:1/a")
+		if err == nil && e.Servers[0].Deadline.After(clk.Now()) {
+			break
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("got (%v, %v), want a refreshed entry", e, err)
+		}
+	}
+
+	// Beyond the stale period, the name is resolved again before it is used.
+	clk.advance(3 * time.Minute)
+	if _, err := resolve("/mt:1/a"); err != nil {
+		t.Fatal(err)
+	}
+	<-refreshed
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 4 {
+		t.Errorf("got %d calls to the resolver, want 4", calls)
+	}
+	got := c.Stats()
+	if got.Hits < 2 || got.StaleHits != 3 || got.NegativeHits != 1 || got.Refreshes != 1 {
+		t.Errorf("unexpected stats %+v", got)
+	}
+}
+
+func TestSource(t *testing.T) {
+	c, _ := newCache()
+	c.Remember("/a", mountEntry("", time.Time{}, "/s:1"))
+	expectEntry(t, c, "/a", "")
+	expectMiss(t, c, "/b")
+	src := nscache.NewSource(c)
+	names := src.Names()
+	if len(names) != 8 || names[0] != "namespace/cache/entries" {
+		t.Errorf("unexpected names %v", names)
+	}
+	for name, want := range map[string]int64{
+		"namespace/cache/entries": 1,
+		"namespace/cache/hits":    1,
+		"namespace/cache/misses":  1,
+	} {
+		if got, err := src.Value(name); err != nil || got != want {
+			t.Errorf("%s: got (%v, %v), want %v", name, got, err, want)
+		}
+	}
+	if _, err := src.Value("namespace/cache/nothing"); err == nil {
+		t.Errorf("expected an error")
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nscache
+
+import (
+	"sort"
+
+	"v.io/v23/naming"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	"v.io/v23/services/stats/statsserver"
+	"v.io/v23/verror"
+)
+
+var errNoObject = verror.Register(pkgPath+".errNoObject", verror.NoRetry, "{1:}{2:} no cache stats object named {3}{:_}")
+
+// Root is the name, relative to the root of the stats service, under which
+// the statistics of a cache are published by NewSource:
+//
+//   namespace/cache/entries       int64
+//   namespace/cache/hits          int64
+//   namespace/cache/staleHits     int64
+//   namespace/cache/negativeHits  int64
+//   namespace/cache/misses        int64
+//   namespace/cache/evictions     int64
+//   namespace/cache/expirations   int64
+//   namespace/cache/refreshes     int64
+//
+// See the fields of Stats for their meaning.
+const Root = "namespace/cache"
+
+var objects = []struct {
+	name  string
+	value func(s *Stats) int64
+}{
+	{"entries", func(s *Stats) int64 { return int64(s.Entries) }},
+	{"evictions", func(s *Stats) int64 { return int64(s.Evictions) }},
+	{"expirations", func(s *Stats) int64 { return int64(s.Expirations) }},
+	{"hits", func(s *Stats) int64 { return int64(s.Hits) }},
+	{"misses", func(s *Stats) int64 { return int64(s.Misses) }},
+	{"negativeHits", func(s *Stats) int64 { return int64(s.NegativeHits) }},
+	{"refreshes", func(s *Stats) int64 { return int64(s.Refreshes) }},
+	{"staleHits", func(s *Stats) int64 { return int64(s.StaleHits) }},
+}
+
+// Source is a statsserver.Source of the statistics of a Cache.
+type Source struct {
+	c *Cache
+}
+
+// NewSource returns a Source of the statistics of c.
+func NewSource(c *Cache) *Source {
+	return &Source{c}
+}
+
+// NewDispatcher returns a dispatcher that serves the statistics of c via the
+// stats.Stats interface.  Like statsserver.NewDispatcher, it is meant to be
+// mounted at the root of the stats service.
+func NewDispatcher(c *Cache, auth security.Authorizer) rpc.Dispatcher {
+	return statsserver.NewDispatcher(NewSource(c), auth)
+}
+
+// Names returns the names of all the objects, relative to the root of the
+// stats service, in sorted order.
+func (s *Source) Names() []string {
+	names := make([]string, len(objects))
+	for i, o := range objects {
+		names[i] = naming.Join(Root, o.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Value returns the current value of the named object, where name is
+// relative to the root of the stats service.
+func (s *Source) Value(name string) (interface{}, error) {
+	stats := s.c.Stats()
+	for _, o := range objects {
+		if naming.Clean(name) == naming.Join(Root, o.name) {
+			return o.value(&stats), nil
+		}
+	}
+	return nil, verror.New(errNoObject, nil, name)
+}
@@ -0,0 +1,324 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package nswatch implements namespace.T.WatchGlob on top of the other
+// methods of a namespace.T, for use by namespace implementations:
+//
+//   func (ns *namespace) WatchGlob(ctx *context.T, pattern string, opts ...naming.NamespaceOpt) (<-chan namespace.Change, error) {
+//     return nswatch.WatchGlob(ctx, ns, pattern, opts...)
+//   }
+//
+// The mount table responsible for the fixed prefix of the pattern, e.g. for
+// a/b in a/b/*/c, is first asked to push its changes with the
+// v.io/v23/services/watch.GlobWatcher interface.  Mount tables that
+// implement it send their mount entries, encoded as naming.MountEntry, in the
+// values of their watch.Change.  Such a watch doesn't extend into the mount
+// tables mounted below the prefix.
+//
+// If the mount table doesn't implement GlobWatcher, or if its watch fails,
+// the pattern is polled with Glob instead, and the results of consecutive
+// Globs are diffed to produce the changes.  The names below a part of the
+// namespace that Glob can't traverse are not reported as removed until it
+// can be traversed again.
+package nswatch
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/glob"
+	"v.io/v23/namespace"
+	"v.io/v23/naming"
+	"v.io/v23/options"
+	"v.io/v23/services/watch"
+	"v.io/v23/verror"
+)
+
+// DefaultPollInterval is the interval at which mount tables that don't
+// implement GlobWatcher are polled, unless an options.WatchPollInterval is
+// provided.
+const DefaultPollInterval = 10 * time.Second
+
+// Namespace is the subset of namespace.T used by WatchGlob.
+type Namespace interface {
+	ResolveToMountTable(ctx *context.T, name string, opts ...naming.NamespaceOpt) (*naming.MountEntry, error)
+	Glob(ctx *context.T, pattern string, opts ...naming.NamespaceOpt) (<-chan naming.GlobReply, error)
+}
+
+// WatchGlob implements namespace.T.WatchGlob with ns.  The options are
+// passed to ns, except for options.WatchPollInterval.
+func WatchGlob(ctx *context.T, ns Namespace, pattern string, opts ...naming.NamespaceOpt) (<-chan namespace.Change, error) {
+	address, suffix := naming.SplitAddressName(pattern)
+	g, err := glob.Parse(suffix)
+	if err != nil {
+		return nil, err
+	}
+	w := &watcher{
+		ns:       ns,
+		address:  address,
+		pattern:  pattern,
+		glob:     g,
+		interval: DefaultPollInterval,
+		out:      make(chan namespace.Change),
+		entries:  make(map[string]naming.MountEntry),
+		failures: make(map[string]string),
+	}
+	for _, o := range opts {
+		if i, ok := o.(options.WatchPollInterval); ok {
+			w.interval = time.Duration(i)
+		} else {
+			w.opts = append(w.opts, o)
+		}
+	}
+	go w.run(ctx)
+	return w.out, nil
+}
+
+// watcher sends the changes of a pattern on out.  Its fields are only used
+// by the goroutine that runs run.
+type watcher struct {
+	ns       Namespace
+	address  string
+	pattern  string
+	glob     *glob.Glob
+	opts     []naming.NamespaceOpt
+	interval time.Duration
+	out      chan namespace.Change
+
+	// entries are the names that have been sent as matching the pattern.
+	entries map[string]naming.MountEntry
+	// failures are the errors that have been sent, by name.
+	failures map[string]string
+	synced   bool
+}
+
+func (w *watcher) run(ctx *context.T) {
+	defer close(w.out)
+	w.watch(ctx)
+	if ctx.Err() == nil {
+		w.poll(ctx)
+	}
+}
+
+// recvStream is the receiving side of a GlobWatcher.WatchGlob call.
+type recvStream interface {
+	Advance() bool
+	Value() watch.Change
+	Err() error
+}
+
+// watch watches the pattern with the GlobWatcher interface of the mount table
+// responsible for its fixed prefix, until the watch fails or ctx is
+// canceled.
+func (w *watcher) watch(ctx *context.T) {
+	prefix, tail := w.glob.SplitFixedElements()
+	root := naming.JoinAddressName(w.address, naming.Join(prefix...))
+	entry, err := w.ns.ResolveToMountTable(ctx, root, w.opts...)
+	if err != nil {
+		return
+	}
+	call, err := watch.GlobWatcherClient(root).WatchGlob(ctx, watch.GlobRequest{Pattern: tail.String()}, options.Preresolved{Resolution: entry})
+	if err != nil {
+		return
+	}
+	w.consume(ctx, root, call.RecvStream())
+	call.Finish()
+}
+
+// consume sends the changes received on stream, whose names are relative to
+// root.
+func (w *watcher) consume(ctx *context.T, root string, stream recvStream) {
+	for stream.Advance() {
+		change := stream.Value()
+		name := naming.Join(root, change.Name)
+		switch change.State {
+		case watch.Exists:
+			var entry naming.MountEntry
+			if change.Value == nil || change.Value.ToValue(&entry) != nil {
+				// Not a mount table: the watch only covers the root.
+				return
+			}
+			entry.Name = name
+			if !w.update(ctx, entry) {
+				return
+			}
+		case watch.DoesNotExist:
+			if !w.remove(ctx, name) {
+				return
+			}
+		}
+		if !change.Continued && !w.sync(ctx) {
+			return
+		}
+	}
+}
+
+// poll polls the pattern with Glob until ctx is canceled.
+func (w *watcher) poll(ctx *context.T) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		if !w.pollOnce(ctx) {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollOnce sends the differences between the results of a Glob and the
+// entries that have been sent.  It returns false if ctx is canceled.
+func (w *watcher) pollOnce(ctx *context.T) bool {
+	ch, err := w.ns.Glob(ctx, w.pattern, w.opts...)
+	if err != nil {
+		return w.fail(ctx, w.pattern, err) && w.sync(ctx)
+	}
+	entries := make(map[string]naming.MountEntry)
+	failures := make(map[string]error)
+	truncated := false
+	for reply := range ch {
+		switch v := reply.(type) {
+		case *naming.GlobReplyEntry:
+			entries[v.Value.Name] = v.Value
+		case *naming.GlobReplyError:
+			failures[v.Value.Name] = v.Value.Error
+		case *naming.GlobReplyTruncated:
+			// The matches after the resume token are missing, so the Glob
+			// fails as a whole.
+			truncated = true
+			failures[w.pattern] = truncatedError(ctx, v.Value)
+		}
+	}
+	if ctx.Err() != nil {
+		// The results of a canceled Glob are incomplete.
+		return false
+	}
+	for _, name := range sortedNames(entries) {
+		if !w.update(ctx, entries[name]) {
+			return false
+		}
+	}
+	for _, name := range sortedNames(w.entries) {
+		if _, ok := entries[name]; ok || truncated || underFailure(name, failures) {
+			continue
+		}
+		if !w.remove(ctx, name) {
+			return false
+		}
+	}
+	for name := range w.failures {
+		if _, ok := failures[name]; !ok {
+			delete(w.failures, name)
+		}
+	}
+	for name, err := range failures {
+		if !w.fail(ctx, name, err) {
+			return false
+		}
+	}
+	return w.sync(ctx)
+}
+
+// truncatedError returns the error that a Glob was truncated with.
+func truncatedError(ctx *context.T, t naming.GlobTruncated) error {
+	if t.Reason != nil {
+		return t.Reason
+	}
+	return verror.New(verror.ErrAborted, ctx, "glob truncated after "+t.ResumeToken)
+}
+
+// underFailure returns true if name is in a part of the namespace that
+// couldn't be traversed.
+func underFailure(name string, failures map[string]error) bool {
+	for root := range failures {
+		if root == "" || name == root || strings.HasPrefix(name, root+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedNames(entries map[string]naming.MountEntry) []string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sameServers returns true if a and b have the same servers and flags,
+// regardless of their order and deadlines: servers refresh their mounts
+// periodically, which isn't a change.
+func sameServers(a, b naming.MountEntry) bool {
+	if a.ServesMountTable != b.ServesMountTable || a.IsLeaf != b.IsLeaf || len(a.Servers) != len(b.Servers) {
+		return false
+	}
+	servers := make(map[string]bool)
+	for _, s := range a.Servers {
+		servers[s.Server] = true
+	}
+	for _, s := range b.Servers {
+		if !servers[s.Server] {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *watcher) send(ctx *context.T, c namespace.Change) bool {
+	select {
+	case w.out <- c:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// update sends an Added or Modified change for entry, if it is new or has
+// changed.
+func (w *watcher) update(ctx *context.T, entry naming.MountEntry) bool {
+	kind := namespace.Added
+	if old, ok := w.entries[entry.Name]; ok {
+		if sameServers(old, entry) {
+			return true
+		}
+		kind = namespace.Modified
+	}
+	w.entries[entry.Name] = entry
+	return w.send(ctx, namespace.Change{Kind: kind, Name: entry.Name, Entry: &entry})
+}
+
+// remove sends a Removed change for name, if it has been sent as matching.
+func (w *watcher) remove(ctx *context.T, name string) bool {
+	if _, ok := w.entries[name]; !ok {
+		return true
+	}
+	delete(w.entries, name)
+	return w.send(ctx, namespace.Change{Kind: namespace.Removed, Name: name})
+}
+
+// fail sends a Failed change, unless the same error has already been sent
+// for name.
+func (w *watcher) fail(ctx *context.T, name string, err error) bool {
+	if w.failures[name] == err.Error() {
+		return true
+	}
+	w.failures[name] = err.Error()
+	return w.send(ctx, namespace.Change{Kind: namespace.Failed, Name: name, Err: err})
+}
+
+// sync sends the Synced change, the first time it is called.
+func (w *watcher) sync(ctx *context.T) bool {
+	if w.synced {
+		return true
+	}
+	w.synced = true
+	return w.send(ctx, namespace.Change{Kind: namespace.Synced})
+}
@@ -0,0 +1,236 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nswatch
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/namespace"
+	"v.io/v23/naming"
+	"v.io/v23/options"
+	"v.io/v23/services/watch"
+	"v.io/v23/vom"
+)
+
+// fakeNamespace has no mount tables that implement GlobWatcher, and returns
+// all its entries and errors from Glob, regardless of the pattern.
+type fakeNamespace struct {
+	mu      sync.Mutex
+	entries map[string][]string
+	errors  map[string]error
+	// truncate is the number of entries after which Glob is truncated, if
+	// positive.
+	truncate int
+	globs    int
+}
+
+func (ns *fakeNamespace) set(name string, servers ...string) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if len(servers) == 0 {
+		delete(ns.entries, name)
+	} else {
+		ns.entries[name] = servers
+	}
+}
+
+func (ns *fakeNamespace) setError(name string, err error) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if err == nil {
+		delete(ns.errors, name)
+	} else {
+		ns.errors[name] = err
+	}
+}
+
+func (ns *fakeNamespace) setTruncate(n int) {
+	ns.mu.Lock()
+	ns.truncate = n
+	ns.mu.Unlock()
+}
+
+func (ns *fakeNamespace) ResolveToMountTable(*context.T, string, ...naming.NamespaceOpt) (*naming.MountEntry, error) {
+	return nil, errors.New("no mount table")
+}
+
+func (ns *fakeNamespace) Glob(ctx *context.T, pattern string, opts ...naming.NamespaceOpt) (<-chan naming.GlobReply, error) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	ns.globs++
+	ch := make(chan naming.GlobReply, len(ns.entries)+len(ns.errors)+1)
+	names := make([]string, 0, len(ns.entries))
+	for name := range ns.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if ns.truncate > 0 && i == ns.truncate {
+			ch <- &naming.GlobReplyTruncated{Value: naming.GlobTruncated{Reason: errors.New("too many results"), ResumeToken: names[i-1]}}
+			break
+		}
+		servers := ns.entries[name]
+		entry := naming.MountEntry{Name: name}
+		for _, s := range servers {
+			entry.Servers = append(entry.Servers, naming.MountedServer{Server: s})
+		}
+		ch <- &naming.GlobReplyEntry{Value: entry}
+	}
+	for name, err := range ns.errors {
+		ch <- &naming.GlobReplyError{Value: naming.GlobError{Name: name, Error: err}}
+	}
+	close(ch)
+	return ch, nil
+}
+
+type change struct {
+	kind    namespace.ChangeKind
+	name    string
+	servers []string
+}
+
+func recv(t *testing.T, ch <-chan namespace.Change) change {
+	t.Helper()
+	select {
+	case c, ok := <-ch:
+		if !ok {
+			t.Fatalf("channel closed")
+		}
+		got := change{kind: c.Kind, name: c.Name}
+		if c.Entry != nil {
+			for _, s := range c.Entry.Servers {
+				got.servers = append(got.servers, s.Server)
+			}
+		}
+		return got
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timed out")
+	}
+	return change{}
+}
+
+func expect(t *testing.T, ch <-chan namespace.Change, want ...change) {
+	t.Helper()
+	for _, w := range want {
+		if got := recv(t, ch); !reflect.DeepEqual(got, w) {
+			t.Fatalf("got %v, want %v", got, w)
+		}
+	}
+}
+
+func TestPoll(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	ns := &fakeNamespace{entries: make(map[string][]string), errors: make(map[string]error)}
+	ns.set("a", "/s1:1")
+	ns.set("b", "/s2:1")
+	ch, err := WatchGlob(ctx, ns, "*", options.WatchPollInterval(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(t, ch,
+		change{namespace.Added, "a", []string{"/s1:1"}},
+		change{namespace.Added, "b", []string{"/s2:1"}},
+		change{kind: namespace.Synced})
+
+	ns.set("c", "/s3:1")
+	expect(t, ch, change{namespace.Added, "c", []string{"/s3:1"}})
+	ns.set("b", "/s2:1", "/s4:1")
+	expect(t, ch, change{namespace.Modified, "b", []string{"/s2:1", "/s4:1"}})
+	ns.set("a")
+	expect(t, ch, change{kind: namespace.Removed, name: "a"})
+
+	// The names below a subtree that can't be traversed aren't removed, and
+	// the error is only sent once.
+	ns.set("x/y", "/s5:1")
+	expect(t, ch, change{namespace.Added, "x/y", []string{"/s5:1"}})
+	ns.set("x/y")
+	ns.setError("x", errors.New("unreachable"))
+	expect(t, ch, change{kind: namespace.Failed, name: "x"})
+	ns.set("d", "/s6:1")
+	expect(t, ch, change{namespace.Added, "d", []string{"/s6:1"}})
+	ns.setError("x", nil)
+	expect(t, ch, change{kind: namespace.Removed, name: "x/y"})
+
+	// The names after the resume token of a truncated Glob aren't removed.
+	ns.setTruncate(1)
+	expect(t, ch, change{kind: namespace.Failed, name: "*"})
+	ns.set("b")
+	ns.setTruncate(0)
+	expect(t, ch, change{kind: namespace.Removed, name: "b"})
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestBadPattern(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	if _, err := WatchGlob(ctx, &fakeNamespace{}, "a/[b"); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+type fakeStream struct {
+	changes []watch.Change
+	current watch.Change
+}
+
+func (s *fakeStream) Advance() bool {
+	if len(s.changes) == 0 {
+		return false
+	}
+	s.current, s.changes = s.changes[0], s.changes[1:]
+	return true
+}
+
+func (s *fakeStream) Value() watch.Change { return s.current }
+func (s *fakeStream) Err() error          { return nil }
+
+func TestConsume(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	entry := func(servers ...string) *vom.RawBytes {
+		var e naming.MountEntry
+		for _, s := range servers {
+			e.Servers = append(e.Servers, naming.MountedServer{Server: s})
+		}
+		return vom.RawBytesOf(e)
+	}
+	stream := &fakeStream{changes: []watch.Change{
+		{Name: "a", State: watch.Exists, Value: entry("/s1:1"), Continued: true},
+		{Name: "b", State: watch.Exists, Value: entry("/s2:1")},
+		{Name: "b", State: watch.Exists, Value: entry("/s2:1")},
+		{Name: "a", State: watch.Exists, Value: entry("/s3:1")},
+		{Name: "b", State: watch.DoesNotExist},
+		{Name: "c", State: watch.DoesNotExist},
+		// Values that aren't mount entries end the watch.
+		{Name: "d", State: watch.Exists, Value: vom.RawBytesOf("d")},
+		{Name: "e", State: watch.Exists, Value: entry("/s4:1")},
+	}}
+	w := &watcher{
+		out:      make(chan namespace.Change, 100),
+		entries:  make(map[string]naming.MountEntry),
+		failures: make(map[string]string),
+	}
+	w.consume(ctx, "/h:1/root", stream)
+	close(w.out)
+	expect(t, w.out,
+		change{namespace.Added, "/h:1/root/a", []string{"/s1:1"}},
+		change{namespace.Added, "/h:1/root/b", []string{"/s2:1"}},
+		change{kind: namespace.Synced},
+		change{namespace.Modified, "/h:1/root/a", []string{"/s3:1"}},
+		change{kind: namespace.Removed, name: "/h:1/root/b"})
+	if c, ok := <-w.out; ok {
+		t.Errorf("unexpected change %v", c)
+	}
+}
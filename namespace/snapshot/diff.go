@@ -0,0 +1,126 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package snapshot
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change is a set of changes to an entry between two snapshots.
+type Change int
+
+const (
+	// Added means that the name is only in the new snapshot.
+	Added Change = 1 << iota
+	// Removed means that the name is only in the old snapshot.
+	Removed
+	// ServersChanged means that different servers are mounted on the name.
+	// Changes to deadlines alone are ignored.
+	ServersChanged
+	// FlagsChanged means that ServesMountTable or IsLeaf changed.
+	FlagsChanged
+	// PermissionsChanged means that the permissions of the name changed.
+	PermissionsChanged
+)
+
+func (c Change) String() string {
+	var names []string
+	for _, x := range []struct {
+		c    Change
+		name string
+	}{
+		{Added, "added"},
+		{Removed, "removed"},
+		{ServersChanged, "servers"},
+		{FlagsChanged, "flags"},
+		{PermissionsChanged, "permissions"},
+	} {
+		if c&x.c != 0 {
+			names = append(names, x.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Difference is a name that differs between two snapshots.
+type Difference struct {
+	Name    string
+	Changes Change
+	// Old and New are the entries of the name in the old and new snapshots,
+	// or nil.
+	Old, New *Entry
+}
+
+func (d Difference) String() string {
+	switch d.Changes {
+	case Added:
+		return fmt.Sprintf("+ %q %v", d.Name, servers(d.New))
+	case Removed:
+		return fmt.Sprintf("- %q %v", d.Name, servers(d.Old))
+	}
+	return fmt.Sprintf("~ %q %v: %v -> %v", d.Name, d.Changes, servers(d.Old), servers(d.New))
+}
+
+func servers(e *Entry) []string {
+	if e == nil {
+		return nil
+	}
+	var s []string
+	for _, server := range e.Servers {
+		s = append(s, server.Server)
+	}
+	return s
+}
+
+// compare returns the changes from old to new, which are entries of the same
+// name.
+func compare(old, new *Entry) Change {
+	var c Change
+	if len(old.Servers) != len(new.Servers) {
+		c |= ServersChanged
+	} else {
+		for i := range old.Servers {
+			if old.Servers[i].Server != new.Servers[i].Server {
+				c |= ServersChanged
+				break
+			}
+		}
+	}
+	if old.ServesMountTable != new.ServesMountTable || old.IsLeaf != new.IsLeaf {
+		c |= FlagsChanged
+	}
+	if old.Permissions != nil && new.Permissions != nil && !reflect.DeepEqual(old.Permissions, new.Permissions) {
+		c |= PermissionsChanged
+	}
+	return c
+}
+
+// Diff returns the names that differ between the old and new snapshots,
+// sorted by name.  Names are compared relative to the roots of the
+// snapshots.  Permissions are only compared if they were read in both
+// snapshots.
+func Diff(old, new *Snapshot) []Difference {
+	var diffs []Difference
+	i, j := 0, 0
+	for i < len(old.Entries) || j < len(new.Entries) {
+		switch {
+		case j == len(new.Entries) || i < len(old.Entries) && old.Entries[i].Name < new.Entries[j].Name:
+			diffs = append(diffs, Difference{Name: old.Entries[i].Name, Changes: Removed, Old: &old.Entries[i]})
+			i++
+		case i == len(old.Entries) || new.Entries[j].Name < old.Entries[i].Name:
+			diffs = append(diffs, Difference{Name: new.Entries[j].Name, Changes: Added, New: &new.Entries[j]})
+			j++
+		default:
+			if c := compare(&old.Entries[i], &new.Entries[j]); c != 0 {
+				diffs = append(diffs, Difference{Name: old.Entries[i].Name, Changes: c, Old: &old.Entries[i], New: &new.Entries[j]})
+			}
+			i++
+			j++
+		}
+	}
+	return diffs
+}
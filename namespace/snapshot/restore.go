@@ -0,0 +1,237 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package snapshot
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/namespace"
+	"v.io/v23/naming"
+	"v.io/v23/security/access"
+	"v.io/v23/verror"
+)
+
+// RestoreOpt is the interface for the options of Restore.
+type RestoreOpt interface {
+	RestoreOpt()
+}
+
+// DryRun makes Restore return the actions it would take, without taking
+// them.
+type DryRun bool
+
+func (DryRun) RestoreOpt() {}
+
+// ConflictPolicy specifies how Restore handles the names that already exist
+// in the target namespace with other servers, flags or permissions than in
+// the snapshot.  Names without servers are only in conflict if their
+// permissions differ.
+type ConflictPolicy int
+
+const (
+	// Skip leaves the names in conflict as they are.  It is the default.
+	Skip ConflictPolicy = iota
+	// Merge mounts the servers of the snapshot on the names in conflict,
+	// alongside their current servers, and leaves their permissions as they
+	// are.  Mounting fails if the flags of the servers differ.
+	Merge
+	// Overwrite replaces the servers and the permissions of the names in
+	// conflict with those of the snapshot.  The current servers are unmounted
+	// if the snapshot has no servers for a name, or only expired ones.
+	Overwrite
+	// Abort makes Restore fail, without changing anything, if a name is in
+	// conflict.
+	Abort
+)
+
+func (ConflictPolicy) RestoreOpt() {}
+
+func (p ConflictPolicy) String() string {
+	switch p {
+	case Skip:
+		return "Skip"
+	case Merge:
+		return "Merge"
+	case Overwrite:
+		return "Overwrite"
+	case Abort:
+		return "Abort"
+	}
+	return fmt.Sprintf("ConflictPolicy(%d)", int(p))
+}
+
+// ActionKind is the kind of an Action.
+type ActionKind int
+
+const (
+	// MountServer is a call to namespace.T.Mount.
+	MountServer ActionKind = iota
+	// SetPermissions is a call to namespace.T.SetPermissions.
+	SetPermissions
+	// UnmountServer is a call to namespace.T.Unmount.
+	UnmountServer
+)
+
+// Action is a change made, or that would be made with DryRun, by Restore.
+type Action struct {
+	Kind ActionKind
+	// Name is the name in the target namespace.
+	Name string
+	// Server, TTL and the flags are the arguments of MountServer actions.
+	// Server is also the argument of UnmountServer actions.
+	Server           string
+	TTL              time.Duration
+	ServesMountTable bool
+	IsLeaf           bool
+	Replace          bool
+	// Permissions are the permissions of SetPermissions actions.
+	Permissions access.Permissions
+}
+
+func (a Action) String() string {
+	switch a.Kind {
+	case SetPermissions:
+		return fmt.Sprintf("setperms %q %v", a.Name, a.Permissions)
+	case UnmountServer:
+		return fmt.Sprintf("unmount %q %s", a.Name, a.Server)
+	}
+	var flags []string
+	if a.ServesMountTable {
+		flags = append(flags, "mt")
+	}
+	if a.IsLeaf {
+		flags = append(flags, "leaf")
+	}
+	if a.Replace {
+		flags = append(flags, "replace")
+	}
+	return fmt.Sprintf("mount %q %s %v [%s]", a.Name, a.Server, a.TTL, strings.Join(flags, ","))
+}
+
+func (a Action) do(ctx *context.T, ns namespace.T) error {
+	switch a.Kind {
+	case SetPermissions:
+		return ns.SetPermissions(ctx, a.Name, a.Permissions, "")
+	case UnmountServer:
+		return ns.Unmount(ctx, a.Name, a.Server)
+	}
+	return ns.Mount(ctx, a.Name, a.Server, a.TTL,
+		naming.ServesMountTable(a.ServesMountTable), naming.IsLeaf(a.IsLeaf), naming.ReplaceMount(a.Replace))
+}
+
+// inConflict returns true if the existing entry of a name, which may be nil,
+// is in conflict with the entry of the snapshot.
+func inConflict(existing, e *Entry) bool {
+	if existing == nil {
+		return false
+	}
+	c := compare(existing, e)
+	if len(existing.Servers) == 0 {
+		c &^= ServersChanged | FlagsChanged
+	}
+	return c != 0
+}
+
+// Restore replays the snapshot s into the namespace below root, which is
+// usually a fresh mount table: it mounts the servers, and sets the
+// permissions of the names of the snapshot.  Servers whose mount has expired
+// are not mounted, and the others are mounted with the TTL that they had
+// left.  Names of the namespace that aren't in the snapshot are left as they
+// are.
+//
+// Restore returns the actions that it took, or would take with DryRun(true).
+// It stops at the first action that fails.
+func Restore(ctx *context.T, ns namespace.T, s *Snapshot, root string, opts ...RestoreOpt) ([]Action, error) {
+	var dryRun bool
+	policy := Skip
+	for _, o := range opts {
+		switch v := o.(type) {
+		case DryRun:
+			dryRun = bool(v)
+		case ConflictPolicy:
+			policy = v
+		}
+	}
+	target, err := Export(ctx, ns, root)
+	if err != nil {
+		return nil, err
+	}
+	if policy == Abort {
+		var conflicts []string
+		for i := range s.Entries {
+			if e := &s.Entries[i]; inConflict(target.Lookup(e.Name), e) {
+				conflicts = append(conflicts, fmt.Sprintf("%q", e.Name))
+			}
+		}
+		if len(conflicts) > 0 {
+			return nil, verror.New(errConflicts, ctx, len(conflicts), strings.Join(conflicts, ", "))
+		}
+	}
+	now := time.Now()
+	var actions []Action
+	for i := range s.Entries {
+		e := &s.Entries[i]
+		existing := target.Lookup(e.Name)
+		conflict := inConflict(existing, e)
+		if conflict && policy == Skip {
+			continue
+		}
+		mounted := make(map[string]bool)
+		if existing != nil && !(conflict && policy == Overwrite) {
+			for _, server := range existing.Servers {
+				mounted[server.Server] = true
+			}
+		}
+		replace := conflict && policy == Overwrite && len(existing.Servers) > 0
+		for _, server := range e.Servers {
+			var ttl time.Duration
+			if !server.Deadline.IsZero() {
+				if ttl = server.Deadline.Sub(now); ttl <= 0 {
+					continue
+				}
+			}
+			if mounted[server.Server] {
+				continue
+			}
+			actions = append(actions, Action{
+				Kind:             MountServer,
+				Name:             naming.Join(root, e.Name),
+				Server:           server.Server,
+				TTL:              ttl,
+				ServesMountTable: e.ServesMountTable,
+				IsLeaf:           e.IsLeaf,
+				Replace:          replace,
+			})
+			replace = false
+		}
+		if replace {
+			// None of the servers of the snapshot are left to replace the
+			// existing ones, so they are unmounted instead.
+			for _, server := range existing.Servers {
+				actions = append(actions, Action{Kind: UnmountServer, Name: naming.Join(root, e.Name), Server: server.Server})
+			}
+		}
+		if e.Permissions == nil || existing != nil && reflect.DeepEqual(existing.Permissions, e.Permissions) {
+			continue
+		}
+		if existing != nil && existing.Permissions != nil && policy == Merge {
+			continue
+		}
+		actions = append(actions, Action{Kind: SetPermissions, Name: naming.Join(root, e.Name), Permissions: e.Permissions})
+	}
+	if dryRun {
+		return actions, nil
+	}
+	for i, a := range actions {
+		if err := a.do(ctx, ns); err != nil {
+			return actions[:i], err
+		}
+	}
+	return actions, nil
+}
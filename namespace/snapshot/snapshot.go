@@ -0,0 +1,246 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package snapshot captures the state of a namespace below a root, so that it
+// can be saved, compared and restored:
+//
+//   before, err := snapshot.Export(ctx, ns, "/mt:8101/apps")
+//   ...
+//   after, err := snapshot.Export(ctx, ns, "/mt:8101/apps")
+//   for _, d := range snapshot.Diff(before, after) {
+//     fmt.Println(d)
+//   }
+//   err = snapshot.Save("apps.vom", before)
+//
+// A snapshot records the names below the root, their mounted servers and
+// flags, and their permissions, as reported by namespace.T.Glob and
+// namespace.T.GetPermissions.  Restore replays a snapshot into a namespace,
+// with namespace.T.Mount and namespace.T.SetPermissions.
+package snapshot
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/namespace"
+	"v.io/v23/naming"
+	"v.io/v23/security/access"
+	_ "v.io/v23/vdlroot/time"
+	"v.io/v23/verror"
+	"v.io/v23/vom"
+)
+
+const pkgPath = "v.io/v23/namespace/snapshot"
+
+var (
+	errUnknownFormat = verror.Register(pkgPath+".errUnknownFormat", verror.NoRetry, "{1:}{2:} unknown snapshot format {3}{:_}")
+	errConflicts     = verror.Register(pkgPath+".errConflicts", verror.NoRetry, "{1:}{2:} {3} names already exist with different servers or permissions: {4}{:_}")
+)
+
+// Snapshot is the state of the namespace below a root.
+type Snapshot struct {
+	// Root is the name below which the snapshot was taken.
+	Root string
+	// Created is the time at which the snapshot was taken.
+	Created time.Time
+	// Entries are the names below the root, sorted by name.
+	Entries []Entry
+	// Failures are the parts of the namespace that couldn't be read.
+	Failures []Failure
+}
+
+// Entry is a name in a Snapshot.
+type Entry struct {
+	// Name is relative to the root of the snapshot.  The root itself is "".
+	Name string
+	// Servers are the servers mounted on the name, sorted by address.
+	Servers []Server
+	// ServesMountTable is true if the servers are mount tables.
+	ServesMountTable bool
+	// IsLeaf is true if the servers are leaf servers.
+	IsLeaf bool
+	// Permissions are the permissions of the name, or nil if they couldn't
+	// be read.
+	Permissions access.Permissions
+}
+
+// Server is a server mounted on a name.
+type Server struct {
+	// Server is the address of the server.
+	Server string
+	// Deadline is the time at which the mount expires, or the zero time if
+	// it doesn't.
+	Deadline time.Time
+}
+
+// Failure is a part of the namespace that couldn't be read.
+type Failure struct {
+	// Name is relative to the root of the snapshot.
+	Name string
+	// Error describes why it couldn't be read.
+	Error string
+}
+
+// Lookup returns the entry with the given name, or nil.
+func (s *Snapshot) Lookup(name string) *Entry {
+	i := sort.Search(len(s.Entries), func(i int) bool { return s.Entries[i].Name >= name })
+	if i < len(s.Entries) && s.Entries[i].Name == name {
+		return &s.Entries[i]
+	}
+	return nil
+}
+
+type byName []Entry
+
+func (e byName) Len() int           { return len(e) }
+func (e byName) Less(i, j int) bool { return e[i].Name < e[j].Name }
+func (e byName) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+
+type byServer []Server
+
+func (s byServer) Len() int           { return len(s) }
+func (s byServer) Less(i, j int) bool { return s[i].Server < s[j].Server }
+func (s byServer) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// relative returns name relative to root.
+func relative(root, name string) string {
+	root, name = naming.Clean(root), naming.Clean(name)
+	if name == root {
+		return ""
+	}
+	if root == "" {
+		return name
+	}
+	return strings.TrimPrefix(name, strings.TrimSuffix(root, "/")+"/")
+}
+
+// Export takes a snapshot of the names below root, including root itself.
+// The parts of the namespace that can't be read are recorded as Failures of
+// the snapshot; Export only fails if Glob does.
+func Export(ctx *context.T, ns namespace.T, root string, opts ...naming.NamespaceOpt) (*Snapshot, error) {
+	ch, err := ns.Glob(ctx, naming.Join(root, "..."), opts...)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{Root: root, Created: time.Now()}
+	for reply := range ch {
+		switch v := reply.(type) {
+		case *naming.GlobReplyEntry:
+			e := Entry{
+				Name:             relative(root, v.Value.Name),
+				ServesMountTable: v.Value.ServesMountTable,
+				IsLeaf:           v.Value.IsLeaf,
+			}
+			for _, ms := range v.Value.Servers {
+				e.Servers = append(e.Servers, Server{Server: ms.Server, Deadline: ms.Deadline.Time})
+			}
+			sort.Sort(byServer(e.Servers))
+			s.Entries = append(s.Entries, e)
+		case *naming.GlobReplyError:
+			s.Failures = append(s.Failures, Failure{relative(root, v.Value.Name), v.Value.Error.Error()})
+		case *naming.GlobReplyTruncated:
+			// The names after the resume token are missing from the
+			// snapshot.
+			reason := "glob truncated"
+			if v.Value.Reason != nil {
+				reason = v.Value.Reason.Error()
+			}
+			s.Failures = append(s.Failures, Failure{relative(root, v.Value.ResumeToken), reason})
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sort.Sort(byName(s.Entries))
+	for i := range s.Entries {
+		e := &s.Entries[i]
+		perms, _, err := ns.GetPermissions(ctx, naming.Join(root, e.Name), opts...)
+		if err != nil {
+			s.Failures = append(s.Failures, Failure{e.Name, err.Error()})
+			continue
+		}
+		e.Permissions = perms.Normalize()
+	}
+	return s, nil
+}
+
+// Format is the encoding of a snapshot.
+type Format int
+
+const (
+	// VOM is the binary vom encoding.
+	VOM Format = iota
+	// JSON is a readable encoding, suitable for reviews.
+	JSON
+)
+
+// formatOf returns the format of a file from its extension: JSON for .json
+// files, VOM otherwise.
+func formatOf(filename string) Format {
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		return JSON
+	}
+	return VOM
+}
+
+// Encode writes s to w in the given format.
+func (s *Snapshot) Encode(w io.Writer, f Format) error {
+	switch f {
+	case VOM:
+		return vom.NewEncoder(w).Encode(s)
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}
+	return verror.New(errUnknownFormat, nil, f)
+}
+
+// Decode reads a snapshot in the given format from r.
+func Decode(r io.Reader, f Format) (*Snapshot, error) {
+	s := new(Snapshot)
+	var err error
+	switch f {
+	case VOM:
+		err = vom.NewDecoder(r).Decode(s)
+	case JSON:
+		err = json.NewDecoder(r).Decode(s)
+	default:
+		err = verror.New(errUnknownFormat, nil, f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Save writes s to a file, in JSON if the name of the file ends with .json,
+// and in VOM otherwise.
+func Save(filename string, s *Snapshot) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := s.Encode(f, formatOf(filename)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads a snapshot written by Save.
+func Load(filename string) (*Snapshot, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Decode(f, formatOf(filename))
+}
@@ -0,0 +1,376 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package snapshot_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/namespace"
+	"v.io/v23/namespace/snapshot"
+	"v.io/v23/naming"
+	"v.io/v23/security/access"
+	vdltime "v.io/v23/vdlroot/time"
+)
+
+type node struct {
+	servers map[string]time.Time
+	mt      bool
+	leaf    bool
+	perms   access.Permissions
+}
+
+// fakeNamespace is a flat namespace: Glob returns all the names that start
+// with the fixed prefix of the pattern.
+type fakeNamespace struct {
+	namespace.T
+	mu    sync.Mutex
+	nodes map[string]*node
+	fail  map[string]bool
+	// resume, if set, is the resume token of a truncated reply that ends
+	// every Glob.
+	resume string
+}
+
+func newNamespace() *fakeNamespace {
+	return &fakeNamespace{nodes: make(map[string]*node), fail: make(map[string]bool)}
+}
+
+func (ns *fakeNamespace) node(name string) *node {
+	n := ns.nodes[name]
+	if n == nil {
+		n = &node{servers: make(map[string]time.Time)}
+		ns.nodes[name] = n
+	}
+	return n
+}
+
+func (ns *fakeNamespace) Mount(ctx *context.T, name, server string, ttl time.Duration, opts ...naming.NamespaceOpt) error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	n := ns.node(name)
+	var mt, leaf bool
+	for _, o := range opts {
+		switch v := o.(type) {
+		case naming.ServesMountTable:
+			mt = bool(v)
+		case naming.IsLeaf:
+			leaf = bool(v)
+		case naming.ReplaceMount:
+			if v {
+				n.servers = make(map[string]time.Time)
+			}
+		}
+	}
+	if len(n.servers) > 0 && (n.mt != mt || n.leaf != leaf) {
+		return errors.New("flag mismatch")
+	}
+	var deadline time.Time
+	if ttl > 0 {
+		deadline = time.Now().Add(ttl)
+	}
+	n.servers[server], n.mt, n.leaf = deadline, mt, leaf
+	return nil
+}
+
+func (ns *fakeNamespace) Unmount(ctx *context.T, name, server string, opts ...naming.NamespaceOpt) error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	delete(ns.node(name).servers, server)
+	return nil
+}
+
+func (ns *fakeNamespace) SetPermissions(ctx *context.T, name string, perms access.Permissions, version string, opts ...naming.NamespaceOpt) error {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	ns.node(name).perms = perms.Copy()
+	return nil
+}
+
+func (ns *fakeNamespace) GetPermissions(ctx *context.T, name string, opts ...naming.NamespaceOpt) (access.Permissions, string, error) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if ns.fail[name] {
+		return nil, "", errors.New("access denied")
+	}
+	if n := ns.nodes[name]; n != nil && n.perms != nil {
+		return n.perms.Copy(), "1", nil
+	}
+	return access.Permissions{}.Add("admin", string(access.Admin)), "0", nil
+}
+
+func (ns *fakeNamespace) Glob(ctx *context.T, pattern string, opts ...naming.NamespaceOpt) (<-chan naming.GlobReply, error) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	root := strings.TrimSuffix(strings.TrimSuffix(pattern, "..."), "/")
+	ch := make(chan naming.GlobReply, len(ns.nodes)+2)
+	for name, n := range ns.nodes {
+		if name != root && !strings.HasPrefix(name, root+"/") {
+			continue
+		}
+		entry := naming.MountEntry{Name: name, ServesMountTable: n.mt, IsLeaf: n.leaf}
+		for s, d := range n.servers {
+			entry.Servers = append(entry.Servers, naming.MountedServer{Server: s, Deadline: vdltime.Deadline{Time: d}})
+		}
+		ch <- &naming.GlobReplyEntry{Value: entry}
+	}
+	if ns.fail[root+"/broken"] {
+		ch <- &naming.GlobReplyError{Value: naming.GlobError{Name: root + "/broken", Error: errors.New("unreachable")}}
+	}
+	if ns.resume != "" {
+		ch <- &naming.GlobReplyTruncated{Value: naming.GlobTruncated{Reason: errors.New("too many results"), ResumeToken: ns.resume}}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func mustExport(t *testing.T, ctx *context.T, ns namespace.T, root string) *snapshot.Snapshot {
+	s, err := snapshot.Export(ctx, ns, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func names(s *snapshot.Snapshot) []string {
+	var names []string
+	for _, e := range s.Entries {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+func populate(ctx *context.T, ns namespace.T) {
+	ns.Mount(ctx, "/mt/apps/a", "/s1:1", 0)
+	ns.Mount(ctx, "/mt/apps/a", "/s2:1", time.Hour)
+	ns.Mount(ctx, "/mt/apps/b/c", "/mt2:1", 0, naming.ServesMountTable(true))
+	ns.Mount(ctx, "/mt/other", "/s3:1", 0)
+	ns.SetPermissions(ctx, "/mt/apps/b", access.Permissions{}.Add("alice", string(access.Admin)), "")
+}
+
+func TestExport(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	ns := newNamespace()
+	populate(ctx, ns)
+	ns.fail["/mt/apps/b/c"] = true
+	ns.fail["/mt/apps/broken"] = true
+
+	s := mustExport(t, ctx, ns, "/mt/apps")
+	if got, want := names(s), []string{"a", "b", "b/c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got names %v, want %v", got, want)
+	}
+	a := s.Lookup("a")
+	if len(a.Servers) != 2 || a.Servers[0].Server != "/s1:1" || !a.Servers[0].Deadline.IsZero() || a.Servers[1].Deadline.IsZero() {
+		t.Errorf("unexpected entry %+v", a)
+	}
+	if c := s.Lookup("b/c"); !c.ServesMountTable || c.Permissions != nil {
+		t.Errorf("unexpected entry %+v", c)
+	}
+	if b := s.Lookup("b"); !reflect.DeepEqual(b.Permissions, access.Permissions{}.Add("alice", string(access.Admin))) {
+		t.Errorf("unexpected entry %+v", b)
+	}
+	var failures []string
+	for _, f := range s.Failures {
+		failures = append(failures, f.Name)
+	}
+	sort.Strings(failures)
+	if want := []string{"b/c", "broken"}; !reflect.DeepEqual(failures, want) {
+		t.Errorf("got failures %v, want %v", failures, want)
+	}
+	if s.Lookup("x") != nil {
+		t.Errorf("unexpected entry for x")
+	}
+}
+
+func TestExportTruncated(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	ns := newNamespace()
+	populate(ctx, ns)
+	ns.resume = "/mt/apps/a"
+
+	s := mustExport(t, ctx, ns, "/mt/apps")
+	if want := []snapshot.Failure{{"a", "too many results"}}; !reflect.DeepEqual(s.Failures, want) {
+		t.Errorf("got failures %v, want %v", s.Failures, want)
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	ns := newNamespace()
+	populate(ctx, ns)
+	ns.fail["/mt/broken"] = true
+	s := mustExport(t, ctx, ns, "/mt")
+
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, file := range []string{"s.vom", "s.json"} {
+		file = filepath.Join(dir, file)
+		if err := snapshot.Save(file, s); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		got, err := snapshot.Load(file)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if d := snapshot.Diff(s, got); len(d) > 0 || got.Root != s.Root || !got.Created.Equal(s.Created) || !reflect.DeepEqual(got.Failures, s.Failures) {
+			t.Errorf("%s: got %+v, want %+v", file, got, s)
+		}
+		if a := got.Lookup("apps/a"); !a.Servers[1].Deadline.Equal(s.Lookup("apps/a").Servers[1].Deadline) {
+			t.Errorf("%s: got deadline %v", file, a.Servers[1].Deadline)
+		}
+	}
+	var buf bytes.Buffer
+	if err := s.Encode(&buf, snapshot.Format(7)); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	ns := newNamespace()
+	populate(ctx, ns)
+	before := mustExport(t, ctx, ns, "/mt")
+
+	ns.Mount(ctx, "/mt/apps/a", "/s1:1", time.Minute) // Only the deadline changes.
+	ns.Mount(ctx, "/mt/apps/b/c", "/mt3:1", 0, naming.ServesMountTable(true))
+	ns.Mount(ctx, "/mt/new", "/s4:1", 0)
+	ns.Mount(ctx, "/mt/other", "/s3:1", 0, naming.ReplaceMount(true), naming.IsLeaf(true))
+	ns.SetPermissions(ctx, "/mt/apps/b", access.Permissions{}.Add("bob", string(access.Admin)), "")
+	delete(ns.nodes, "/mt/apps/a")
+	after := mustExport(t, ctx, ns, "/mt")
+
+	var got []string
+	for _, d := range snapshot.Diff(before, after) {
+		got = append(got, d.Name+" "+d.Changes.String())
+	}
+	want := []string{"apps/a removed", "apps/b permissions", "apps/b/c servers", "new added", "other flags"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if d := snapshot.Diff(after, after); len(d) != 0 {
+		t.Errorf("got %v, want no differences", d)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	src := newNamespace()
+	populate(ctx, src)
+	s := mustExport(t, ctx, src, "/mt/apps")
+
+	// Into an empty namespace, under another root.
+	dst := newNamespace()
+	actions, err := snapshot.Restore(ctx, dst, s, "/mt2/apps", snapshot.DryRun(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 6 || len(dst.nodes) != 0 {
+		t.Fatalf("got %v and %d nodes after a dry run", actions, len(dst.nodes))
+	}
+	if _, err := snapshot.Restore(ctx, dst, s, "/mt2/apps"); err != nil {
+		t.Fatal(err)
+	}
+	if d := snapshot.Diff(s, mustExport(t, ctx, dst, "/mt2/apps")); len(d) != 0 {
+		t.Errorf("got differences after restoring: %v", d)
+	}
+	if a := dst.nodes["/mt2/apps/a"]; !a.servers["/s1:1"].IsZero() || a.servers["/s2:1"].IsZero() {
+		t.Errorf("unexpected deadlines %v", a.servers)
+	}
+
+	// Conflicts.
+	conflicting := func() *fakeNamespace {
+		ns := newNamespace()
+		ns.Mount(ctx, "/mt/apps/a", "/s9:1", 0)
+		ns.SetPermissions(ctx, "/mt/apps/b", access.Permissions{}.Add("bob", string(access.Admin)), "")
+		return ns
+	}
+	servers := func(ns *fakeNamespace, name string) []string {
+		var s []string
+		for server := range ns.nodes[name].servers {
+			s = append(s, server)
+		}
+		sort.Strings(s)
+		return s
+	}
+	dst = conflicting()
+	if _, err := snapshot.Restore(ctx, dst, s, "/mt/apps", snapshot.Abort); err == nil {
+		t.Errorf("expected an error")
+	}
+	if got := servers(dst, "/mt/apps/a"); !reflect.DeepEqual(got, []string{"/s9:1"}) || len(dst.nodes) != 2 {
+		t.Errorf("Abort changed the namespace: %v", got)
+	}
+	if _, err := snapshot.Restore(ctx, dst, s, "/mt/apps"); err != nil {
+		t.Fatal(err)
+	}
+	if got := servers(dst, "/mt/apps/a"); !reflect.DeepEqual(got, []string{"/s9:1"}) || dst.nodes["/mt/apps/b/c"] == nil {
+		t.Errorf("Skip: got %v", got)
+	}
+
+	dst = conflicting()
+	if _, err := snapshot.Restore(ctx, dst, s, "/mt/apps", snapshot.Merge); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := servers(dst, "/mt/apps/a"), []string{"/s1:1", "/s2:1", "/s9:1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge: got %v, want %v", got, want)
+	}
+	if got, want := dst.nodes["/mt/apps/b"].perms, (access.Permissions{}.Add("bob", string(access.Admin))); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge changed permissions: %v", dst.nodes["/mt/apps/b"].perms)
+	}
+
+	dst = conflicting()
+	if _, err := snapshot.Restore(ctx, dst, s, "/mt/apps", snapshot.Overwrite); err != nil {
+		t.Fatal(err)
+	}
+	if d := snapshot.Diff(s, mustExport(t, ctx, dst, "/mt/apps")); len(d) != 0 {
+		t.Errorf("Overwrite: got differences %v", d)
+	}
+}
+
+func TestRestoreOverwriteUnmounts(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	// In the snapshot, a's only server has expired, and b has no servers.
+	s := &snapshot.Snapshot{Root: "/mt/apps", Entries: []snapshot.Entry{
+		{Name: "a", Servers: []snapshot.Server{{Server: "/s1:1", Deadline: time.Now().Add(-time.Hour)}}},
+		{Name: "b"},
+	}}
+	dst := newNamespace()
+	dst.Mount(ctx, "/mt/apps/a", "/s9:1", 0)
+	dst.Mount(ctx, "/mt/apps/b", "/s8:1", 0)
+	actions, err := snapshot.Restore(ctx, dst, s, "/mt/apps", snapshot.Overwrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []snapshot.Action{
+		{Kind: snapshot.UnmountServer, Name: "/mt/apps/a", Server: "/s9:1"},
+		{Kind: snapshot.UnmountServer, Name: "/mt/apps/b", Server: "/s8:1"},
+	}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("got actions %v, want %v", actions, want)
+	}
+	for _, name := range []string{"/mt/apps/a", "/mt/apps/b"} {
+		if n := dst.nodes[name]; len(n.servers) != 0 {
+			t.Errorf("%v: got servers %v, want none", name, n.servers)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package namespace
+
+import (
+	"fmt"
+
+	"v.io/v23/naming"
+)
+
+// ChangeKind is the kind of a Change returned by WatchGlob.
+type ChangeKind int
+
+const (
+	// Added indicates that a name matches the pattern, either because it
+	// was mounted, or because it was part of the initial state of the watch.
+	Added ChangeKind = iota
+	// Modified indicates that the servers mounted on a name have changed.
+	Modified
+	// Removed indicates that a name no longer matches the pattern, i.e.
+	// that its servers were unmounted or have expired.
+	Removed
+	// Synced indicates that all the names that matched the pattern when
+	// the watch started have been sent as Added changes.  It is sent once.
+	Synced
+	// Failed indicates that part of the namespace can't be watched,
+	// for example because a mount table is unreachable.
+	Failed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "Added"
+	case Modified:
+		return "Modified"
+	case Removed:
+		return "Removed"
+	case Synced:
+		return "Synced"
+	case Failed:
+		return "Failed"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change is a change to the names that match the pattern of a WatchGlob
+// call.
+type Change struct {
+	Kind ChangeKind
+	// Name is the name that changed.  For Failed changes, it is the root
+	// of the part of the namespace that can't be watched.
+	Name string
+	// Entry is the current mount entry of the name, for Added and Modified
+	// changes.
+	Entry *naming.MountEntry
+	// Err is the error of Failed changes.
+	Err error
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case Added, Modified:
+		return fmt.Sprintf("%v %s: %v", c.Kind, c.Name, c.Entry.Servers)
+	case Failed:
+		return fmt.Sprintf("%v %s: %v", c.Kind, c.Name, c.Err)
+	}
+	return fmt.Sprintf("%v %s", c.Kind, c.Name)
+}
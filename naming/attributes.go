@@ -0,0 +1,235 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package naming
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	attrSeparator      = ","
+	attrValueSeparator = "="
+	attrTypeSeparator  = ":"
+)
+
+// Attribute is a typed key/value pair carried by an Endpoint, such as the
+// region or the weight of a server, so that clients and balancers can make
+// placement decisions without an extra lookup.
+//
+// Keys are non-empty and made of ASCII letters, digits, '_', '-' and '.'.
+// Values are of type string, int64, bool or float64; the type of a value is
+// preserved by the endpoint string.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+func (a Attribute) String() string {
+	return fmt.Sprintf("%s=%v", a.Key, a.Value)
+}
+
+// The type tags of attribute values in endpoint strings.
+const (
+	stringAttr = "s"
+	intAttr    = "i"
+	boolAttr   = "b"
+	floatAttr  = "f"
+)
+
+func validAttrKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		switch c := key[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '_' || c == '-' || c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeAttr checks a and converts values of other integer types to int64.
+func normalizeAttr(a Attribute) (Attribute, error) {
+	if !validAttrKey(a.Key) {
+		return a, fmt.Errorf("invalid attribute key %q", a.Key)
+	}
+	switch v := a.Value.(type) {
+	case string, int64, bool:
+	case float64:
+		if math.IsNaN(v) {
+			return a, fmt.Errorf("invalid value for attribute %q: NaN", a.Key)
+		}
+	case int:
+		a.Value = int64(v)
+	case int32:
+		a.Value = int64(v)
+	default:
+		return a, fmt.Errorf("invalid type %T for attribute %q", a.Value, a.Key)
+	}
+	return a, nil
+}
+
+type attributes []Attribute
+
+func (a attributes) Len() int           { return len(a) }
+func (a attributes) Less(i, j int) bool { return a[i].Key < a[j].Key }
+func (a attributes) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// WithAttributes derives a new endpoint with the given attributes, but
+// otherwise identical to e.  It returns an error if an attribute is invalid
+// or if a key is repeated.  Integer values of type int and int32 are
+// converted to int64.
+func (e Endpoint) WithAttributes(attrs ...Attribute) (Endpoint, error) {
+	sorted := make(attributes, len(attrs))
+	for i, a := range attrs {
+		var err error
+		if sorted[i], err = normalizeAttr(a); err != nil {
+			return e, err
+		}
+	}
+	sort.Sort(sorted)
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Key == sorted[i-1].Key {
+			return e, fmt.Errorf("duplicate attribute key %q", sorted[i].Key)
+		}
+	}
+	if len(sorted) == 0 {
+		sorted = nil
+	}
+	e.attrs = sorted
+	return e, nil
+}
+
+// Attributes returns the attributes of the endpoint, sorted by key.
+func (e Endpoint) Attributes() []Attribute {
+	return append([]Attribute(nil), e.attrs...)
+}
+
+// Attribute returns the value of the attribute with the given key, and
+// whether the endpoint has such an attribute.
+func (e Endpoint) Attribute(key string) (interface{}, bool) {
+	i := sort.Search(len(e.attrs), func(i int) bool { return e.attrs[i].Key >= key })
+	if i < len(e.attrs) && e.attrs[i].Key == key {
+		return e.attrs[i].Value, true
+	}
+	return nil, false
+}
+
+// StringAttribute returns the value of the string attribute with the given
+// key, and whether the endpoint has a string attribute with that key.
+func (e Endpoint) StringAttribute(key string) (string, bool) {
+	v, _ := e.Attribute(key)
+	s, ok := v.(string)
+	return s, ok
+}
+
+// IntAttribute returns the value of the int64 attribute with the given key,
+// and whether the endpoint has an int64 attribute with that key.
+func (e Endpoint) IntAttribute(key string) (int64, bool) {
+	v, _ := e.Attribute(key)
+	i, ok := v.(int64)
+	return i, ok
+}
+
+// BoolAttribute returns the value of the bool attribute with the given key,
+// and whether the endpoint has a bool attribute with that key.
+func (e Endpoint) BoolAttribute(key string) (bool, bool) {
+	v, _ := e.Attribute(key)
+	b, ok := v.(bool)
+	return b, ok
+}
+
+// FloatAttribute returns the value of the float64 attribute with the given
+// key, and whether the endpoint has a float64 attribute with that key.
+func (e Endpoint) FloatAttribute(key string) (float64, bool) {
+	v, _ := e.Attribute(key)
+	f, ok := v.(float64)
+	return f, ok
+}
+
+// formatAttributes returns the attributes field of a version 7 endpoint:
+//   <key>=<type>:<value>[,<key>=<type>:<value>]...
+// where type is one of s, i, b and f.  String values are escaped.
+func formatAttributes(attrs []Attribute) string {
+	parts := make([]string, len(attrs))
+	for i, a := range attrs {
+		var typ, value string
+		switch v := a.Value.(type) {
+		case string:
+			typ, value = stringAttr, Escape(v, "@/,=")
+		case int64:
+			typ, value = intAttr, strconv.FormatInt(v, 10)
+		case bool:
+			typ, value = boolAttr, strconv.FormatBool(v)
+		case float64:
+			typ, value = floatAttr, strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		parts[i] = a.Key + attrValueSeparator + typ + attrTypeSeparator + value
+	}
+	return strings.Join(parts, attrSeparator)
+}
+
+// parseAttributes parses the attributes field of a version 7 endpoint.  The
+// attributes must be sorted by key, without duplicates, so that every set of
+// attributes has a single representation.
+func parseAttributes(s string) ([]Attribute, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var attrs []Attribute
+	for _, field := range strings.Split(s, attrSeparator) {
+		kv := strings.SplitN(field, attrValueSeparator, 2)
+		if len(kv) != 2 || !validAttrKey(kv[0]) {
+			return nil, fmt.Errorf("invalid attribute %q", field)
+		}
+		tv := strings.SplitN(kv[1], attrTypeSeparator, 2)
+		if len(tv) != 2 {
+			return nil, fmt.Errorf("invalid attribute %q: missing type", field)
+		}
+		a := Attribute{Key: kv[0]}
+		var err error
+		switch typ, value := tv[0], tv[1]; typ {
+		case stringAttr:
+			var ok bool
+			if a.Value, ok = Unescape(value); !ok {
+				err = fmt.Errorf("bad escape %s", value)
+			}
+		case intAttr:
+			a.Value, err = strconv.ParseInt(value, 10, 64)
+		case boolAttr:
+			switch value {
+			case "true":
+				a.Value = true
+			case "false":
+				a.Value = false
+			default:
+				err = fmt.Errorf("invalid bool %q", value)
+			}
+		case floatAttr:
+			var f float64
+			if f, err = strconv.ParseFloat(value, 64); err == nil && math.IsNaN(f) {
+				err = fmt.Errorf("invalid float %q", value)
+			}
+			a.Value = f
+		default:
+			err = fmt.Errorf("unknown type %q", typ)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid attribute %q: %v", field, err)
+		}
+		if n := len(attrs); n > 0 && attrs[n-1].Key >= a.Key {
+			return nil, fmt.Errorf("attribute %q is out of order or repeated", a.Key)
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs, nil
+}
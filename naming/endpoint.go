@@ -38,6 +38,7 @@ type Endpoint struct {
 	RoutingID     RoutingID
 	routes        []string
 	blessingNames []string
+	attrs         []Attribute
 
 	// ServesMountTable is true if this endpoint serves a mount table.
 	// TODO(mattr): Remove it?
@@ -84,6 +85,8 @@ func ParseEndpoint(input string) (Endpoint, error) {
 	switch version {
 	case 6:
 		return parseV6(parts)
+	case 7:
+		return parseV7(parts)
 	default:
 		return Endpoint{}, errInvalidEndpointString
 	}
@@ -110,6 +113,38 @@ func parseHostPort(blessing, hostport string) (Endpoint, error) {
 }
 
 func parseV6(parts []string) (Endpoint, error) {
+	ep, err := parseCommon(parts)
+	if err != nil {
+		return ep, err
+	}
+	// Join the remaining and re-split.
+	if str := strings.Join(parts[6:], separator); len(str) > 0 {
+		ep.blessingNames = strings.Split(str, blessingsSeparator)
+	}
+	return ep, nil
+}
+
+func parseV7(parts []string) (Endpoint, error) {
+	if len(parts) < 7 {
+		return Endpoint{}, errInvalidEndpointString
+	}
+	ep, err := parseCommon(parts)
+	if err != nil {
+		return ep, err
+	}
+	if ep.attrs, err = parseAttributes(parts[6]); err != nil {
+		return ep, err
+	}
+	// Join the remaining and re-split.
+	if str := strings.Join(parts[7:], separator); len(str) > 0 {
+		ep.blessingNames = strings.Split(str, blessingsSeparator)
+	}
+	return ep, nil
+}
+
+// parseCommon parses the fields that versions 6 and 7 have in common: the
+// protocol, address, routes, routing id and mount table flag.
+func parseCommon(parts []string) (Endpoint, error) {
 	var ep Endpoint
 	if len(parts) < 6 {
 		return ep, errInvalidEndpointString
@@ -124,7 +159,7 @@ func parseV6(parts []string) (Endpoint, error) {
 	if ep.Address, ok = Unescape(parts[2]); !ok {
 		return ep, fmt.Errorf("invalid address: bad escape %s", parts[2])
 	}
-	if len(ep.Address) == 0 {
+	if len(ep.Address) == 0 && !isPathProtocol(ep.Protocol) {
 		ep.Address = net.JoinHostPort("", "0")
 	}
 
@@ -147,13 +182,27 @@ func parseV6(parts []string) (Endpoint, error) {
 	default:
 		return ep, fmt.Errorf("invalid mount table flag (%v)", p)
 	}
-	// Join the remaining and re-split.
-	if str := strings.Join(parts[6:], separator); len(str) > 0 {
-		ep.blessingNames = strings.Split(str, blessingsSeparator)
-	}
 	return ep, nil
 }
 
+// isPathProtocol returns true for the protocols of unix domain sockets,
+// whose addresses are socket paths rather than host:port pairs.  Slashes in
+// such addresses are escaped in endpoint strings, so that they aren't split
+// when the endpoint is part of a name, and an empty address is valid, rather
+// than a shorthand for ":0".
+func isPathProtocol(protocol string) bool {
+	return protocol == "unix" || protocol == "unixpacket"
+}
+
+// escapeAddress escapes the address of an endpoint of the given protocol for
+// an endpoint string.
+func escapeAddress(protocol, address string) string {
+	if isPathProtocol(protocol) {
+		return Escape(address, separator+"/")
+	}
+	return Escape(address, separator)
+}
+
 // WithBlessingNames derives a new endpoint with the given
 // blessing names, but otherwise identical to e.
 func (e Endpoint) WithBlessingNames(names []string) Endpoint {
@@ -187,15 +236,18 @@ func (e Endpoint) IsZero() bool {
 		e.RoutingID == RoutingID{} &&
 		len(e.routes) == 0 &&
 		len(e.blessingNames) == 0 &&
+		len(e.attrs) == 0 &&
 		!e.ServesMountTable
 }
 
 // VersionedString returns a string in the specified format. If the version
 // number is unsupported, the current 'default' version will be used.
+// Attributes are only represented by version 7 and later, so they are
+// dropped from strings of version 6.
 func (e Endpoint) VersionedString(version int) string {
 	// nologcall
 	switch version {
-	case 6:
+	case 6, 7:
 		mt := "s"
 		if e.ServesMountTable {
 			mt = "m"
@@ -206,15 +258,27 @@ func (e Endpoint) VersionedString(version int) string {
 			escaped[i] = Escape(e.routes[i], routeSeparator)
 		}
 		routes := strings.Join(escaped, routeSeparator)
-		return fmt.Sprintf("@6@%s@%s@%s@%s@%s@%s@@",
-			e.Protocol, Escape(e.Address, "@"), routes, e.RoutingID, mt, blessings)
+		address := escapeAddress(e.Protocol, e.Address)
+		if version == 6 {
+			return fmt.Sprintf("@6@%s@%s@%s@%s@%s@%s@@",
+				e.Protocol, address, routes, e.RoutingID, mt, blessings)
+		}
+		return fmt.Sprintf("@7@%s@%s@%s@%s@%s@%s@%s@@",
+			e.Protocol, address, routes, e.RoutingID, mt, formatAttributes(e.attrs), blessings)
 	default:
 		return e.VersionedString(DefaultEndpointVersion)
 	}
 }
 
+// String returns the endpoint in the DefaultEndpointVersion format, unless
+// the endpoint has attributes that the default version can't represent, in
+// which case version 7 is used.  Endpoints without attributes thus remain
+// readable by parsers that only know older versions.
 func (e Endpoint) String() string {
 	//nologcall
+	if len(e.attrs) > 0 && DefaultEndpointVersion < 7 {
+		return e.VersionedString(7)
+	}
 	return e.VersionedString(DefaultEndpointVersion)
 }
 
@@ -254,6 +318,10 @@ func (a addr) Network() string {
 // Version 6 is the current version for RPC:
 //   @6@<protocol>@<address>@<route>[,<route>]...@<routingid>@m|s@[<blessing>[,<blessing>]...]@@
 //
+// Version 7 adds typed attributes, sorted by key:
+//   @7@<protocol>@<address>@<route>[,<route>]...@<routingid>@m|s@[<key>=<type>:<value>[,<key>=<type>:<value>]...]@[<blessing>[,<blessing>]...]@@
+// where type is s (string), i (int64), b (bool) or f (float64).
+//
 // Along with Network, this method ensures that Endpoint implements net.Addr.
 func (a addr) String() string {
 	return a.address
@@ -211,3 +211,145 @@ func TestEscapeEndpoint(t *testing.T) {
 		}
 	}
 }
+
+func TestEscapePathProtocols(t *testing.T) {
+	for _, test := range []struct {
+		ep   Endpoint
+		want string
+	}{
+		{Endpoint{Protocol: "unix", Address: "/tmp/sock"}, "@6@unix@%2Ftmp%2Fsock@@00000000000000000000000000000000@s@@@"},
+		{Endpoint{Protocol: "unix"}, "@6@unix@@@00000000000000000000000000000000@s@@@"},
+		{Endpoint{Protocol: "unixpacket", Address: "/tmp/sock"}, "@6@unixpacket@%2Ftmp%2Fsock@@00000000000000000000000000000000@s@@@"},
+		{Endpoint{Protocol: "ws", Address: "h:1/path"}, "@6@ws@h:1/path@@00000000000000000000000000000000@s@@@"},
+	} {
+		if got := test.ep.VersionedString(6); got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+		if ep, err := ParseEndpoint(test.want); err != nil || ep.Address != test.ep.Address {
+			t.Errorf("%q: got (%q, %v), want %q", test.want, ep.Address, err, test.ep.Address)
+		}
+	}
+}
+
+func TestEndpointAttributes(t *testing.T) {
+	ep := Endpoint{
+		Protocol:      "tcp",
+		Address:       "batman.com:2345",
+		RoutingID:     FixedRoutingID(0xba77),
+		blessingNames: []string{"dev.v.io:foo@bar.com"},
+	}
+	ep, err := ep.WithAttributes(
+		Attribute{"zone", "us-east1-b"},
+		Attribute{"weight", 10},
+		Attribute{"tls", true},
+		Attribute{"load", 0.25},
+		Attribute{"note", "a@b/c,d=e:f%"},
+		Attribute{"offset", int64(-3)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "@7@tcp@batman.com:2345@@0000000000000000000000000000ba77@s@" +
+		"load=f:0.25,note=s:a%40b%2Fc%2Cd%3De:f%25,offset=i:-3,tls=b:true,weight=i:10,zone=s:us-east1-b" +
+		"@dev.v.io:foo@bar.com@@"
+	if got := ep.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	parsed, err := ParseEndpoint(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, ep) {
+		t.Errorf("got %#v, want %#v", parsed, ep)
+	}
+	if v, ok := parsed.IntAttribute("weight"); !ok || v != 10 {
+		t.Errorf("got weight (%v, %v)", v, ok)
+	}
+	if v, ok := parsed.StringAttribute("zone"); !ok || v != "us-east1-b" {
+		t.Errorf("got zone (%v, %v)", v, ok)
+	}
+	if v, ok := parsed.BoolAttribute("tls"); !ok || !v {
+		t.Errorf("got tls (%v, %v)", v, ok)
+	}
+	if v, ok := parsed.FloatAttribute("load"); !ok || v != 0.25 {
+		t.Errorf("got load (%v, %v)", v, ok)
+	}
+	if _, ok := parsed.StringAttribute("weight"); ok {
+		t.Errorf("weight is not a string attribute")
+	}
+	if _, ok := parsed.Attribute("region"); ok {
+		t.Errorf("unexpected attribute region")
+	}
+
+	// Version 6 strings drop the attributes, so that older parsers can read
+	// them, and endpoints without attributes use the default version.
+	v6 := ep.VersionedString(6)
+	if got, want := v6, "@6@tcp@batman.com:2345@@0000000000000000000000000000ba77@s@dev.v.io:foo@bar.com@@"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	plain, err := ParseEndpoint(v6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := plain.String(); got != v6 {
+		t.Errorf("got %q, want %q", got, v6)
+	}
+	if len(plain.Attributes()) != 0 {
+		t.Errorf("unexpected attributes %v", plain.Attributes())
+	}
+	// Version 7 strings without attributes are parsed too.
+	v7, err := ParseEndpoint(plain.VersionedString(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v7, plain) {
+		t.Errorf("got %#v, want %#v", v7, plain)
+	}
+	cleared, err := ep.WithAttributes()
+	if err != nil || !reflect.DeepEqual(cleared, plain) {
+		t.Errorf("got (%#v, %v), want %#v", cleared, err, plain)
+	}
+}
+
+func TestInvalidEndpointAttributes(t *testing.T) {
+	for _, attrs := range [][]Attribute{
+		{{"", "x"}},
+		{{"a b", "x"}},
+		{{"a=b", "x"}},
+		{{"a", "x"}, {"a", "y"}},
+		{{"a", []string{"x"}}},
+		{{"a", uint64(1)}},
+		{{"a", nil}},
+	} {
+		if _, err := (Endpoint{}).WithAttributes(attrs...); err == nil {
+			t.Errorf("%v: expected an error", attrs)
+		}
+	}
+	const prefix = "@7@tcp@batman.com:2345@@0000000000000000000000000000ba77@s@"
+	for _, attrs := range []string{
+		"a",
+		"a=",
+		"a=x",
+		"a=s",
+		"=s:x",
+		"a b=s:x",
+		"a=q:x",
+		"a=i:x",
+		"a=i:1.5",
+		"a=i:99999999999999999999",
+		"a=b:yes",
+		"a=f:x",
+		"a=f:NaN",
+		"a=s:%zz",
+		"a=s:x,",
+		"b=s:x,a=s:y",
+		"a=s:x,a=s:y",
+	} {
+		if ep, err := ParseEndpoint(prefix + attrs + "@@@"); err == nil {
+			t.Errorf("%q: expected an error, got %v", attrs, ep)
+		}
+	}
+	if _, err := ParseEndpoint("@7@tcp@batman.com:2345@@0000000000000000000000000000ba77@@"); err == nil {
+		t.Errorf("expected an error for a truncated endpoint")
+	}
+}
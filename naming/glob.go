@@ -0,0 +1,81 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package naming
+
+import (
+	"strings"
+	"time"
+)
+
+// ApplyOpt sets the limit specified by opt, and returns true, if opt is a
+// GlobMaxResults, GlobMaxDepth, GlobStartAfter or GlobTimeBudget option.
+// Namespace and rpc client implementations use it to gather the limits to
+// send with a Glob.
+func (l *GlobLimits) ApplyOpt(opt interface{}) bool {
+	switch v := opt.(type) {
+	case GlobMaxResults:
+		l.MaxResults = uint64(v)
+	case GlobMaxDepth:
+		l.MaxDepth = uint32(v)
+	case GlobStartAfter:
+		l.StartAfter = string(v)
+	case GlobTimeBudget:
+		l.TimeBudget = time.Duration(v)
+	default:
+		return false
+	}
+	return true
+}
+
+// Restrict returns the limits that satisfy both l and o: the smaller of each
+// of their limits, and the later of their StartAfter names.
+func (l GlobLimits) Restrict(o GlobLimits) GlobLimits {
+	if o.MaxResults != 0 && (l.MaxResults == 0 || o.MaxResults < l.MaxResults) {
+		l.MaxResults = o.MaxResults
+	}
+	if o.MaxDepth != 0 && (l.MaxDepth == 0 || o.MaxDepth < l.MaxDepth) {
+		l.MaxDepth = o.MaxDepth
+	}
+	if CompareGlobNames(o.StartAfter, l.StartAfter) > 0 {
+		l.StartAfter = o.StartAfter
+	}
+	if o.TimeBudget != 0 && (l.TimeBudget == 0 || o.TimeBudget < l.TimeBudget) {
+		l.TimeBudget = o.TimeBudget
+	}
+	return l
+}
+
+// CompareGlobNames returns -1, 0 or +1 depending on whether a comes before,
+// is the same as, or comes after b in the order in which Glob returns
+// matches: depth-first, with the children of each name in lexicographic
+// order.  The empty name, the receiver of the Glob, comes first.
+func CompareGlobNames(a, b string) int {
+	a, b = strings.Trim(a, "/"), strings.Trim(b, "/")
+	for a != "" && b != "" {
+		ea, ra := splitFirst(a)
+		eb, rb := splitFirst(b)
+		if ea != eb {
+			if ea < eb {
+				return -1
+			}
+			return +1
+		}
+		a, b = ra, rb
+	}
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return -1
+	}
+	return +1
+}
+
+func splitFirst(name string) (string, string) {
+	if i := strings.Index(name, "/"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return name, ""
+}
@@ -0,0 +1,84 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package naming_test
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"v.io/v23/naming"
+	"v.io/v23/vom"
+)
+
+type byGlobOrder []string
+
+func (s byGlobOrder) Len() int           { return len(s) }
+func (s byGlobOrder) Less(i, j int) bool { return naming.CompareGlobNames(s[i], s[j]) < 0 }
+func (s byGlobOrder) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func TestCompareGlobNames(t *testing.T) {
+	names := []string{"b", "a-x", "a/c", "", "a/b/c", "a", "a/b", "ab"}
+	sort.Sort(byGlobOrder(names))
+	want := []string{"", "a", "a/b", "a/b/c", "a/c", "a-x", "ab", "b"}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("got %q, want %q", names, want)
+		}
+	}
+	if got := naming.CompareGlobNames("/a/b/", "a/b"); got != 0 {
+		t.Errorf("got %d, want 0", got)
+	}
+}
+
+func TestGlobLimits(t *testing.T) {
+	var l naming.GlobLimits
+	for _, opt := range []naming.NamespaceOpt{
+		naming.GlobMaxResults(10),
+		naming.GlobMaxDepth(3),
+		naming.GlobStartAfter("a/b"),
+		naming.GlobTimeBudget(time.Second),
+		naming.IsLeaf(true),
+	} {
+		_, isLeaf := opt.(naming.IsLeaf)
+		if got := l.ApplyOpt(opt); got == isLeaf {
+			t.Errorf("ApplyOpt(%v): got %v", opt, got)
+		}
+	}
+	want := naming.GlobLimits{MaxResults: 10, MaxDepth: 3, StartAfter: "a/b", TimeBudget: time.Second}
+	if l != want {
+		t.Errorf("got %+v, want %+v", l, want)
+	}
+
+	server := naming.GlobLimits{MaxResults: 5, StartAfter: "a", TimeBudget: time.Minute}
+	want = naming.GlobLimits{MaxResults: 5, MaxDepth: 3, StartAfter: "a/b", TimeBudget: time.Second}
+	if got := l.Restrict(server); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := server.Restrict(l); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// The limits and the truncated reply round-trip through vom.
+	var reply naming.GlobReply = naming.GlobReplyTruncated{naming.GlobTruncated{ResumeToken: "a/b"}}
+	b, err := vom.Encode(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded naming.GlobReply
+	if err := vom.Decode(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := decoded.(naming.GlobReplyTruncated); !ok || got.Value.ResumeToken != "a/b" {
+		t.Errorf("got %#v, want %#v", decoded, reply)
+	}
+	if b, err = vom.Encode(want); err != nil {
+		t.Fatal(err)
+	}
+	var limits naming.GlobLimits
+	if err := vom.Decode(b, &limits); err != nil || limits != want {
+		t.Errorf("got (%+v, %v), want %+v", limits, err, want)
+	}
+}
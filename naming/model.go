@@ -4,7 +4,11 @@
 
 package naming
 
-import "v.io/v23/verror"
+import (
+	"time"
+
+	"v.io/v23/verror"
+)
 
 const (
 	pkgPath         = "v.io/v23/naming"
@@ -40,6 +44,26 @@ type DisableCache bool
 
 func (DisableCache) CacheCtl() {}
 
+// MaxCacheEntries bounds the number of entries of the resolution cache.  The
+// least recently used entries are evicted first.  Zero means no bound.
+type MaxCacheEntries int
+
+func (MaxCacheEntries) CacheCtl() {}
+
+// NegativeCacheTTL is how long the resolution cache remembers that a name
+// doesn't exist, i.e. that resolving it failed with ErrNoSuchName,
+// ErrNoSuchNameRoot or verror.ErrNoExist.  Zero disables negative caching.
+type NegativeCacheTTL time.Duration
+
+func (NegativeCacheTTL) CacheCtl() {}
+
+// StaleWhileRevalidate is how long the resolution cache keeps using an entry
+// after it has expired, while it is resolved again in the background.  Zero
+// means that expired entries are resolved again before they are used.
+type StaleWhileRevalidate time.Duration
+
+func (StaleWhileRevalidate) CacheCtl() {}
+
 // NamespaceOpt is the interface for all Namespace options.
 type NamespaceOpt interface {
 	NSOpt()
@@ -61,6 +85,34 @@ type IsLeaf bool
 
 func (IsLeaf) NSOpt() {}
 
+// GlobMaxResults bounds the number of matches returned by a Glob.  It sets
+// GlobLimits.MaxResults.
+type GlobMaxResults uint64
+
+func (GlobMaxResults) NSOpt()      {}
+func (GlobMaxResults) RPCCallOpt() {}
+
+// GlobMaxDepth bounds the number of name elements that a Glob descends to.
+// It sets GlobLimits.MaxDepth.
+type GlobMaxDepth uint32
+
+func (GlobMaxDepth) NSOpt()      {}
+func (GlobMaxDepth) RPCCallOpt() {}
+
+// GlobStartAfter resumes a Glob after the given name, usually the
+// ResumeToken of a GlobTruncated reply.  It sets GlobLimits.StartAfter.
+type GlobStartAfter string
+
+func (GlobStartAfter) NSOpt()      {}
+func (GlobStartAfter) RPCCallOpt() {}
+
+// GlobTimeBudget bounds the time that servers may spend on a Glob.  It sets
+// GlobLimits.TimeBudget.
+type GlobTimeBudget time.Duration
+
+func (GlobTimeBudget) NSOpt()      {}
+func (GlobTimeBudget) RPCCallOpt() {}
+
 // BlessingOpt is used to add a blessing name to the endpoint.
 type BlessingOpt string
 
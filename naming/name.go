@@ -0,0 +1,173 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package naming
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Name is the structured form of an object name, as parsed by ParseName.
+//
+// A rooted name starts with the address of a server, in endpoint or
+// host:port format, followed by the components of the name relative to that
+// server.  A relative name has no address:
+//
+//   /@6@tcp@h:1@@...@m@@@/a/b   Rooted, Address "@6@tcp@h:1@@...@m@@@", Components [a b]
+//   /h:1/a%2Fb                  Rooted, Address "h:1", Components [a/b]
+//   a/b                         Components [a b]
+//   /                           Rooted
+//
+// Components are unescaped: a slash within a component is represented by
+// %2F in the string form of the name, see EncodeAsNameElement.
+type Name struct {
+	// Rooted is true for names that start with a '/'.
+	Rooted bool
+	// Address is the address of the server of a rooted name, empty
+	// otherwise.
+	Address string
+	// Components are the unescaped components of the name relative to the
+	// server.
+	Components []string
+}
+
+// NameError is the error returned by ParseName and Name.Validate.
+type NameError struct {
+	// Name is the string that was parsed, or the string form of the invalid
+	// Name.
+	Name string
+	// Component is the index of the invalid component, or -1 if the error
+	// isn't about a component.
+	Component int
+	// Reason describes the error.
+	Reason string
+}
+
+func (e *NameError) Error() string {
+	if e.Component >= 0 {
+		return fmt.Sprintf("invalid name %q: component %d: %s", e.Name, e.Component, e.Reason)
+	}
+	return fmt.Sprintf("invalid name %q: %s", e.Name, e.Reason)
+}
+
+// ParseName parses an object name.  Like the other functions of this
+// package, it ignores repeated and trailing slashes.  It returns a *NameError
+// if the address of a rooted name is malformed, or if a component has an
+// invalid escape sequence.
+func ParseName(name string) (Name, error) {
+	var n Name
+	suffix := Clean(name)
+	if Rooted(suffix) {
+		n.Rooted = true
+		n.Address, suffix = SplitAddressName(suffix)
+		if err := validateAddress(n.Address); err != "" {
+			return Name{}, &NameError{name, -1, err}
+		}
+	}
+	if suffix == "" {
+		return n, nil
+	}
+	for i, c := range strings.Split(suffix, "/") {
+		d, ok := DecodeFromNameElement(c)
+		if !ok {
+			return Name{}, &NameError{name, i, fmt.Sprintf("invalid escape sequence in %q", c)}
+		}
+		n.Components = append(n.Components, d)
+	}
+	return n, nil
+}
+
+// validateAddress returns a description of what is wrong with the address
+// of a rooted name, or "" if it is valid.
+func validateAddress(address string) string {
+	switch {
+	case address == "":
+		return ""
+	case strings.HasPrefix(address, "@"):
+		if !strings.HasSuffix(address, suffix) {
+			return fmt.Sprintf("endpoint %q doesn't end with %q", address, suffix)
+		}
+		if _, err := ParseEndpoint(address); err != nil {
+			return fmt.Sprintf("invalid endpoint %q: %v", address, err)
+		}
+	case strings.HasPrefix(address, "("):
+		i := strings.Index(address, ")@")
+		if i < 0 || i+2 == len(address) {
+			return fmt.Sprintf("invalid address %q: want (<blessing>)@<host:port>", address)
+		}
+	case strings.Contains(address, "/"):
+		return fmt.Sprintf("invalid address %q: contains a '/'", address)
+	}
+	return ""
+}
+
+// Validate returns a *NameError if n has no string form that ParseName
+// parses back to n: if it has an empty component, an invalid address, or an
+// address without being rooted, or if it is rooted without an address but
+// has components.
+func (n Name) Validate() error {
+	if !n.Rooted && n.Address != "" {
+		return &NameError{n.String(), -1, "name with an address must be rooted"}
+	}
+	if n.Rooted && n.Address == "" && len(n.Components) > 0 {
+		return &NameError{n.String(), -1, "rooted name without an address"}
+	}
+	if strings.HasPrefix(n.Address, "/") {
+		return &NameError{n.String(), -1, fmt.Sprintf("invalid address %q: starts with a '/'", n.Address)}
+	}
+	if err := validateAddress(n.Address); err != "" {
+		return &NameError{n.String(), -1, err}
+	}
+	for i, c := range n.Components {
+		if c == "" {
+			return &NameError{n.String(), i, "empty component"}
+		}
+	}
+	return nil
+}
+
+// String returns the string form of n.  It is canonical: names that are
+// equal have the same string form, and ParseName(n.String()) is equal to n
+// if n is valid.
+func (n Name) String() string {
+	suffix := n.Suffix()
+	switch {
+	case !n.Rooted:
+		return suffix
+	case n.Address == "":
+		return Clean("/" + suffix)
+	}
+	return JoinAddressName(n.Address, suffix)
+}
+
+// Suffix returns the name relative to the server, with escaped components.
+func (n Name) Suffix() string {
+	escaped := make([]string, len(n.Components))
+	for i, c := range n.Components {
+		escaped[i] = EncodeAsNameElement(c)
+	}
+	return strings.Join(escaped, "/")
+}
+
+// Endpoint returns the endpoint of the server of a rooted name.  Addresses
+// in host:port format are converted as by ParseEndpoint.
+func (n Name) Endpoint() (Endpoint, error) {
+	if n.Address == "" {
+		return Endpoint{}, &NameError{n.String(), -1, "name has no address"}
+	}
+	return ParseEndpoint(n.Address)
+}
+
+// IsReserved returns true if a component of n starts with
+// ReservedNamePrefix.  Such components are used by the framework, and not by
+// applications.
+func (n Name) IsReserved() bool {
+	for _, c := range n.Components {
+		if IsReserved(c) {
+			return true
+		}
+	}
+	return false
+}
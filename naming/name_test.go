@@ -0,0 +1,199 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package naming
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+func TestParseName(t *testing.T) {
+	ep := "@6@tcp@h:1@@0000000000000000000000000000ba77@m@@@"
+	unix := "@6@unix@%2Ftmp%2Fsock@@0000000000000000000000000000ba77@s@@@"
+	cases := []struct {
+		input, output string
+		want          Name
+	}{
+		{"", "", Name{}},
+		{"a", "a", Name{Components: []string{"a"}}},
+		{"a//b/", "a/b", Name{Components: []string{"a", "b"}}},
+		{"a%2Fb/%25", "a%2Fb/%25", Name{Components: []string{"a/b", "%"}}},
+		{"a%41", "aA", Name{Components: []string{"aA"}}},
+		{"/", "/", Name{Rooted: true}},
+		{"//", "/", Name{Rooted: true}},
+		{"/h:1", "/h:1", Name{Rooted: true, Address: "h:1"}},
+		{"/h:1/a/__debug", "/h:1/a/__debug", Name{Rooted: true, Address: "h:1", Components: []string{"a", "__debug"}}},
+		{"/(dev.v.io:foo)@h:1/a", "/(dev.v.io:foo)@h:1/a", Name{Rooted: true, Address: "(dev.v.io:foo)@h:1", Components: []string{"a"}}},
+		{"/" + ep, "/" + ep, Name{Rooted: true, Address: ep}},
+		{"/" + ep + "/a/b", "/" + ep + "/a/b", Name{Rooted: true, Address: ep, Components: []string{"a", "b"}}},
+		{"/" + unix + "//x%2Fy", "/" + unix + "/x%2Fy", Name{Rooted: true, Address: unix, Components: []string{"x/y"}}},
+	}
+	for _, c := range cases {
+		n, err := ParseName(c.input)
+		if err != nil {
+			t.Errorf("%q: %v", c.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(n, c.want) {
+			t.Errorf("%q: got %#v, want %#v", c.input, n, c.want)
+		}
+		if got := n.String(); got != c.output {
+			t.Errorf("%q: got string %q, want %q", c.input, got, c.output)
+		}
+		if err := n.Validate(); err != nil {
+			t.Errorf("%q: %v", c.input, err)
+		}
+	}
+
+	n, _ := ParseName("/" + unix + "/a")
+	if e, err := n.Endpoint(); err != nil || e.Address != "/tmp/sock" {
+		t.Errorf("got endpoint (%v, %v)", e, err)
+	}
+	if !n.Rooted || n.IsReserved() {
+		t.Errorf("unexpected %#v", n)
+	}
+	n, _ = ParseName("/h:1/a/__debug/stats")
+	if !n.IsReserved() {
+		t.Errorf("%v is reserved", n)
+	}
+	if _, err := (Name{Components: []string{"a"}}).Endpoint(); err == nil {
+		t.Errorf("expected an error for the endpoint of a relative name")
+	}
+}
+
+func TestParseNameErrors(t *testing.T) {
+	cases := []struct {
+		input     string
+		component int
+	}{
+		{"a/b%2", 1},
+		{"%zz", 0},
+		{"/h:1/a/b/c%", 2},
+		{"/@6@tcp@h:1@@xyz@m@@@/a", -1},
+		{"/@6@tcp@h:1@@0000000000000000000000000000ba77@m@", -1},
+		{"/@9@tcp@h:1@@0000000000000000000000000000ba77@m@@@", -1},
+		{"/(dev.v.io:foo)@", -1},
+		{"/(dev.v.io:foo/a", -1},
+	}
+	for _, c := range cases {
+		_, err := ParseName(c.input)
+		nerr, ok := err.(*NameError)
+		if !ok {
+			t.Errorf("%q: got error %v, want a *NameError", c.input, err)
+			continue
+		}
+		if nerr.Name != c.input || nerr.Component != c.component {
+			t.Errorf("%q: got %#v, want component %d", c.input, nerr, c.component)
+		}
+	}
+
+	for _, n := range []Name{
+		{Address: "h:1"},
+		{Rooted: true, Components: []string{"a"}},
+		{Rooted: true, Address: "/h:1"},
+		{Rooted: true, Address: "h:1/a"},
+		{Components: []string{"a", ""}},
+	} {
+		if err := n.Validate(); err == nil {
+			t.Errorf("%#v: expected an error", n)
+		}
+	}
+}
+
+// randomName generates valid Names for testing/quick.
+type randomName Name
+
+const componentChars = "abc_-.:@%/()é"
+
+func randomString(r *rand.Rand, chars string, max int) string {
+	runes := []rune(chars)
+	s := make([]rune, 1+r.Intn(max))
+	for i := range s {
+		s[i] = runes[r.Intn(len(runes))]
+	}
+	return string(s)
+}
+
+func (randomName) Generate(r *rand.Rand, size int) reflect.Value {
+	var n Name
+	switch r.Intn(5) {
+	case 0:
+	case 1:
+		n.Address = randomString(r, "abc.", 8) + ":" + randomString(r, "0123456789", 5)
+	case 2:
+		n.Address = "(" + randomString(r, "abc.:", 8) + ")@" + randomString(r, "abc", 5) + ":1"
+	case 3:
+		ep := Endpoint{Protocol: "unix", Address: "/" + randomString(r, "ab/@%", 10), RoutingID: FixedRoutingID(r.Uint64())}
+		n.Address = ep.String()
+	case 4:
+		ep := Endpoint{Protocol: "tcp", Address: "h:1", RoutingID: FixedRoutingID(r.Uint64()), ServesMountTable: r.Intn(2) == 0}
+		ep = ep.WithBlessingNames([]string{randomString(r, "abc:", 8)})
+		n.Address = ep.String()
+	}
+	n.Rooted = n.Address != ""
+	if n.Rooted || r.Intn(4) > 0 {
+		for i := r.Intn(size%5 + 1); i > 0; i-- {
+			n.Components = append(n.Components, randomString(r, componentChars, 6))
+		}
+	} else {
+		n.Rooted = true
+	}
+	return reflect.ValueOf(randomName(n))
+}
+
+func TestNameProperties(t *testing.T) {
+	config := &quick.Config{MaxCount: 2000}
+	check := func(name string, f interface{}) {
+		if err := quick.Check(f, config); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	check("Parse(String(x)) == x", func(x randomName) bool {
+		n := Name(x)
+		got, err := ParseName(n.String())
+		return n.Validate() == nil && err == nil && reflect.DeepEqual(got, n)
+	})
+	check("String is clean", func(x randomName) bool {
+		s := Name(x).String()
+		return Clean(s) == s
+	})
+	check("Rooted", func(x randomName) bool {
+		n := Name(x)
+		return Rooted(n.String()) == n.Rooted
+	})
+	check("SplitAddressName", func(x randomName) bool {
+		n := Name(x)
+		address, suffix := SplitAddressName(n.String())
+		return address == n.Address && suffix == n.Suffix()
+	})
+	check("JoinAddressName", func(x randomName) bool {
+		n := Name(x)
+		if n.Rooted && n.Address == "" {
+			return n.String() == "/"
+		}
+		return JoinAddressName(n.Address, n.Suffix()) == n.String()
+	})
+	check("Join", func(x randomName) bool {
+		n := Name(x)
+		prefix := ""
+		if n.Rooted {
+			prefix = "/" + n.Address
+		}
+		var escaped []string
+		for _, c := range n.Components {
+			escaped = append(escaped, EncodeAsNameElement(c))
+		}
+		return Join(append([]string{prefix}, escaped...)...) == n.String() || (n.Rooted && n.Address == "")
+	})
+	check("TrimSuffix", func(x randomName) bool {
+		n := Name(x)
+		if !n.Rooted || len(n.Components) == 0 {
+			return true
+		}
+		return TrimSuffix(n.String(), n.Suffix()) == "/"+n.Address
+	})
+}
@@ -9,6 +9,7 @@ package naming
 
 import (
 	"fmt"
+	"time"
 	"v.io/v23/vdl"
 	vdltime "v.io/v23/vdlroot/time"
 	"v.io/v23/verror"
@@ -406,13 +407,19 @@ type (
 	GlobReplyEntry struct{ Value MountEntry }
 	// GlobReplyError represents field Error of the GlobReply union type.
 	GlobReplyError struct{ Value GlobError }
+	// GlobReplyTruncated represents field Truncated of the GlobReply union type.
+	//
+	// Truncated is the last reply of a Glob that was stopped by one of its
+	// GlobLimits before all the matches were returned.
+	GlobReplyTruncated struct{ Value GlobTruncated }
 	// __GlobReplyReflect describes the GlobReply union type.
 	__GlobReplyReflect struct {
 		Name  string `vdl:"v.io/v23/naming.GlobReply"`
 		Type  GlobReply
 		Union struct {
-			Entry GlobReplyEntry
-			Error GlobReplyError
+			Entry     GlobReplyEntry
+			Error     GlobReplyError
+			Truncated GlobReplyTruncated
 		}
 	}
 )
@@ -427,6 +434,11 @@ func (x GlobReplyError) Interface() interface{}          { return x.Value }
 func (x GlobReplyError) Name() string                    { return "Error" }
 func (x GlobReplyError) __VDLReflect(__GlobReplyReflect) {}
 
+func (x GlobReplyTruncated) Index() int                      { return 2 }
+func (x GlobReplyTruncated) Interface() interface{}          { return x.Value }
+func (x GlobReplyTruncated) Name() string                    { return "Truncated" }
+func (x GlobReplyTruncated) __VDLReflect(__GlobReplyReflect) {}
+
 func (x GlobReplyEntry) VDLIsZero() bool {
 	return x.Value.VDLIsZero()
 }
@@ -435,6 +447,10 @@ func (x GlobReplyError) VDLIsZero() bool {
 	return false
 }
 
+func (x GlobReplyTruncated) VDLIsZero() bool {
+	return false
+}
+
 func (x GlobReplyEntry) VDLWrite(enc vdl.Encoder) error {
 	if err := enc.StartValue(__VDLType_union_7); err != nil {
 		return err
@@ -467,6 +483,22 @@ func (x GlobReplyError) VDLWrite(enc vdl.Encoder) error {
 	return enc.FinishValue()
 }
 
+func (x GlobReplyTruncated) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_union_7); err != nil {
+		return err
+	}
+	if err := enc.NextField(2); err != nil {
+		return err
+	}
+	if err := x.Value.VDLWrite(enc); err != nil {
+		return err
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
 func VDLReadGlobReply(dec vdl.Decoder, x *GlobReply) error {
 	if err := dec.StartValue(__VDLType_union_7); err != nil {
 		return err
@@ -499,6 +531,12 @@ func VDLReadGlobReply(dec vdl.Decoder, x *GlobReply) error {
 			return err
 		}
 		*x = field
+	case 2:
+		var field GlobReplyTruncated
+		if err := field.Value.VDLRead(dec); err != nil {
+			return err
+		}
+		*x = field
 	}
 	switch index, err := dec.NextField(); {
 	case err != nil:
@@ -632,6 +670,209 @@ func VDLReadGlobChildrenReply(dec vdl.Decoder, x *GlobChildrenReply) error {
 	return dec.FinishValue()
 }
 
+// GlobTruncated describes why a Glob was stopped before all the matches were
+// returned, and how to resume it.
+type GlobTruncated struct {
+	// Reason is the error describing the limit that was reached.
+	Reason error
+	// ResumeToken is the name of the last match that was returned, relative
+	// to the receiver of the Glob.  Passing it as GlobLimits.StartAfter
+	// returns the remaining matches.
+	ResumeToken string
+}
+
+func (GlobTruncated) __VDLReflect(struct {
+	Name string `vdl:"v.io/v23/naming.GlobTruncated"`
+}) {
+}
+
+func (x GlobTruncated) VDLIsZero() bool {
+	return x == GlobTruncated{}
+}
+
+func (x GlobTruncated) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_9); err != nil {
+		return err
+	}
+	if x.Reason != nil {
+		if err := enc.NextField(0); err != nil {
+			return err
+		}
+		if err := verror.VDLWrite(enc, x.Reason); err != nil {
+			return err
+		}
+	}
+	if x.ResumeToken != "" {
+		if err := enc.NextFieldValueString(1, vdl.StringType, x.ResumeToken); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *GlobTruncated) VDLRead(dec vdl.Decoder) error {
+	*x = GlobTruncated{}
+	if err := dec.StartValue(__VDLType_struct_9); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_9 {
+			index = __VDLType_struct_9.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			if err := verror.VDLRead(dec, &x.Reason); err != nil {
+				return err
+			}
+		case 1:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.ResumeToken = value
+			}
+		}
+	}
+}
+
+// GlobLimits bounds the results of a Glob.  Zero values mean no limit.
+//
+// Matches are returned in depth-first order, with the children of each
+// name in lexicographic order, so that a Glob can be resumed with
+// StartAfter.
+type GlobLimits struct {
+	// MaxResults is the maximum number of matches to return.
+	MaxResults uint64
+	// MaxDepth is the maximum number of name elements, below the receiver
+	// of the Glob, that the Glob descends to.
+	MaxDepth uint32
+	// StartAfter, if non-empty, skips the matches up to and including the
+	// given name, relative to the receiver of the Glob.
+	StartAfter string
+	// TimeBudget is the time that the server may spend on the Glob.
+	TimeBudget time.Duration
+}
+
+func (GlobLimits) __VDLReflect(struct {
+	Name string `vdl:"v.io/v23/naming.GlobLimits"`
+}) {
+}
+
+func (x GlobLimits) VDLIsZero() bool {
+	return x == GlobLimits{}
+}
+
+func (x GlobLimits) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_10); err != nil {
+		return err
+	}
+	if x.MaxResults != 0 {
+		if err := enc.NextFieldValueUint(0, vdl.Uint64Type, x.MaxResults); err != nil {
+			return err
+		}
+	}
+	if x.MaxDepth != 0 {
+		if err := enc.NextFieldValueUint(1, vdl.Uint32Type, uint64(x.MaxDepth)); err != nil {
+			return err
+		}
+	}
+	if x.StartAfter != "" {
+		if err := enc.NextFieldValueString(2, vdl.StringType, x.StartAfter); err != nil {
+			return err
+		}
+	}
+	if x.TimeBudget != 0 {
+		if err := enc.NextField(3); err != nil {
+			return err
+		}
+		var wire vdltime.Duration
+		if err := vdltime.DurationFromNative(&wire, x.TimeBudget); err != nil {
+			return err
+		}
+		if err := wire.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *GlobLimits) VDLRead(dec vdl.Decoder) error {
+	*x = GlobLimits{}
+	if err := dec.StartValue(__VDLType_struct_10); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_10 {
+			index = __VDLType_struct_10.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			switch value, err := dec.ReadValueUint(64); {
+			case err != nil:
+				return err
+			default:
+				x.MaxResults = value
+			}
+		case 1:
+			switch value, err := dec.ReadValueUint(32); {
+			case err != nil:
+				return err
+			default:
+				x.MaxDepth = uint32(value)
+			}
+		case 2:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.StartAfter = value
+			}
+		case 3:
+			var wire vdltime.Duration
+			if err := wire.VDLRead(dec); err != nil {
+				return err
+			}
+			if err := vdltime.DurationToNative(wire, &x.TimeBudget); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 //////////////////////////////////////////////////
 // Const definitions
 
@@ -641,14 +882,17 @@ const Leaf = MountFlag(4)    // Leaf means that the target server is a leaf.
 
 // Hold type definitions in package-level variables, for better performance.
 var (
-	__VDLType_uint32_1 *vdl.Type
-	__VDLType_struct_2 *vdl.Type
-	__VDLType_struct_3 *vdl.Type
-	__VDLType_struct_4 *vdl.Type
-	__VDLType_list_5   *vdl.Type
-	__VDLType_struct_6 *vdl.Type
-	__VDLType_union_7  *vdl.Type
-	__VDLType_union_8  *vdl.Type
+	__VDLType_uint32_1  *vdl.Type
+	__VDLType_struct_2  *vdl.Type
+	__VDLType_struct_3  *vdl.Type
+	__VDLType_struct_4  *vdl.Type
+	__VDLType_list_5    *vdl.Type
+	__VDLType_struct_6  *vdl.Type
+	__VDLType_union_7   *vdl.Type
+	__VDLType_union_8   *vdl.Type
+	__VDLType_struct_9  *vdl.Type
+	__VDLType_struct_10 *vdl.Type
+	__VDLType_struct_11 *vdl.Type
 )
 
 var __VDLInitCalled bool
@@ -679,6 +923,8 @@ func __VDLInit() struct{} {
 	vdl.Register((*GlobError)(nil))
 	vdl.Register((*GlobReply)(nil))
 	vdl.Register((*GlobChildrenReply)(nil))
+	vdl.Register((*GlobTruncated)(nil))
+	vdl.Register((*GlobLimits)(nil))
 
 	// Initialize type definitions.
 	__VDLType_uint32_1 = vdl.TypeOf((*MountFlag)(nil))
@@ -689,6 +935,9 @@ func __VDLInit() struct{} {
 	__VDLType_struct_6 = vdl.TypeOf((*GlobError)(nil)).Elem()
 	__VDLType_union_7 = vdl.TypeOf((*GlobReply)(nil))
 	__VDLType_union_8 = vdl.TypeOf((*GlobChildrenReply)(nil))
+	__VDLType_struct_9 = vdl.TypeOf((*GlobTruncated)(nil)).Elem()
+	__VDLType_struct_10 = vdl.TypeOf((*GlobLimits)(nil)).Elem()
+	__VDLType_struct_11 = vdl.TypeOf((*vdltime.Duration)(nil)).Elem()
 
 	return struct{}{}
 }
@@ -53,6 +53,7 @@ package options
 import (
 	"time"
 
+	"v.io/v23/flow"
 	"v.io/v23/naming"
 	"v.io/v23/security"
 )
@@ -108,6 +109,12 @@ type Preresolved struct {
 func (Preresolved) RPCCallOpt() {}
 func (Preresolved) NSOpt()      {}
 
+// WatchPollInterval is the interval at which namespace.T.WatchGlob polls the
+// mount tables that don't support watching.
+type WatchPollInterval time.Duration
+
+func (WatchPollInterval) NSOpt() {}
+
 // Create a server that will be used to serve a MountTable. This server
 // cannot be used for any other purpose.
 type ServesMountTable bool
@@ -145,3 +152,10 @@ func (ChannelTimeout) RPCServerOpt() {}
 type ConnectionTimeout time.Duration
 
 func (ConnectionTimeout) RPCCallOpt() {}
+
+// Priority is the scheduling class of the flow that carries an RPC.  Calls with
+// a higher priority are not starved by concurrent calls of lower priority on
+// the same connection, see flow.Priority.
+type Priority flow.Priority
+
+func (Priority) RPCCallOpt() {}
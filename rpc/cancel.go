@@ -0,0 +1,134 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"fmt"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/verror"
+)
+
+var (
+	errCancelReason          = verror.Register(pkgPath+".errCancelReason", verror.NoRetry, "{1:}{2:}{3}{:_}")
+	errDeadlineExceededAtHop = verror.Register(pkgPath+".errDeadlineExceededAtHop", verror.NoRetry, "{1:}{2:}deadline exceeded at hop {3}.{4}, remaining budget {5}{:_}")
+)
+
+// NewCancelReason returns the wire form of the reason that ctx was canceled,
+// or the zero CancelReason if ctx has not been canceled.  Implementations
+// send it in the Request or Response header when a call is canceled.
+func NewCancelReason(ctx *context.T) CancelReason {
+	reason, ok := ctx.CancelReason()
+	if !ok {
+		return CancelReason{}
+	}
+	wire := CancelReason{
+		Canceler: reason.Canceler,
+		Message:  reason.Message,
+		Error:    reason.Err,
+	}
+	if wire.Message == "" {
+		wire.Message = ctx.Err().Error()
+	}
+	return wire
+}
+
+// ContextReason returns r as a context.CancelReason, suitable for canceling
+// the context of the local end of a call canceled by its peer.
+func (r CancelReason) ContextReason() context.CancelReason {
+	return context.CancelReason{
+		Canceler: r.Canceler,
+		Message:  r.Message,
+		Err:      r.Error,
+	}
+}
+
+// hopsKey is used to store the Hops of a call in the context.
+type hopsKey struct{}
+
+// WithHops returns a new context with hops attached.  Servers attach the
+// Hops of each incoming Request to the context of the call, so that any calls
+// made while handling it extend the chain.
+func WithHops(ctx *context.T, hops []Hop) *context.T {
+	return context.WithValue(ctx, hopsKey{}, hops)
+}
+
+// GetHops returns the Hops attached to ctx, or nil if there are none.
+func GetHops(ctx *context.T) []Hop {
+	hops, _ := ctx.Value(hopsKey{}).([]Hop)
+	return hops
+}
+
+// NewHops returns the Hops to send in the Request of a call to method on
+// name made with ctx: those attached to ctx followed by one describing the
+// call itself.
+func NewHops(ctx *context.T, name, method string) []Hop {
+	hop := Hop{Name: name, Method: method}
+	if deadline, ok := ctx.Deadline(); ok {
+		hop.Budget = deadline.Sub(time.Now())
+	}
+	prev := GetHops(ctx)
+	hops := make([]Hop, len(prev), len(prev)+1)
+	copy(hops, prev)
+	return append(hops, hop)
+}
+
+// NewCancelError returns the error that describes why ctx was canceled, for
+// servers to return when the context of a call is canceled before the call
+// completes.  The error has ID verror.ErrTimeout if the deadline of ctx was
+// exceeded, and verror.ErrCanceled otherwise.  Its subordinate errors give the
+// reason ctx was canceled and, if the deadline was exceeded, the hop attached
+// to ctx whose deadline expired, so that clients can tell which hop or
+// ancestor context caused the cancellation.
+func NewCancelError(ctx *context.T) error {
+	var err error
+	culprit := -1
+	hops := GetHops(ctx)
+	if ctx.Err() == context.DeadlineExceeded {
+		err = verror.New(verror.ErrTimeout, ctx)
+		culprit = expiredHop(hops)
+	} else {
+		err = verror.New(verror.ErrCanceled, ctx)
+	}
+	var subErrs []verror.SubErr
+	if reason, ok := ctx.CancelReason(); ok {
+		subErrs = append(subErrs, verror.SubErr{
+			Name:    "reason",
+			Err:     verror.New(errCancelReason, ctx, reason.String()),
+			Options: verror.Print,
+		})
+	}
+	if culprit >= 0 {
+		hop := hops[culprit]
+		subErrs = append(subErrs, verror.SubErr{
+			Name:    fmt.Sprintf("hop=%d", culprit),
+			Err:     verror.New(errDeadlineExceededAtHop, ctx, hop.Name, hop.Method, hop.Budget.String()),
+			Options: verror.Print,
+		})
+	}
+	if len(subErrs) == 0 {
+		return err
+	}
+	return verror.AddSubErrs(err, ctx, subErrs...)
+}
+
+// expiredHop returns the index of the hop whose deadline expired: the first
+// one that was made with no budget left, or else the one with the smallest
+// budget.  It returns -1 if none of the hops had a deadline.
+func expiredHop(hops []Hop) int {
+	culprit := -1
+	for i, hop := range hops {
+		switch {
+		case hop.Budget == 0:
+			// The hop had no deadline.
+		case hop.Budget < 0:
+			return i
+		case culprit < 0 || hop.Budget < hops[culprit].Budget:
+			culprit = i
+		}
+	}
+	return culprit
+}
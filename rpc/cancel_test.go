@@ -0,0 +1,88 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rpc_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/rpc"
+	"v.io/v23/verror"
+)
+
+func TestHops(t *testing.T) {
+	root, cancel := context.RootContext()
+	defer cancel()
+
+	if hops := rpc.NewHops(root, "a", "Get"); len(hops) != 1 || hops[0] != (rpc.Hop{Name: "a", Method: "Get"}) {
+		t.Errorf("unexpected hops %v", hops)
+	}
+	ctx, _ := context.WithTimeout(root, time.Hour)
+	first := rpc.NewHops(ctx, "a", "Get")
+	ctx = rpc.WithHops(ctx, first)
+	second := rpc.NewHops(ctx, "b", "Put")
+	if len(second) != 2 || second[0] != first[0] || second[1].Name != "b" || second[1].Method != "Put" {
+		t.Errorf("unexpected hops %v", second)
+	}
+	if b := second[1].Budget; b <= 0 || b > time.Hour {
+		t.Errorf("unexpected budget %v", b)
+	}
+	if len(first) != 1 {
+		t.Errorf("NewHops modified the hops attached to the context: %v", first)
+	}
+}
+
+func TestNewCancelError(t *testing.T) {
+	root, cancel := context.RootContext()
+	defer cancel()
+
+	ctx, _ := context.WithTimeout(root, time.Millisecond)
+	ctx = rpc.WithHops(ctx, []rpc.Hop{
+		{Name: "a", Method: "Get", Budget: time.Second},
+		{Name: "b", Method: "Put", Budget: time.Millisecond},
+	})
+	<-ctx.Done()
+	err := rpc.NewCancelError(ctx)
+	if verror.ErrorID(err) != verror.ErrTimeout.ID {
+		t.Errorf("got %v, want ErrTimeout", err)
+	}
+	// Only the hop with the smallest budget is reported.
+	if want := "deadline exceeded at hop b.Put, remaining budget 1ms"; !strings.Contains(err.Error(), want) || strings.Contains(err.Error(), "a.Get") {
+		t.Errorf("%q does not only contain %q", err, want)
+	}
+	if reason := rpc.NewCancelReason(ctx); reason.Message != "deadline exceeded" || reason.Canceler != "" {
+		t.Errorf("unexpected reason %+v", reason)
+	}
+
+	// Unless a hop was made with its budget already exhausted.
+	ctx = rpc.WithHops(ctx, []rpc.Hop{
+		{Name: "a", Method: "Get"},
+		{Name: "b", Method: "Put", Budget: -time.Millisecond},
+		{Name: "c", Method: "Del", Budget: -time.Second},
+	})
+	err = rpc.NewCancelError(ctx)
+	if want := "deadline exceeded at hop b.Put, remaining budget -1ms"; !strings.Contains(err.Error(), want) || strings.Contains(err.Error(), "c.Del") || strings.Contains(err.Error(), "a.Get") {
+		t.Errorf("%q does not only contain %q", err, want)
+	}
+
+	ctx, _, cancelWithReason := context.WithCancelReason(root)
+	ctx = rpc.WithHops(ctx, []rpc.Hop{{Name: "a", Method: "Get"}})
+	cancelWithReason(context.CancelReason{Canceler: "client", Message: "user interrupt"})
+	err = rpc.NewCancelError(ctx)
+	if verror.ErrorID(err) != verror.ErrCanceled.ID {
+		t.Errorf("got %v, want ErrCanceled", err)
+	}
+	if !strings.Contains(err.Error(), "user interrupt by client") || strings.Contains(err.Error(), "hop") {
+		t.Errorf("unexpected error %q", err)
+	}
+	if got, want := rpc.NewCancelReason(ctx).ContextReason(), (context.CancelReason{Canceler: "client", Message: "user interrupt"}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := rpc.NewCancelReason(root); got != (rpc.CancelReason{}) {
+		t.Errorf("got %+v for an uncanceled context", got)
+	}
+}
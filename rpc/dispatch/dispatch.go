@@ -0,0 +1,228 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dispatch provides combinators for building rpc.Dispatchers.
+//
+// Servers with several object trees can be assembled from small dispatchers,
+// e.g.
+//
+//   mux := dispatch.NewMux(nil)
+//   mux.Handle("stats", statsDispatcher)
+//   mux.Handle("apps", dispatch.WithAuthorizer(appsDispatcher, appsAuth))
+//   mux.Handle("config", dispatch.Static(map[string]interface{}{
+//     "global":     globalConfig,
+//     "users/alice": aliceConfig,
+//   }, nil))
+//   server.ServeDispatcher(name, dispatch.Fallback(mux, defaultDispatcher))
+//
+// The dispatchers in this package take part in the namespace on behalf of
+// the objects they route to: names that exist only as the prefix of other
+// names, e.g. "config/users" above, are served by an object that implements
+// rpc.ChildrenGlobber, and objects that do not do their own globbing report
+// the names registered below them as their children.
+package dispatch
+
+import (
+	"sort"
+	"strings"
+
+	"v.io/v23/context"
+	"v.io/v23/naming"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	"v.io/v23/verror"
+)
+
+const pkgPath = "v.io/v23/rpc/dispatch"
+
+var (
+	errDuplicatePrefix = verror.Register(pkgPath+".errDuplicatePrefix", verror.NoRetry, "{1:}{2:} a dispatcher is already registered for prefix {3}{:_}")
+)
+
+// Leaf returns a dispatcher that serves obj, authorized by auth, at the empty
+// suffix only.  Lookups of any other suffix fail with verror.ErrUnknownSuffix.
+func Leaf(obj interface{}, auth security.Authorizer) rpc.Dispatcher {
+	return &leaf{obj, auth}
+}
+
+type leaf struct {
+	obj  interface{}
+	auth security.Authorizer
+}
+
+func (d *leaf) Lookup(ctx *context.T, suffix string) (interface{}, security.Authorizer, error) {
+	if suffix != "" {
+		return nil, nil, verror.New(verror.ErrUnknownSuffix, ctx, suffix)
+	}
+	return d.obj, d.auth, nil
+}
+
+// Tree returns a dispatcher that serves obj, authorized by auth, at every
+// suffix.  The object may use the Suffix method of the call to determine which
+// name it was invoked on.
+func Tree(obj interface{}, auth security.Authorizer) rpc.Dispatcher {
+	return &tree{obj, auth}
+}
+
+type tree struct {
+	obj  interface{}
+	auth security.Authorizer
+}
+
+func (d *tree) Lookup(*context.T, string) (interface{}, security.Authorizer, error) {
+	return d.obj, d.auth, nil
+}
+
+// WithAuthorizer returns a dispatcher that serves the objects of d, but
+// authorizes all calls to them with auth, regardless of the authorizer
+// returned by d.
+func WithAuthorizer(d rpc.Dispatcher, auth security.Authorizer) rpc.Dispatcher {
+	return &withAuthorizer{d, auth}
+}
+
+type withAuthorizer struct {
+	d    rpc.Dispatcher
+	auth security.Authorizer
+}
+
+func (d *withAuthorizer) Lookup(ctx *context.T, suffix string) (interface{}, security.Authorizer, error) {
+	obj, _, err := d.d.Lookup(ctx, suffix)
+	if obj == nil || err != nil {
+		return nil, nil, err
+	}
+	return obj, d.auth, nil
+}
+
+// Fallback returns a dispatcher that looks suffixes up in each of the given
+// dispatchers in turn, returning the first object found.  A dispatcher that
+// returns a nil object, or fails with verror.ErrNoExist or
+// verror.ErrUnknownSuffix, is skipped; any other error ends the lookup.  If no
+// dispatcher has an object for the suffix, the last error, if any, is
+// returned.
+func Fallback(ds ...rpc.Dispatcher) rpc.Dispatcher {
+	return fallback(append([]rpc.Dispatcher(nil), ds...))
+}
+
+type fallback []rpc.Dispatcher
+
+func (ds fallback) Lookup(ctx *context.T, suffix string) (interface{}, security.Authorizer, error) {
+	var lastErr error
+	for _, d := range ds {
+		obj, auth, err := d.Lookup(ctx, suffix)
+		switch {
+		case err == nil && obj != nil:
+			return obj, auth, nil
+		case err == nil:
+		case verror.ErrorID(err) == verror.ErrNoExist.ID, verror.ErrorID(err) == verror.ErrUnknownSuffix.ID:
+			lastErr = err
+		default:
+			return nil, nil, err
+		}
+	}
+	return nil, nil, lastErr
+}
+
+// Static returns a dispatcher that serves a fixed set of objects, keyed by
+// their names relative to the dispatcher, all authorized by auth.  Names that
+// are only prefixes of the names in objects are served by an object that
+// reports the next element of each such name as its children.
+func Static(objects map[string]interface{}, auth security.Authorizer) rpc.Dispatcher {
+	d := &static{objects: make(map[string]interface{}, len(objects)), auth: auth}
+	for name, obj := range objects {
+		name = clean(name)
+		d.objects[name] = obj
+		d.names = append(d.names, name)
+	}
+	return d
+}
+
+type static struct {
+	objects map[string]interface{}
+	names   []string
+	auth    security.Authorizer
+}
+
+func (d *static) Lookup(ctx *context.T, suffix string) (interface{}, security.Authorizer, error) {
+	suffix = clean(suffix)
+	children := childrenOf(d.names, suffix)
+	if obj, ok := d.objects[suffix]; ok {
+		obj, err := withChildren(obj, children)
+		if err != nil {
+			return nil, nil, err
+		}
+		return obj, d.auth, nil
+	}
+	if len(children) > 0 {
+		return rpc.ChildrenGlobberInvoker(children...), d.auth, nil
+	}
+	return nil, nil, nil
+}
+
+// withChildren returns obj, extended to report the given children to Glob if
+// it does not take part in the namespace itself.
+func withChildren(obj interface{}, children []string) (interface{}, error) {
+	if len(children) == 0 {
+		return obj, nil
+	}
+	invoker, ok := obj.(rpc.Invoker)
+	if !ok {
+		var err error
+		if invoker, err = rpc.ReflectInvoker(obj); err != nil {
+			return nil, err
+		}
+	}
+	if invoker.Globber() != nil {
+		return invoker, nil
+	}
+	return &childrenInvoker{invoker, rpc.ChildrenGlobberInvoker(children...).Globber()}, nil
+}
+
+// childrenInvoker is an Invoker whose Globber is replaced.
+type childrenInvoker struct {
+	rpc.Invoker
+	globState *rpc.GlobState
+}
+
+func (i *childrenInvoker) Globber() *rpc.GlobState {
+	return i.globState
+}
+
+// childrenOf returns the sorted, distinct elements that follow name in those
+// of names that are strictly below it.
+func childrenOf(names []string, name string) []string {
+	set := map[string]bool{}
+	for _, n := range names {
+		var rest string
+		switch {
+		case name == "":
+			rest = n
+		case strings.HasPrefix(n, name+"/"):
+			rest = n[len(name)+1:]
+		default:
+			continue
+		}
+		if rest == "" {
+			continue
+		}
+		if i := strings.Index(rest, "/"); i >= 0 {
+			rest = rest[:i]
+		}
+		set[rest] = true
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	children := make([]string, 0, len(set))
+	for c := range set {
+		children = append(children, c)
+	}
+	sort.Strings(children)
+	return children
+}
+
+// clean returns name in a canonical form, without leading, trailing or
+// repeated slashes.
+func clean(name string) string {
+	return strings.Trim(naming.Clean(name), "/")
+}
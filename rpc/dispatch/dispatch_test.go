@@ -0,0 +1,212 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dispatch_test
+
+import (
+	"reflect"
+	"testing"
+
+	"v.io/v23/context"
+	"v.io/v23/glob"
+	"v.io/v23/naming"
+	"v.io/v23/rpc"
+	"v.io/v23/rpc/dispatch"
+	"v.io/v23/security"
+	"v.io/v23/verror"
+)
+
+type service struct{ name string }
+
+func (s *service) Get(*context.T, rpc.ServerCall) (string, error) { return s.name, nil }
+
+type globChildrenCall struct {
+	rpc.ServerCall
+	children []string
+}
+
+func (c *globChildrenCall) SendStream() interface {
+	Send(reply naming.GlobChildrenReply) error
+} {
+	return c
+}
+
+func (c *globChildrenCall) Send(reply naming.GlobChildrenReply) error {
+	c.children = append(c.children, reply.(naming.GlobChildrenReplyName).Value)
+	return nil
+}
+
+// children returns the children that obj reports to Glob, and false if obj
+// does not take part in the namespace.
+func children(t *testing.T, ctx *context.T, obj interface{}) ([]string, bool) {
+	invoker, ok := obj.(rpc.Invoker)
+	if !ok {
+		invoker = rpc.ReflectInvokerOrDie(obj)
+	}
+	gs := invoker.Globber()
+	if gs == nil || gs.ChildrenGlobber == nil {
+		return nil, false
+	}
+	m, err := glob.Parse("*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := &globChildrenCall{}
+	if err := gs.ChildrenGlobber.GlobChildren__(ctx, call, m.Head()); err != nil {
+		t.Fatal(err)
+	}
+	return call.children, true
+}
+
+type lookup struct {
+	suffix   string
+	obj      interface{} // nil if the suffix isn't found
+	children []string    // expected children of the object, if any
+}
+
+func testLookups(t *testing.T, ctx *context.T, d rpc.Dispatcher, tests []lookup) {
+	for _, test := range tests {
+		obj, _, err := d.Lookup(ctx, test.suffix)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.suffix, err)
+			continue
+		}
+		if test.obj == nil && test.children == nil {
+			if obj != nil {
+				t.Errorf("%q: got %v, want nil", test.suffix, obj)
+			}
+			continue
+		}
+		if obj == nil {
+			t.Errorf("%q: got nil object", test.suffix)
+			continue
+		}
+		if test.obj != nil && obj != test.obj {
+			if _, ok := obj.(rpc.Invoker); !ok {
+				t.Errorf("%q: got %v, want %v", test.suffix, obj, test.obj)
+			}
+		}
+		if got, _ := children(t, ctx, obj); !reflect.DeepEqual(got, test.children) {
+			t.Errorf("%q: got children %v, want %v", test.suffix, got, test.children)
+		}
+	}
+}
+
+func TestStatic(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	a, abc := &service{"a"}, &service{"abc"}
+	d := dispatch.Static(map[string]interface{}{"a": a, "a/b/c": abc, "/x/y/": abc}, nil)
+	testLookups(t, ctx, d, []lookup{
+		{"", nil, []string{"a", "x"}},
+		{"a", a, []string{"b"}},
+		{"a/b", nil, []string{"c"}},
+		{"a/b/c", abc, nil},
+		{"x/y", abc, nil},
+		{"a/b/c/d", nil, nil},
+		{"b", nil, nil},
+	})
+}
+
+func TestMux(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	a, ab, root := &service{"a"}, &service{"ab"}, &service{"root"}
+	m := dispatch.NewMux(nil)
+	if err := m.Handle("a", dispatch.Tree(a, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Handle("a/b/c", dispatch.Leaf(ab, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Handle("x/y", dispatch.Static(map[string]interface{}{"z": root}, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Handle("/a/", dispatch.Tree(a, nil)); err == nil {
+		t.Errorf("expected an error registering a duplicate prefix")
+	}
+	testLookups(t, ctx, m, []lookup{
+		{"", nil, []string{"a", "x"}},
+		{"a", a, []string{"b"}},
+		{"a/b", a, []string{"c"}},
+		{"a/b/c", ab, nil},
+		{"a/q", a, nil},
+		{"x", nil, []string{"y"}},
+		{"x/y", nil, []string{"z"}},
+		{"x/y/z", root, nil},
+		{"x/y/w", nil, nil},
+		{"q", nil, nil},
+	})
+	if _, _, err := m.Lookup(ctx, "a/b/c/d"); verror.ErrorID(err) != verror.ErrUnknownSuffix.ID {
+		t.Errorf("got %v, want ErrUnknownSuffix", err)
+	}
+
+	m.Remove("a")
+	testLookups(t, ctx, m, []lookup{
+		{"a", nil, []string{"b"}},
+		{"a/q", nil, nil},
+	})
+
+	// Routes below a suffix that the dispatcher of its prefix fails to look
+	// up are still reachable.
+	if err := m.Handle("a", dispatch.Leaf(a, nil)); err != nil {
+		t.Fatal(err)
+	}
+	testLookups(t, ctx, m, []lookup{
+		{"a", a, []string{"b"}},
+		{"a/b", nil, []string{"c"}},
+		{"a/b/c", ab, nil},
+	})
+	if _, _, err := m.Lookup(ctx, "a/q"); verror.ErrorID(err) != verror.ErrUnknownSuffix.ID {
+		t.Errorf("got %v, want ErrUnknownSuffix", err)
+	}
+}
+
+type denyAll struct{}
+
+func (denyAll) Authorize(*context.T, security.Call) error { return verror.New(verror.ErrNoAccess, nil) }
+
+func TestFallbackAndAuthorizer(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	a, b := &service{"a"}, &service{"b"}
+	d := dispatch.Fallback(
+		dispatch.Leaf(a, nil),
+		dispatch.Static(map[string]interface{}{"x": b}, nil),
+		dispatch.WithAuthorizer(dispatch.Static(map[string]interface{}{"y": b}, nil), denyAll{}),
+	)
+	tests := []struct {
+		suffix string
+		obj    interface{}
+		auth   security.Authorizer
+	}{
+		{"", a, nil},
+		{"x", b, nil},
+		{"y", b, denyAll{}},
+	}
+	for _, test := range tests {
+		obj, auth, err := d.Lookup(ctx, test.suffix)
+		if err != nil || obj != test.obj || auth != test.auth {
+			t.Errorf("%q: got (%v, %v, %v), want (%v, %v, nil)", test.suffix, obj, auth, err, test.obj, test.auth)
+		}
+	}
+	// The last error of a skipped dispatcher is returned if none has an object.
+	if obj, _, err := d.Lookup(ctx, "z"); obj != nil || verror.ErrorID(err) != verror.ErrUnknownSuffix.ID {
+		t.Errorf("got (%v, %v), want ErrUnknownSuffix", obj, err)
+	}
+	// Other errors end the lookup.
+	fail := dispatch.Fallback(dispatch.WithAuthorizer(errDispatcher{}, nil), dispatch.Leaf(a, nil))
+	if _, _, err := fail.Lookup(ctx, ""); verror.ErrorID(err) != verror.ErrInternal.ID {
+		t.Errorf("got %v, want ErrInternal", err)
+	}
+}
+
+type errDispatcher struct{}
+
+func (errDispatcher) Lookup(ctx *context.T, _ string) (interface{}, security.Authorizer, error) {
+	return nil, nil, verror.New(verror.ErrInternal, ctx)
+}
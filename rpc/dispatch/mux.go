@@ -0,0 +1,116 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dispatch
+
+import (
+	"strings"
+	"sync"
+
+	"v.io/v23/context"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	"v.io/v23/verror"
+)
+
+// Mux is a dispatcher that routes each suffix to the dispatcher registered
+// for the longest prefix of the suffix, in whole name elements, passing it the
+// remainder of the suffix.  Suffixes that have no registered prefix but are
+// themselves prefixes of registered prefixes are served by an object that
+// reports their children to Glob, authorized by the authorizer given to
+// NewMux; so are the suffixes for which the dispatcher of their prefix
+// returns an error or no object, when routes are registered below them.  It
+// is safe for concurrent use.
+type Mux struct {
+	auth security.Authorizer
+
+	mu     sync.RWMutex
+	routes map[string]rpc.Dispatcher // GUARDED_BY(mu)
+	names  []string                  // GUARDED_BY(mu)
+}
+
+// NewMux returns a new Mux with no routes.  Intermediate nodes of the
+// namespace are authorized by auth; a nil auth indicates the default
+// authorization checks should be used.
+func NewMux(auth security.Authorizer) *Mux {
+	return &Mux{auth: auth, routes: make(map[string]rpc.Dispatcher)}
+}
+
+// Handle registers d to handle the suffixes at and below prefix.  The empty
+// prefix matches every suffix that no other prefix matches.  It is an error to
+// register the same prefix twice.
+func (m *Mux) Handle(prefix string, d rpc.Dispatcher) error {
+	prefix = clean(prefix)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.routes[prefix]; ok {
+		return verror.New(errDuplicatePrefix, nil, prefix)
+	}
+	m.routes[prefix] = d
+	m.names = append(m.names, prefix)
+	return nil
+}
+
+// Remove removes the dispatcher registered for prefix, if any.
+func (m *Mux) Remove(prefix string) {
+	prefix = clean(prefix)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.routes[prefix]; !ok {
+		return
+	}
+	delete(m.routes, prefix)
+	for i, name := range m.names {
+		if name == prefix {
+			m.names = append(m.names[:i], m.names[i+1:]...)
+			break
+		}
+	}
+}
+
+// Lookup implements rpc.Dispatcher.
+func (m *Mux) Lookup(ctx *context.T, suffix string) (interface{}, security.Authorizer, error) {
+	suffix = clean(suffix)
+	m.mu.RLock()
+	prefix, d := m.matchLocked(suffix)
+	children := childrenOf(m.names, suffix)
+	m.mu.RUnlock()
+
+	if d != nil {
+		rest := strings.TrimPrefix(strings.TrimPrefix(suffix, prefix), "/")
+		obj, auth, err := d.Lookup(ctx, rest)
+		switch {
+		case err != nil && len(children) == 0:
+			return nil, nil, err
+		case err == nil && obj != nil:
+			// Routes registered below suffix are children of the object.
+			if obj, err = withChildren(obj, children); err != nil {
+				return nil, nil, err
+			}
+			return obj, auth, nil
+		}
+	}
+	if len(children) > 0 {
+		return rpc.ChildrenGlobberInvoker(children...), m.auth, nil
+	}
+	return nil, nil, nil
+}
+
+// matchLocked returns the longest registered prefix of suffix and its
+// dispatcher, or a nil dispatcher if there is none.  REQUIRES: m.mu is held.
+func (m *Mux) matchLocked(suffix string) (string, rpc.Dispatcher) {
+	for prefix := suffix; ; {
+		if d, ok := m.routes[prefix]; ok {
+			return prefix, d
+		}
+		if prefix == "" {
+			return "", nil
+		}
+		if i := strings.LastIndex(prefix, "/"); i >= 0 {
+			prefix = prefix[:i]
+		} else {
+			prefix = ""
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"v.io/v23/context"
+	"v.io/v23/naming"
+)
+
+// globLimitsKey is used to store the GlobLimits of a call in the context.
+type globLimitsKey struct{}
+
+// WithGlobLimits returns a new context with limits attached.  Servers attach
+// the GlobLimits of each incoming Request for the reserved Glob__ method to
+// the context of the call, so that AllGlobber implementations can apply them.
+func WithGlobLimits(ctx *context.T, limits naming.GlobLimits) *context.T {
+	return context.WithValue(ctx, globLimitsKey{}, limits)
+}
+
+// GetGlobLimits returns the GlobLimits attached to ctx, restricted by those
+// of gs if gs is non-nil.
+func GetGlobLimits(ctx *context.T, gs *GlobState) naming.GlobLimits {
+	limits, _ := ctx.Value(globLimitsKey{}).(naming.GlobLimits)
+	if gs != nil {
+		limits = limits.Restrict(gs.Limits)
+	}
+	return limits
+}
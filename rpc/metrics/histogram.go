@@ -0,0 +1,62 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"sort"
+
+	"v.io/v23/services/stats"
+)
+
+// histogram accumulates values into buckets.  Bucket i holds the values v
+// with bounds[i] <= v < bounds[i+1]; values below bounds[0] are counted in
+// the first bucket.  It is not safe for concurrent use.
+type histogram struct {
+	bounds   []int64
+	counts   []int64
+	count    int64
+	sum      int64
+	min, max int64
+}
+
+func newHistogram(bounds []int64) *histogram {
+	return &histogram{
+		bounds: bounds,
+		counts: make([]int64, len(bounds)),
+	}
+}
+
+func (h *histogram) add(v int64) {
+	if h.count == 0 || v < h.min {
+		h.min = v
+	}
+	if h.count == 0 || v > h.max {
+		h.max = v
+	}
+	h.count++
+	h.sum += v
+	// i is the index of the first bucket whose lower bound exceeds v.
+	i := sort.Search(len(h.bounds), func(i int) bool { return h.bounds[i] > v })
+	if i > 0 {
+		i--
+	}
+	if len(h.counts) > 0 {
+		h.counts[i]++
+	}
+}
+
+func (h *histogram) value() stats.HistogramValue {
+	v := stats.HistogramValue{
+		Count:   h.count,
+		Sum:     h.sum,
+		Min:     h.min,
+		Max:     h.max,
+		Buckets: make([]stats.HistogramBucket, len(h.bounds)),
+	}
+	for i, b := range h.bounds {
+		v.Buckets[i] = stats.HistogramBucket{LowBound: b, Count: h.counts[i]}
+	}
+	return v
+}
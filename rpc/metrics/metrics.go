@@ -0,0 +1,285 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package metrics records per-method rpc metrics and publishes them as stats
+// objects.
+//
+// A Recorder keeps the following objects for each side (client or server) of
+// each method:
+//
+//   rpc/<side>/<method>/calls     stats.CounterValue   calls started
+//   rpc/<side>/<method>/errors    stats.CounterValue   calls failed, by verror ID
+//   rpc/<side>/<method>/inflight  int64                calls in progress
+//   rpc/<side>/<method>/latency   stats.HistogramValue latency in microseconds
+//
+// The names are relative to the root of the stats service, so a process that
+// serves its stats under __debug/stats exposes, for example,
+// __debug/stats/rpc/server/Get/latency.  A Recorder is a
+// statsserver.Source, so statsserver.NewDispatcher serves its objects via the
+// stats.Stats interface, and WritePrometheus renders them in the Prometheus
+// text exposition format.
+//
+// RPC implementations find the Recorder attached to the context of a call:
+//
+//   done := metrics.StartServerCall(ctx, method)
+//   results, err := invoke(...)
+//   done(err)
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/naming"
+	"v.io/v23/services/stats"
+	"v.io/v23/services/stats/statsserver"
+	"v.io/v23/verror"
+)
+
+const pkgPath = "v.io/v23/rpc/metrics"
+
+var (
+	errNoObject = verror.Register(pkgPath+".errNoObject", verror.NoRetry, "{1:}{2:} no metrics object named {3}{:_}")
+	errNoValue  = verror.Register(pkgPath+".errNoValue", verror.NoRetry, "{1:}{2:} metrics object {3} has no value{:_}")
+)
+
+// Root is the name, relative to the root of the stats service, under which
+// all rpc metrics are published.
+const Root = "rpc"
+
+// The names of the objects published for each method.
+const (
+	CallsObject    = "calls"
+	ErrorsObject   = "errors"
+	InFlightObject = "inflight"
+	LatencyObject  = "latency"
+)
+
+// Side identifies which end of an rpc a metric was recorded at.
+type Side int
+
+const (
+	// Client indicates metrics recorded by the client making a call.
+	Client Side = iota
+	// Server indicates metrics recorded by the server handling a call.
+	Server
+)
+
+// String returns the name used for the side in the stats naming tree.
+func (s Side) String() string {
+	switch s {
+	case Client:
+		return "client"
+	case Server:
+		return "server"
+	default:
+		return "unknown"
+	}
+}
+
+// DefaultLatencyBounds are the histogram bucket lower bounds, in
+// microseconds, used by a Recorder created without explicit bounds.
+var DefaultLatencyBounds = []int64{
+	0, 100, 250, 500,
+	1000, 2500, 5000, 10000, 25000, 50000, 100000, 250000, 500000,
+	1000000, 2500000, 5000000, 10000000,
+}
+
+// Recorder records rpc metrics.  It is safe for concurrent use.
+type Recorder struct {
+	bounds []int64
+
+	mu      sync.Mutex
+	methods map[methodKey]*methodMetrics // GUARDED_BY(mu)
+}
+
+type methodKey struct {
+	side   Side
+	method string
+}
+
+type methodMetrics struct {
+	calls    int64
+	errors   int64
+	byID     map[verror.ID]int64
+	inFlight int64
+	latency  *histogram
+}
+
+// NewRecorder returns a new Recorder whose latency histograms use the given
+// bucket lower bounds, in microseconds.  DefaultLatencyBounds is used if no
+// bounds are given.
+func NewRecorder(latencyBounds ...int64) *Recorder {
+	if len(latencyBounds) == 0 {
+		latencyBounds = DefaultLatencyBounds
+	}
+	bounds := append([]int64(nil), latencyBounds...)
+	sort.Sort(int64s(bounds))
+	return &Recorder{
+		bounds:  bounds,
+		methods: make(map[methodKey]*methodMetrics),
+	}
+}
+
+// metricsLocked returns the metrics for the given side and method, creating
+// them if necessary.  REQUIRES: r.mu is held.
+func (r *Recorder) metricsLocked(side Side, method string) *methodMetrics {
+	k := methodKey{side, method}
+	m := r.methods[k]
+	if m == nil {
+		m = &methodMetrics{
+			byID:    make(map[verror.ID]int64),
+			latency: newHistogram(r.bounds),
+		}
+		r.methods[k] = m
+	}
+	return m
+}
+
+// Start records the start of a call to method and returns a function that
+// must be called exactly once, with the outcome of the call, when it
+// finishes.
+func (r *Recorder) Start(side Side, method string) func(err error) {
+	start := time.Now()
+	r.mu.Lock()
+	r.metricsLocked(side, method).inFlight++
+	r.mu.Unlock()
+	return func(err error) {
+		latency := time.Since(start)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		m := r.metricsLocked(side, method)
+		m.inFlight--
+		m.record(latency, err)
+	}
+}
+
+// Record records a call to method that has already completed, taking the
+// given amount of time and failing with err if it is not nil.
+func (r *Recorder) Record(side Side, method string, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metricsLocked(side, method).record(latency, err)
+}
+
+func (m *methodMetrics) record(latency time.Duration, err error) {
+	m.calls++
+	if err != nil {
+		m.errors++
+		m.byID[verror.ErrorID(err)]++
+	}
+	m.latency.add(int64(latency / time.Microsecond))
+}
+
+// Names returns the names of all the objects in r, relative to the root of
+// the stats service, in sorted order.
+func (r *Recorder) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, 4*len(r.methods))
+	for k := range r.methods {
+		prefix := naming.Join(Root, k.side.String(), k.method)
+		for _, obj := range []string{CallsObject, ErrorsObject, InFlightObject, LatencyObject} {
+			names = append(names, naming.Join(prefix, obj))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Value returns the current value of the named object, where name is
+// relative to the root of the stats service.  Intermediate nodes of the
+// naming tree, e.g. "rpc/server", exist but have no value.
+func (r *Recorder) Value(name string) (interface{}, error) {
+	elems := statsserver.SplitName(name)
+	if len(elems) != 4 || elems[0] != Root {
+		if statsserver.Exists(r, name) {
+			return nil, verror.New(errNoValue, nil, name)
+		}
+		return nil, verror.New(errNoObject, nil, name)
+	}
+	side, ok := parseSide(elems[1])
+	if !ok {
+		return nil, verror.New(errNoObject, nil, name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.methods[methodKey{side, elems[2]}]
+	if m == nil {
+		return nil, verror.New(errNoObject, nil, name)
+	}
+	switch elems[3] {
+	case CallsObject:
+		return stats.CounterValue{Count: m.calls}, nil
+	case ErrorsObject:
+		v := stats.CounterValue{Count: m.errors}
+		if len(m.byID) > 0 {
+			v.ByKey = make(map[string]int64, len(m.byID))
+			for id, n := range m.byID {
+				v.ByKey[string(id)] = n
+			}
+		}
+		return v, nil
+	case InFlightObject:
+		return m.inFlight, nil
+	case LatencyObject:
+		return m.latency.value(), nil
+	}
+	return nil, verror.New(errNoObject, nil, name)
+}
+
+// StartClientCall records the start of a client call to method with the
+// Recorder attached to ctx.  It returns a function that must be called with
+// the outcome of the call when it finishes.  If ctx has no Recorder, the
+// returned function does nothing.
+func StartClientCall(ctx *context.T, method string) func(err error) {
+	return start(ctx, Client, method)
+}
+
+// StartServerCall records the start of a server call to method with the
+// Recorder attached to ctx.  It returns a function that must be called with
+// the outcome of the call when it finishes.  If ctx has no Recorder, the
+// returned function does nothing.
+func StartServerCall(ctx *context.T, method string) func(err error) {
+	return start(ctx, Server, method)
+}
+
+func start(ctx *context.T, side Side, method string) func(err error) {
+	if r := GetRecorder(ctx); r != nil {
+		return r.Start(side, method)
+	}
+	return func(error) {}
+}
+
+// recorderKey is used to store a Recorder in the context.
+type recorderKey struct{}
+
+// WithRecorder returns a new context with r attached.
+func WithRecorder(ctx *context.T, r *Recorder) *context.T {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// GetRecorder returns the Recorder attached to ctx, or nil if there is none.
+func GetRecorder(ctx *context.T) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
+
+func parseSide(s string) (Side, bool) {
+	switch s {
+	case "client":
+		return Client, true
+	case "server":
+		return Server, true
+	}
+	return 0, false
+}
+
+type int64s []int64
+
+func (s int64s) Len() int           { return len(s) }
+func (s int64s) Less(i, j int) bool { return s[i] < s[j] }
+func (s int64s) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
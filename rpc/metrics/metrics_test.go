@@ -0,0 +1,157 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/rpc/metrics"
+	"v.io/v23/services/stats"
+	"v.io/v23/verror"
+)
+
+func TestRecorder(t *testing.T) {
+	r := metrics.NewRecorder(0, 1000, 10000)
+	r.Record(metrics.Server, "Get", 500*time.Microsecond, nil)
+	r.Record(metrics.Server, "Get", 2*time.Millisecond, verror.New(verror.ErrNoExist, nil))
+	r.Record(metrics.Server, "Get", 20*time.Millisecond, errors.New("oops"))
+	done := r.Start(metrics.Client, "Put")
+
+	want := []string{
+		"rpc/client/Put/calls",
+		"rpc/client/Put/errors",
+		"rpc/client/Put/inflight",
+		"rpc/client/Put/latency",
+		"rpc/server/Get/calls",
+		"rpc/server/Get/errors",
+		"rpc/server/Get/inflight",
+		"rpc/server/Get/latency",
+	}
+	if got := r.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name string
+		want interface{}
+	}{
+		{"rpc/server/Get/calls", stats.CounterValue{Count: 3}},
+		{"rpc/server/Get/errors", stats.CounterValue{
+			Count: 2,
+			ByKey: map[string]int64{
+				string(verror.ErrNoExist.ID): 1,
+				string(verror.ErrUnknown.ID): 1,
+			},
+		}},
+		{"rpc/server/Get/inflight", int64(0)},
+		{"rpc/server/Get/latency", stats.HistogramValue{
+			Count: 3,
+			Sum:   22500,
+			Min:   500,
+			Max:   20000,
+			Buckets: []stats.HistogramBucket{
+				{LowBound: 0, Count: 1},
+				{LowBound: 1000, Count: 1},
+				{LowBound: 10000, Count: 1},
+			},
+		}},
+		{"rpc/client/Put/calls", stats.CounterValue{}},
+		{"rpc/client/Put/inflight", int64(1)},
+	}
+	for _, test := range tests {
+		got, err := r.Value(test.name)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %#v, want %#v", test.name, got, test.want)
+		}
+	}
+
+	done(nil)
+	if got, _ := r.Value("rpc/client/Put/inflight"); got != int64(0) {
+		t.Errorf("got %v, want 0", got)
+	}
+	if got, _ := r.Value("rpc/client/Put/calls"); !reflect.DeepEqual(got, stats.CounterValue{Count: 1}) {
+		t.Errorf("got %v, want 1 call", got)
+	}
+
+	for _, name := range []string{"", "rpc", "rpc/server", "rpc/server/Get"} {
+		if _, err := r.Value(name); err == nil {
+			t.Errorf("%q: expected an error", name)
+		}
+	}
+	for _, name := range []string{"foo", "rpc/server/Put/calls", "rpc/server/Get/foo", "rpc/other/Get/calls"} {
+		if _, err := r.Value(name); err == nil {
+			t.Errorf("%q: expected an error", name)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+
+	// Without a recorder, calls are not recorded, but don't fail either.
+	metrics.StartServerCall(ctx, "Get")(nil)
+	if r := metrics.GetRecorder(ctx); r != nil {
+		t.Fatalf("unexpected recorder: %v", r)
+	}
+
+	r := metrics.NewRecorder()
+	ctx = metrics.WithRecorder(ctx, r)
+	if got := metrics.GetRecorder(ctx); got != r {
+		t.Fatalf("got %v, want %v", got, r)
+	}
+	metrics.StartServerCall(ctx, "Get")(nil)
+	metrics.StartClientCall(ctx, "Get")(verror.New(verror.ErrTimeout, nil))
+	if got, _ := r.Value("rpc/server/Get/calls"); !reflect.DeepEqual(got, stats.CounterValue{Count: 1}) {
+		t.Errorf("got %v, want 1 call", got)
+	}
+	want := stats.CounterValue{Count: 1, ByKey: map[string]int64{string(verror.ErrTimeout.ID): 1}}
+	if got, _ := r.Value("rpc/client/Get/errors"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	r := metrics.NewRecorder(0, 1000)
+	r.Record(metrics.Server, "Get", 500*time.Microsecond, nil)
+	r.Record(metrics.Server, "Get", 1000*time.Microsecond, nil)
+	r.Record(metrics.Server, "Get", 1500*time.Microsecond, verror.New(verror.ErrNoExist, nil))
+	var buf bytes.Buffer
+	if err := r.WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP vanadium_rpc_calls_total Number of rpc calls started.
+# TYPE vanadium_rpc_calls_total counter
+vanadium_rpc_calls_total{side="server",method="Get"} 3
+# HELP vanadium_rpc_errors_total Number of rpc calls that failed, by verror ID.
+# TYPE vanadium_rpc_errors_total counter
+vanadium_rpc_errors_total{side="server",method="Get",id="v.io/v23/verror.NoExist"} 1
+# HELP vanadium_rpc_in_flight Number of rpc calls in progress.
+# TYPE vanadium_rpc_in_flight gauge
+vanadium_rpc_in_flight{side="server",method="Get"} 0
+# HELP vanadium_rpc_latency_seconds Latency of rpc calls.
+# TYPE vanadium_rpc_latency_seconds histogram
+vanadium_rpc_latency_seconds_bucket{side="server",method="Get",le="0.000999"} 1
+vanadium_rpc_latency_seconds_bucket{side="server",method="Get",le="+Inf"} 3
+vanadium_rpc_latency_seconds_sum{side="server",method="Get"} 0.003
+vanadium_rpc_latency_seconds_count{side="server",method="Get"} 3
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if strings.Count(buf.String(), "# TYPE") != 4 {
+		t.Errorf("unexpected number of metric families:\n%s", buf.String())
+	}
+}
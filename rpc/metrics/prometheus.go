@@ -0,0 +1,149 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"v.io/v23/services/stats"
+)
+
+// PrometheusPrefix is prepended to the names of all the metrics written by
+// WritePrometheus.
+const PrometheusPrefix = "vanadium_rpc_"
+
+// PrometheusContentType is the content type of the Prometheus text exposition
+// format, version 0.0.4.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type methodSnapshot struct {
+	side     Side
+	method   string
+	calls    int64
+	errors   stats.CounterValue
+	inFlight int64
+	latency  stats.HistogramValue
+}
+
+func (r *Recorder) snapshot() []methodSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap := make([]methodSnapshot, 0, len(r.methods))
+	for k, m := range r.methods {
+		s := methodSnapshot{
+			side:     k.side,
+			method:   k.method,
+			calls:    m.calls,
+			errors:   stats.CounterValue{Count: m.errors, ByKey: make(map[string]int64, len(m.byID))},
+			inFlight: m.inFlight,
+			latency:  m.latency.value(),
+		}
+		for id, n := range m.byID {
+			s.errors.ByKey[string(id)] = n
+		}
+		snap = append(snap, s)
+	}
+	sort.Sort(bySideAndMethod(snap))
+	return snap
+}
+
+type bySideAndMethod []methodSnapshot
+
+func (s bySideAndMethod) Len() int      { return len(s) }
+func (s bySideAndMethod) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s bySideAndMethod) Less(i, j int) bool {
+	if s[i].side != s[j].side {
+		return s[i].side < s[j].side
+	}
+	return s[i].method < s[j].method
+}
+
+// WritePrometheus writes the metrics in r to w using the Prometheus text
+// exposition format.  Latencies are reported in seconds.  Latencies are
+// recorded in whole microseconds, and a bucket holds the latencies from its
+// lower bound up to, but not including, the lower bound of the next bucket,
+// so the "le" label of a bucket is one microsecond below the next lower bound.
+func (r *Recorder) WritePrometheus(w io.Writer) error {
+	snap := r.snapshot()
+	b := bufio.NewWriter(w)
+
+	family(b, "calls_total", "counter", "Number of rpc calls started.")
+	for _, s := range snap {
+		sample(b, "calls_total", labels(s), strconv.FormatInt(s.calls, 10))
+	}
+
+	family(b, "errors_total", "counter", "Number of rpc calls that failed, by verror ID.")
+	for _, s := range snap {
+		ids := make([]string, 0, len(s.errors.ByKey))
+		for id := range s.errors.ByKey {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			sample(b, "errors_total", labels(s, "id", id), strconv.FormatInt(s.errors.ByKey[id], 10))
+		}
+	}
+
+	family(b, "in_flight", "gauge", "Number of rpc calls in progress.")
+	for _, s := range snap {
+		sample(b, "in_flight", labels(s), strconv.FormatInt(s.inFlight, 10))
+	}
+
+	family(b, "latency_seconds", "histogram", "Latency of rpc calls.")
+	for _, s := range snap {
+		var cumulative int64
+		for i, bucket := range s.latency.Buckets {
+			cumulative += bucket.Count
+			if i+1 < len(s.latency.Buckets) {
+				le := seconds(s.latency.Buckets[i+1].LowBound - 1)
+				sample(b, "latency_seconds_bucket", labels(s, "le", le), strconv.FormatInt(cumulative, 10))
+			}
+		}
+		sample(b, "latency_seconds_bucket", labels(s, "le", "+Inf"), strconv.FormatInt(s.latency.Count, 10))
+		sample(b, "latency_seconds_sum", labels(s), seconds(s.latency.Sum))
+		sample(b, "latency_seconds_count", labels(s), strconv.FormatInt(s.latency.Count, 10))
+	}
+	return b.Flush()
+}
+
+// ServeHTTP implements http.Handler, serving the metrics in r to Prometheus
+// scrapers.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", PrometheusContentType)
+	r.WritePrometheus(w)
+}
+
+func family(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s%s %s\n", PrometheusPrefix, name, help)
+	fmt.Fprintf(w, "# TYPE %s%s %s\n", PrometheusPrefix, name, typ)
+}
+
+func sample(w io.Writer, name, labels, value string) {
+	fmt.Fprintf(w, "%s%s{%s} %s\n", PrometheusPrefix, name, labels, value)
+}
+
+// labels returns the label set for s, followed by the given extra name/value
+// pairs.
+func labels(s methodSnapshot, extra ...string) string {
+	pairs := append([]string{"side", s.side.String(), "method", s.method}, extra...)
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, pairs[i]+`="`+labelEscaper.Replace(pairs[i+1])+`"`)
+	}
+	return strings.Join(parts, ",")
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// seconds formats a number of microseconds as seconds.
+func seconds(micros int64) string {
+	return strconv.FormatFloat(float64(micros)/1e6, 'g', -1, 64)
+}
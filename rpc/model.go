@@ -82,6 +82,16 @@ type ClientCall interface {
 }
 
 // Stream defines the interface for a bidirectional FIFO stream of typed values.
+//
+// The stream is flow-controlled: each end grants the other a window of bytes
+// that may be sent before the sender must wait for the receiver to Recv.
+// SendBatch and RecvBatch amortize the per-item framing and flow-control
+// overhead over many small items, and TrySend allows a sender to detect a slow
+// peer rather than block on it.
+//
+// The stubs generated for streaming VDL methods expose typed variants of these
+// methods: SendBatch and TrySend on SendStream, RecvBatch on RecvStream, and
+// Window and SetWindow on the call itself.
 type Stream interface {
 	// Send places the item onto the output stream, blocking if there is no buffer
 	// space available.
@@ -90,6 +100,45 @@ type Stream interface {
 	// Recv fills itemptr with the next item in the input stream, blocking until
 	// an item is available.  Returns io.EOF to indicate graceful end of input.
 	Recv(itemptr interface{}) error
+
+	// SendBatch places all of the items onto the output stream, in order, behind
+	// a single header.  It blocks until all of the items have been accepted.  The
+	// peer receives the items individually, via either Recv or RecvBatch.
+	SendBatch(items ...interface{}) error
+
+	// RecvBatch fills a prefix of itemptrs with the next items in the input
+	// stream, blocking until at least one item is available, and returns the
+	// number of itemptrs filled.  It does not block waiting for more items once
+	// at least one has been received.  Returns 0 and io.EOF to indicate graceful
+	// end of input.
+	RecvBatch(itemptrs ...interface{}) (int, error)
+
+	// TrySend places the item onto the output stream only if that can be done
+	// without blocking.  It returns false, without sending the item, if the peer
+	// has not granted enough of the send window for it, i.e. the peer is slow to
+	// Recv.
+	TrySend(item interface{}) (bool, error)
+
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() StreamWindow
+
+	// SetWindow sets the number of bytes the peer may send on the stream before
+	// it must wait for the local end to Recv.  Shrinking the window takes effect
+	// only as the peer consumes the window it has already been granted.
+	SetWindow(size uint64) error
+}
+
+// StreamWindow describes the flow-control windows of a Stream.
+type StreamWindow struct {
+	// Send is the number of bytes that may currently be sent to the peer without
+	// blocking.
+	Send uint64
+	// Recv is the size of the window granted to the peer, as set by SetWindow.
+	Recv uint64
+	// Buffered is the number of received bytes that have not yet been consumed
+	// by Recv or RecvBatch.
+	Buffered uint64
 }
 
 // ListenAddrs is the set of protocol, address pairs to listen on.
@@ -427,6 +476,9 @@ type Globber interface {
 type GlobState struct {
 	AllGlobber      AllGlobber
 	ChildrenGlobber ChildrenGlobber
+	// Limits are the limits that the server applies to Globs of the object,
+	// in addition to those requested by the client.
+	Limits naming.GlobLimits
 }
 
 // AllGlobber is a powerful interface that allows the object to enumerate the
@@ -44,6 +44,11 @@ func (*FakeStreamServerCall) Closed() <-chan struct{}                         {
 func (*FakeStreamServerCall) IsClosed() bool                                  { return false }
 func (*FakeStreamServerCall) Send(item interface{}) error                     { return nil }
 func (*FakeStreamServerCall) Recv(itemptr interface{}) error                  { return nil }
+func (*FakeStreamServerCall) SendBatch(items ...interface{}) error            { return nil }
+func (*FakeStreamServerCall) RecvBatch(itemptrs ...interface{}) (int, error)  { return 0, nil }
+func (*FakeStreamServerCall) TrySend(item interface{}) (bool, error)          { return true, nil }
+func (*FakeStreamServerCall) Window() rpc.StreamWindow                        { return rpc.StreamWindow{} }
+func (*FakeStreamServerCall) SetWindow(size uint64) error                     { return nil }
 func (*FakeStreamServerCall) Timestamp() time.Time                            { return time.Time{} }
 func (*FakeStreamServerCall) Method() string                                  { return "" }
 func (*FakeStreamServerCall) MethodTags() []*vdl.Value                        { return nil }
@@ -0,0 +1,132 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reserved
+
+import (
+	"strings"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/naming"
+)
+
+// GlobLimiter applies naming.GlobLimits to a Glob, for servers implementing
+// the reserved Glob__ method.  A server creates a GlobLimiter per call, with
+// the limits returned by rpc.GetGlobLimits, and walks the namespace below the
+// receiver in the order described by naming.CompareGlobNames.  While walking,
+// it:
+//
+//   - skips the names, and everything below them, for which Visit returns
+//     false;
+//   - doesn't descend below the names for which Descend returns false, and
+//     sends a naming.GlobReplyError with ErrGlobMaxRecursionReached for
+//     those that have children;
+//   - only sends the matches for which Admit returns true;
+//   - finally sends the reply returned by Truncated, if it isn't nil.
+//
+// A GlobLimiter isn't safe for concurrent use.
+type GlobLimiter struct {
+	ctx    *context.T
+	limits naming.GlobLimits
+	now    func() time.Time
+	start  time.Time
+	sent   uint64
+	last   string
+	reason error
+}
+
+// NewGlobLimiter returns a GlobLimiter that applies limits.  The time budget
+// starts when NewGlobLimiter is called.
+func NewGlobLimiter(ctx *context.T, limits naming.GlobLimits) *GlobLimiter {
+	return &GlobLimiter{
+		ctx:    ctx,
+		limits: limits,
+		now:    time.Now,
+		start:  time.Now(),
+		last:   limits.StartAfter,
+	}
+}
+
+// SetClock sets the function used by the GlobLimiter to tell the time, for
+// tests, and restarts the time budget.
+func (l *GlobLimiter) SetClock(now func() time.Time) {
+	l.now = now
+	l.start = now()
+}
+
+// Visit returns true if the Glob should visit name.  It returns false if
+// name, and all the names below it, come before StartAfter, or once a limit
+// has been reached.
+func (l *GlobLimiter) Visit(name string) bool {
+	if l.stopped() {
+		return false
+	}
+	after := l.limits.StartAfter
+	return after == "" || naming.CompareGlobNames(name, after) >= 0 || isAncestor(name, after)
+}
+
+// Descend returns true if the Glob should visit the children of name, i.e.
+// if they are within MaxDepth.
+func (l *GlobLimiter) Descend(name string) bool {
+	if l.stopped() {
+		return false
+	}
+	return l.limits.MaxDepth == 0 || depth(name) < int(l.limits.MaxDepth)
+}
+
+// Admit returns true if the Glob should send the match name.  It returns
+// false if name isn't after StartAfter, or once a limit has been reached.
+func (l *GlobLimiter) Admit(name string) bool {
+	if l.stopped() {
+		return false
+	}
+	if l.limits.StartAfter != "" && naming.CompareGlobNames(name, l.limits.StartAfter) <= 0 {
+		return false
+	}
+	if max := l.limits.MaxResults; max != 0 && l.sent >= max {
+		l.reason = NewErrGlobMaxResultsReached(l.ctx, max)
+		return false
+	}
+	l.sent++
+	l.last = name
+	return true
+}
+
+// Truncated returns the naming.GlobReplyTruncated to send as the last reply
+// of the Glob if a limit was reached, and nil otherwise.
+func (l *GlobLimiter) Truncated() naming.GlobReply {
+	if l.reason == nil {
+		return nil
+	}
+	return naming.GlobReplyTruncated{Value: naming.GlobTruncated{
+		Reason:      l.reason,
+		ResumeToken: l.last,
+	}}
+}
+
+// stopped returns true if a limit has been reached.
+func (l *GlobLimiter) stopped() bool {
+	if l.reason != nil {
+		return true
+	}
+	if budget := l.limits.TimeBudget; budget != 0 && l.now().Sub(l.start) >= budget {
+		l.reason = NewErrGlobTimeBudgetExceeded(l.ctx)
+		return true
+	}
+	return false
+}
+
+// isAncestor returns true if name is an ancestor of other.
+func isAncestor(name, other string) bool {
+	name, other = strings.Trim(name, "/"), strings.Trim(other, "/")
+	return name == "" || strings.HasPrefix(other, name+"/")
+}
+
+func depth(name string) int {
+	if name = strings.Trim(name, "/"); name == "" {
+		return 0
+	}
+	return strings.Count(name, "/") + 1
+}
@@ -0,0 +1,124 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reserved_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/naming"
+	"v.io/v23/rpc/reserved"
+	"v.io/v23/verror"
+)
+
+// tree is a namespace, as a map from names to their children.
+var tree = map[string][]string{
+	"":    {"b", "a", "c"},
+	"a":   {"y", "x"},
+	"a/x": {"1", "2"},
+	"c":   {"z"},
+}
+
+// glob walks tree like a server implementing Glob__ for "...", and returns
+// the names of the replies.
+func glob(l *reserved.GlobLimiter, tick func()) (names, errs []string, truncated *naming.GlobTruncated) {
+	var walk func(name string)
+	walk = func(name string) {
+		if !l.Visit(name) {
+			return
+		}
+		if tick != nil {
+			tick()
+		}
+		if l.Admit(name) {
+			names = append(names, name)
+		}
+		children := append([]string(nil), tree[name]...)
+		if len(children) == 0 {
+			return
+		}
+		if !l.Descend(name) {
+			errs = append(errs, name)
+			return
+		}
+		sort.Strings(children)
+		for _, c := range children {
+			walk(naming.Join(name, c))
+		}
+	}
+	walk("")
+	if reply := l.Truncated(); reply != nil {
+		t := reply.(naming.GlobReplyTruncated).Value
+		truncated = &t
+	}
+	return names, errs, truncated
+}
+
+var all = []string{"", "a", "a/x", "a/x/1", "a/x/2", "a/y", "b", "c", "c/z"}
+
+func TestGlobLimiterPagination(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	names, _, truncated := glob(reserved.NewGlobLimiter(ctx, naming.GlobLimits{}), nil)
+	if !reflect.DeepEqual(names, all) || truncated != nil {
+		t.Fatalf("got (%q, %v), want %q", names, truncated, all)
+	}
+
+	var got []string
+	limits := naming.GlobLimits{MaxResults: 4}
+	for pages := 1; ; pages++ {
+		names, _, truncated := glob(reserved.NewGlobLimiter(ctx, limits), nil)
+		got = append(got, names...)
+		if truncated == nil {
+			if pages != 3 {
+				t.Errorf("got %d pages, want 3", pages)
+			}
+			break
+		}
+		if verror.ErrorID(truncated.Reason) != reserved.ErrGlobMaxResultsReached.ID {
+			t.Fatalf("unexpected reason %v", truncated.Reason)
+		}
+		if len(names) != 4 || truncated.ResumeToken != names[3] {
+			t.Fatalf("got (%q, %q)", names, truncated.ResumeToken)
+		}
+		limits.StartAfter = truncated.ResumeToken
+	}
+	if !reflect.DeepEqual(got, all) {
+		t.Errorf("got %q, want %q", got, all)
+	}
+}
+
+func TestGlobLimiterDepth(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	names, errs, truncated := glob(reserved.NewGlobLimiter(ctx, naming.GlobLimits{MaxDepth: 1}), nil)
+	if want := []string{"", "a", "b", "c"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got %q, want %q", names, want)
+	}
+	if want := []string{"a", "c"}; !reflect.DeepEqual(errs, want) {
+		t.Errorf("got %q, want %q", errs, want)
+	}
+	if truncated != nil {
+		t.Errorf("unexpected %v", truncated)
+	}
+}
+
+func TestGlobLimiterTimeBudget(t *testing.T) {
+	ctx, cancel := context.RootContext()
+	defer cancel()
+	now := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := reserved.NewGlobLimiter(ctx, naming.GlobLimits{TimeBudget: 5 * time.Second, StartAfter: "a"})
+	l.SetClock(func() time.Time { return now })
+	names, _, truncated := glob(l, func() { now = now.Add(time.Second) })
+	if want := []string{"a/x", "a/x/1"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got %q, want %q", names, want)
+	}
+	if truncated == nil || truncated.ResumeToken != "a/x/1" || verror.ErrorID(truncated.Reason) != reserved.ErrGlobTimeBudgetExceeded.ID {
+		t.Errorf("unexpected %+v", truncated)
+	}
+}
@@ -3,7 +3,7 @@
 // license that can be found in the LICENSE file.
 
 // Package reserved implements client-side support for reserved RPC methods
-// implemented by all servers.
+// implemented by all servers, and helpers for the servers implementing them.
 package reserved
 
 import (
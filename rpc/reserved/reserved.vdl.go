@@ -29,6 +29,12 @@ var (
 	// GlobNotImplemented indicates that Glob is not implemented by the
 	// object.
 	ErrGlobNotImplemented = verror.Register("v.io/v23/rpc/reserved.GlobNotImplemented", verror.NoRetry, "{1:}{2:} Glob not implemented")
+	// GlobMaxResultsReached indicates that the Glob request returned the
+	// maximum number of results it was allowed to.
+	ErrGlobMaxResultsReached = verror.Register("v.io/v23/rpc/reserved.GlobMaxResultsReached", verror.NoRetry, "{1:}{2:} max results reached: {3}{:_}")
+	// GlobTimeBudgetExceeded indicates that the Glob request exceeded the
+	// time the server was allowed to spend on it.
+	ErrGlobTimeBudgetExceeded = verror.Register("v.io/v23/rpc/reserved.GlobTimeBudgetExceeded", verror.NoRetry, "{1:}{2:} time budget exceeded{:_}")
 )
 
 // NewErrGlobMaxRecursionReached returns an error with the ErrGlobMaxRecursionReached ID.
@@ -46,6 +52,16 @@ func NewErrGlobNotImplemented(ctx *context.T) error {
 	return verror.New(ErrGlobNotImplemented, ctx)
 }
 
+// NewErrGlobMaxResultsReached returns an error with the ErrGlobMaxResultsReached ID.
+func NewErrGlobMaxResultsReached(ctx *context.T, max uint64) error {
+	return verror.New(ErrGlobMaxResultsReached, ctx, max)
+}
+
+// NewErrGlobTimeBudgetExceeded returns an error with the ErrGlobTimeBudgetExceeded ID.
+func NewErrGlobTimeBudgetExceeded(ctx *context.T) error {
+	return verror.New(ErrGlobTimeBudgetExceeded, ctx)
+}
+
 var __VDLInitCalled bool
 
 // __VDLInit performs vdl initialization.  It is safe to call multiple times.
@@ -71,6 +87,8 @@ func __VDLInit() struct{} {
 	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrGlobMaxRecursionReached.ID), "{1:}{2:} max recursion level reached{:_}")
 	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrGlobMatchesOmitted.ID), "{1:}{2:} some matches might have been omitted")
 	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrGlobNotImplemented.ID), "{1:}{2:} Glob not implemented")
+	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrGlobMaxResultsReached.ID), "{1:}{2:} max results reached: {3}{:_}")
+	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrGlobTimeBudgetExceeded.ID), "{1:}{2:} time budget exceeded{:_}")
 
 	return struct{}{}
 }
@@ -8,6 +8,8 @@
 package rpc
 
 import (
+	"time"
+	"v.io/v23/naming"
 	"v.io/v23/security"
 	"v.io/v23/vdl"
 	vdltime "v.io/v23/vdlroot/time"
@@ -35,13 +37,6 @@ type Request struct {
 	NumPosArgs uint64
 	// EndStreamArgs is true iff no more streaming arguments will be sent.  No
 	// more data will be sent on the request stream.
-	//
-	// NOTE(bprosnitz): We can support multiple stream values per request (+response) header
-	// efficiently by adding a NumExtraStreamArgs (+NumExtraStreamResults to response) field
-	// that is the uint64 (number of stream args to send) - 1. The request is then zero when
-	// exactly one streaming arg is sent. Since the request and response headers are small,
-	// this is only likely necessary for frequently streaming small values.
-	// See implementation in CL: 3913
 	EndStreamArgs bool
 	// Deadline after which the request should be cancelled.  This is a hint to
 	// the server, to avoid wasted work.
@@ -56,6 +51,20 @@ type Request struct {
 	// By convention it should be an IETF language tag:
 	// http://en.wikipedia.org/wiki/IETF_language_tag
 	Language string
+	// NumExtraStreamArgs is the number of streaming args, in addition to the
+	// first, that follow this header.  It is non-zero only for headers that
+	// precede a batch of streaming args sent via Stream.SendBatch, so that the
+	// header remains zero when exactly one streaming arg is sent.
+	NumExtraStreamArgs uint64
+	// Hops describes the chain of RPCs that led to this request, outermost
+	// first, ending with this request itself.  It is used to report which hop
+	// of the chain caused a deadline to be exceeded.
+	Hops []Hop
+	// CancelReason, if non-zero, indicates that the client has canceled the
+	// call, and why.  No more data will be sent on the request stream.
+	CancelReason CancelReason
+	// GlobLimits bounds the results of a call to the reserved Glob__ method.
+	GlobLimits naming.GlobLimits
 }
 
 func (Request) __VDLReflect(struct {
@@ -88,6 +97,18 @@ func (x Request) VDLIsZero() bool {
 	if x.Language != "" {
 		return false
 	}
+	if x.NumExtraStreamArgs != 0 {
+		return false
+	}
+	if len(x.Hops) != 0 {
+		return false
+	}
+	if !x.CancelReason.VDLIsZero() {
+		return false
+	}
+	if x.GlobLimits != (naming.GlobLimits{}) {
+		return false
+	}
 	return true
 }
 
@@ -152,12 +173,62 @@ func (x Request) VDLWrite(enc vdl.Encoder) error {
 			return err
 		}
 	}
+	if x.NumExtraStreamArgs != 0 {
+		if err := enc.NextFieldValueUint(8, vdl.Uint64Type, x.NumExtraStreamArgs); err != nil {
+			return err
+		}
+	}
+	if len(x.Hops) != 0 {
+		if err := enc.NextField(9); err != nil {
+			return err
+		}
+		if err := __VDLWriteAnon_list_1(enc, x.Hops); err != nil {
+			return err
+		}
+	}
+	if !x.CancelReason.VDLIsZero() {
+		if err := enc.NextField(10); err != nil {
+			return err
+		}
+		if err := x.CancelReason.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if x.GlobLimits != (naming.GlobLimits{}) {
+		if err := enc.NextField(11); err != nil {
+			return err
+		}
+		if err := x.GlobLimits.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
 	if err := enc.NextField(-1); err != nil {
 		return err
 	}
 	return enc.FinishValue()
 }
 
+func __VDLWriteAnon_list_1(enc vdl.Encoder, x []Hop) error {
+	if err := enc.StartValue(__VDLType_list_5); err != nil {
+		return err
+	}
+	if err := enc.SetLenHint(len(x)); err != nil {
+		return err
+	}
+	for _, elem := range x {
+		if err := enc.NextEntry(false); err != nil {
+			return err
+		}
+		if err := elem.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextEntry(true); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
 func (x *Request) VDLRead(dec vdl.Decoder) error {
 	*x = Request{}
 	if err := dec.StartValue(__VDLType_struct_1); err != nil {
@@ -237,6 +308,50 @@ func (x *Request) VDLRead(dec vdl.Decoder) error {
 			default:
 				x.Language = value
 			}
+		case 8:
+			switch value, err := dec.ReadValueUint(64); {
+			case err != nil:
+				return err
+			default:
+				x.NumExtraStreamArgs = value
+			}
+		case 9:
+			if err := __VDLReadAnon_list_1(dec, &x.Hops); err != nil {
+				return err
+			}
+		case 10:
+			if err := x.CancelReason.VDLRead(dec); err != nil {
+				return err
+			}
+		case 11:
+			if err := x.GlobLimits.VDLRead(dec); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func __VDLReadAnon_list_1(dec vdl.Decoder, x *[]Hop) error {
+	if err := dec.StartValue(__VDLType_list_5); err != nil {
+		return err
+	}
+	if len := dec.LenHint(); len > 0 {
+		*x = make([]Hop, 0, len)
+	} else {
+		*x = nil
+	}
+	for {
+		switch done, err := dec.NextEntry(); {
+		case err != nil:
+			return err
+		case done:
+			return dec.FinishValue()
+		default:
+			var elem Hop
+			if err := elem.VDLRead(dec); err != nil {
+				return err
+			}
+			*x = append(*x, elem)
 		}
 	}
 }
@@ -261,6 +376,14 @@ type Response struct {
 	// AckBlessings is true if the server successfully recevied the client's
 	// blessings and stored them in the server's blessings cache.
 	AckBlessings bool
+	// NumExtraStreamResults is the number of streaming results, in addition to
+	// the first, that follow this header.  It is non-zero only for headers that
+	// precede a batch of streaming results sent via Stream.SendBatch.
+	NumExtraStreamResults uint64
+	// CancelReason, if non-zero, indicates that the server's context for the
+	// call was canceled, and why.  It accompanies an Error with ID
+	// verror.ErrCanceled or verror.ErrTimeout.
+	CancelReason CancelReason
 }
 
 func (Response) __VDLReflect(struct {
@@ -284,11 +407,17 @@ func (x Response) VDLIsZero() bool {
 	if x.AckBlessings {
 		return false
 	}
+	if x.NumExtraStreamResults != 0 {
+		return false
+	}
+	if !x.CancelReason.VDLIsZero() {
+		return false
+	}
 	return true
 }
 
 func (x Response) VDLWrite(enc vdl.Encoder) error {
-	if err := enc.StartValue(__VDLType_struct_5); err != nil {
+	if err := enc.StartValue(__VDLType_struct_10); err != nil {
 		return err
 	}
 	if x.Error != nil {
@@ -322,6 +451,19 @@ func (x Response) VDLWrite(enc vdl.Encoder) error {
 			return err
 		}
 	}
+	if x.NumExtraStreamResults != 0 {
+		if err := enc.NextFieldValueUint(5, vdl.Uint64Type, x.NumExtraStreamResults); err != nil {
+			return err
+		}
+	}
+	if !x.CancelReason.VDLIsZero() {
+		if err := enc.NextField(6); err != nil {
+			return err
+		}
+		if err := x.CancelReason.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
 	if err := enc.NextField(-1); err != nil {
 		return err
 	}
@@ -330,7 +472,7 @@ func (x Response) VDLWrite(enc vdl.Encoder) error {
 
 func (x *Response) VDLRead(dec vdl.Decoder) error {
 	*x = Response{}
-	if err := dec.StartValue(__VDLType_struct_5); err != nil {
+	if err := dec.StartValue(__VDLType_struct_10); err != nil {
 		return err
 	}
 	decType := dec.Type()
@@ -342,8 +484,8 @@ func (x *Response) VDLRead(dec vdl.Decoder) error {
 		case index == -1:
 			return dec.FinishValue()
 		}
-		if decType != __VDLType_struct_5 {
-			index = __VDLType_struct_5.FieldIndexByName(decType.Field(index).Name)
+		if decType != __VDLType_struct_10 {
+			index = __VDLType_struct_10.FieldIndexByName(decType.Field(index).Name)
 			if index == -1 {
 				if err := dec.SkipValue(); err != nil {
 					return err
@@ -381,6 +523,223 @@ func (x *Response) VDLRead(dec vdl.Decoder) error {
 			default:
 				x.AckBlessings = value
 			}
+		case 5:
+			switch value, err := dec.ReadValueUint(64); {
+			case err != nil:
+				return err
+			default:
+				x.NumExtraStreamResults = value
+			}
+		case 6:
+			if err := x.CancelReason.VDLRead(dec); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Hop describes one RPC in a chain of RPCs, each made while handling the
+// previous one.
+type Hop struct {
+	// Name is the object name the RPC was made to.
+	Name string
+	// Method is the method that was invoked.
+	Method string
+	// Budget is the time remaining before the deadline of the RPC when it was
+	// started, or zero if the RPC had no deadline.  It is negative if the
+	// deadline had already passed.
+	Budget time.Duration
+}
+
+func (Hop) __VDLReflect(struct {
+	Name string `vdl:"v.io/v23/rpc.Hop"`
+}) {
+}
+
+func (x Hop) VDLIsZero() bool {
+	return x == Hop{}
+}
+
+func (x Hop) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_6); err != nil {
+		return err
+	}
+	if x.Name != "" {
+		if err := enc.NextFieldValueString(0, vdl.StringType, x.Name); err != nil {
+			return err
+		}
+	}
+	if x.Method != "" {
+		if err := enc.NextFieldValueString(1, vdl.StringType, x.Method); err != nil {
+			return err
+		}
+	}
+	if x.Budget != 0 {
+		if err := enc.NextField(2); err != nil {
+			return err
+		}
+		var wire vdltime.Duration
+		if err := vdltime.DurationFromNative(&wire, x.Budget); err != nil {
+			return err
+		}
+		if err := wire.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *Hop) VDLRead(dec vdl.Decoder) error {
+	*x = Hop{}
+	if err := dec.StartValue(__VDLType_struct_6); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_6 {
+			index = __VDLType_struct_6.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.Name = value
+			}
+		case 1:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.Method = value
+			}
+		case 2:
+			var wire vdltime.Duration
+			if err := wire.VDLRead(dec); err != nil {
+				return err
+			}
+			if err := vdltime.DurationToNative(wire, &x.Budget); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// CancelReason is the wire form of context.CancelReason.
+type CancelReason struct {
+	// Canceler identifies who canceled the context.
+	Canceler string
+	// Message describes why the context was canceled.
+	Message string
+	// Error is the error, if any, that caused the cancellation.
+	Error error
+}
+
+func (CancelReason) __VDLReflect(struct {
+	Name string `vdl:"v.io/v23/rpc.CancelReason"`
+}) {
+}
+
+func (x CancelReason) VDLIsZero() bool {
+	if x.Canceler != "" {
+		return false
+	}
+	if x.Message != "" {
+		return false
+	}
+	if x.Error != nil {
+		return false
+	}
+	return true
+}
+
+func (x CancelReason) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_8); err != nil {
+		return err
+	}
+	if x.Canceler != "" {
+		if err := enc.NextFieldValueString(0, vdl.StringType, x.Canceler); err != nil {
+			return err
+		}
+	}
+	if x.Message != "" {
+		if err := enc.NextFieldValueString(1, vdl.StringType, x.Message); err != nil {
+			return err
+		}
+	}
+	if x.Error != nil {
+		if err := enc.NextField(2); err != nil {
+			return err
+		}
+		if err := verror.VDLWrite(enc, x.Error); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *CancelReason) VDLRead(dec vdl.Decoder) error {
+	*x = CancelReason{}
+	if err := dec.StartValue(__VDLType_struct_8); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_8 {
+			index = __VDLType_struct_8.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.Canceler = value
+			}
+		case 1:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.Message = value
+			}
+		case 2:
+			if err := verror.VDLRead(dec, &x.Error); err != nil {
+				return err
+			}
 		}
 	}
 }
@@ -395,12 +754,17 @@ const ReservedMethodSignature = "__MethodSignature"
 
 // Hold type definitions in package-level variables, for better performance.
 var (
-	__VDLType_struct_1 *vdl.Type
-	__VDLType_struct_2 *vdl.Type
-	__VDLType_struct_3 *vdl.Type
-	__VDLType_struct_4 *vdl.Type
-	__VDLType_struct_5 *vdl.Type
-	__VDLType_struct_6 *vdl.Type
+	__VDLType_struct_1  *vdl.Type
+	__VDLType_struct_2  *vdl.Type
+	__VDLType_struct_3  *vdl.Type
+	__VDLType_struct_4  *vdl.Type
+	__VDLType_list_5    *vdl.Type
+	__VDLType_struct_6  *vdl.Type
+	__VDLType_struct_7  *vdl.Type
+	__VDLType_struct_8  *vdl.Type
+	__VDLType_struct_9  *vdl.Type
+	__VDLType_struct_10 *vdl.Type
+	__VDLType_struct_11 *vdl.Type
 )
 
 var __VDLInitCalled bool
@@ -427,14 +791,21 @@ func __VDLInit() struct{} {
 	// Register types.
 	vdl.Register((*Request)(nil))
 	vdl.Register((*Response)(nil))
+	vdl.Register((*Hop)(nil))
+	vdl.Register((*CancelReason)(nil))
 
 	// Initialize type definitions.
 	__VDLType_struct_1 = vdl.TypeOf((*Request)(nil)).Elem()
 	__VDLType_struct_2 = vdl.TypeOf((*vdltime.WireDeadline)(nil)).Elem()
 	__VDLType_struct_3 = vdl.TypeOf((*security.WireBlessings)(nil)).Elem()
 	__VDLType_struct_4 = vdl.TypeOf((*vtrace.Request)(nil)).Elem()
-	__VDLType_struct_5 = vdl.TypeOf((*Response)(nil)).Elem()
-	__VDLType_struct_6 = vdl.TypeOf((*vtrace.Response)(nil)).Elem()
+	__VDLType_list_5 = vdl.TypeOf((*[]Hop)(nil))
+	__VDLType_struct_6 = vdl.TypeOf((*Hop)(nil)).Elem()
+	__VDLType_struct_7 = vdl.TypeOf((*vdltime.Duration)(nil)).Elem()
+	__VDLType_struct_8 = vdl.TypeOf((*CancelReason)(nil)).Elem()
+	__VDLType_struct_9 = vdl.TypeOf((*naming.GlobLimits)(nil)).Elem()
+	__VDLType_struct_10 = vdl.TypeOf((*Response)(nil)).Elem()
+	__VDLType_struct_11 = vdl.TypeOf((*vtrace.Response)(nil)).Elem()
 
 	return struct{}{}
 }
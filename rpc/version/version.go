@@ -47,6 +47,10 @@ const (
 	// RPCVersion14 adds the setup message to the channel binding during
 	// connection setup.
 	RPCVersion14
+
+	// RPCVersion15 adds priority classes to flows, and weighted scheduling of
+	// the flows on a connection.
+	RPCVersion15
 )
 
 // RPCVersionRange allows you to optionally specify a range of versions to
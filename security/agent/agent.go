@@ -0,0 +1,179 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package agent
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"v.io/v23/flow/unix"
+	"v.io/v23/security"
+	"v.io/v23/verror"
+	"v.io/v23/vom"
+)
+
+const pkgPath = "v.io/v23/security/agent"
+
+var (
+	errInsecureDir = verror.Register(pkgPath+".errInsecureDir", verror.NoRetry, "{1:}{2:} directory {3} is accessible by other users{:_}")
+)
+
+// Agent is a reference signing agent, which holds its keys in the memory of
+// the process that serves it, as security.Signers.  It is meant for tests and
+// for running the agent in a separate, long-lived process on behalf of the
+// processes that connect to it.
+//
+// Multiple goroutines may invoke methods on an Agent simultaneously.
+type Agent struct {
+	// uid is the user whose processes may connect to the agent.
+	uid  uint32
+	mu   sync.RWMutex
+	keys map[string]security.Signer // GUARDED_BY(mu)
+}
+
+// NewAgent returns an Agent that holds no keys, and only serves the processes
+// of the current user.
+func NewAgent() *Agent {
+	return &Agent{uid: uint32(os.Getuid()), keys: make(map[string]security.Signer)}
+}
+
+// AddKey adds signer to the keys of the agent, under name, replacing the key
+// previously added under the same name, if any.
+func (a *Agent) AddKey(name string, signer security.Signer) {
+	a.mu.Lock()
+	a.keys[name] = signer
+	a.mu.Unlock()
+}
+
+// RemoveKey removes the key with the given name from the agent.
+func (a *Agent) RemoveKey(name string) {
+	a.mu.Lock()
+	delete(a.keys, name)
+	a.mu.Unlock()
+}
+
+// Listen returns a listener on a Unix socket created at path, which only the
+// current user may connect to.  The directory of path is created with mode
+// 0700 if it doesn't exist, and must not be accessible by other users
+// otherwise, so that the socket is never reachable by them, even before its
+// own mode is set.
+func Listen(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if fi.Mode().Perm()&0077 != 0 {
+		return nil, verror.New(errInsecureDir, nil, dir)
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Serve accepts connections on l and serves the requests received on each
+// of them, until l is closed.  It returns the error returned by l.Accept.
+func (a *Agent) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go a.ServeConn(conn)
+	}
+}
+
+// ServeConn serves the requests received on conn until the client closes
+// it, or the stream breaks, then closes conn.  If conn is a Unix socket, it
+// is closed right away unless the client runs as the same user as the agent,
+// on systems that report the credentials of the peers of sockets.
+func (a *Agent) ServeConn(conn io.ReadWriteCloser) {
+	defer conn.Close()
+	if uc, ok := conn.(*net.UnixConn); ok && !a.allowed(uc) {
+		return
+	}
+	enc, dec := vom.NewEncoder(conn), vom.NewDecoder(conn)
+	for {
+		var req Request
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+		if err := enc.Encode(a.handle(req)); err != nil {
+			return
+		}
+	}
+}
+
+// allowed returns true if the peer of c runs as the user served by a, or if
+// the system doesn't report its credentials.
+func (a *Agent) allowed(c *net.UnixConn) bool {
+	creds, err := unix.SocketCredentials(c)
+	if err != nil {
+		return verror.ErrorID(err) == unix.ErrNoCredentials.ID
+	}
+	return creds.UID == a.uid
+}
+
+func (a *Agent) handle(req Request) Response {
+	switch req := req.(type) {
+	case RequestListKeys:
+		return ResponseKeys{a.names()}
+	case RequestPublicKey:
+		signer, err := a.signer(req.Value)
+		if err != nil {
+			return ResponseError{err}
+		}
+		der, err := signer.PublicKey().MarshalBinary()
+		if err != nil {
+			return ResponseError{err}
+		}
+		return ResponsePublicKey{der}
+	case RequestSign:
+		signer, err := a.signer(req.Value.Key)
+		if err != nil {
+			return ResponseError{err}
+		}
+		sig, err := signer.Sign(req.Value.Purpose, req.Value.Message)
+		if err != nil {
+			return ResponseError{err}
+		}
+		return ResponseSignature{sig}
+	}
+	return ResponseError{verror.New(verror.ErrNotImplemented, nil, req.Name())}
+}
+
+func (a *Agent) names() []string {
+	a.mu.RLock()
+	names := make([]string, 0, len(a.keys))
+	for name := range a.keys {
+		names = append(names, name)
+	}
+	a.mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+func (a *Agent) signer(name string) (security.Signer, error) {
+	a.mu.RLock()
+	signer, ok := a.keys[name]
+	a.mu.RUnlock()
+	if !ok {
+		return nil, NewErrUnknownKey(nil, name)
+	}
+	return signer, nil
+}
@@ -0,0 +1,605 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file was auto-generated by the vanadium vdl tool.
+// Package: agent
+
+// Package agent defines the protocol spoken with a signing agent, a process
+// that holds private keys and signs messages on behalf of other processes on
+// the same machine, in the spirit of ssh-agent, so that the keys never enter
+// the memory of those processes.
+//
+// Clients connect to the agent over a Unix domain socket and send a stream
+// of VOM-encoded Requests, to each of which the agent replies, in order,
+// with a VOM-encoded Response.
+package agent
+
+import (
+	"fmt"
+	"v.io/v23/context"
+	"v.io/v23/i18n"
+	"v.io/v23/security"
+	"v.io/v23/vdl"
+	"v.io/v23/verror"
+)
+
+var _ = __VDLInit() // Must be first; see __VDLInit comments for details.
+
+//////////////////////////////////////////////////
+// Type definitions
+
+// SignRequest asks the agent to sign a message with one of its keys.
+type SignRequest struct {
+	Key     string // Name of the key, as returned by ListKeys.
+	Purpose []byte // Purpose of the signature, see security.Signer.
+	Message []byte // Message to sign.
+}
+
+func (SignRequest) __VDLReflect(struct {
+	Name string `vdl:"v.io/v23/security/agent.SignRequest"`
+}) {
+}
+
+func (x SignRequest) VDLIsZero() bool {
+	if x.Key != "" {
+		return false
+	}
+	if len(x.Purpose) != 0 {
+		return false
+	}
+	if len(x.Message) != 0 {
+		return false
+	}
+	return true
+}
+
+func (x SignRequest) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_1); err != nil {
+		return err
+	}
+	if x.Key != "" {
+		if err := enc.NextFieldValueString(0, vdl.StringType, x.Key); err != nil {
+			return err
+		}
+	}
+	if len(x.Purpose) != 0 {
+		if err := enc.NextFieldValueBytes(1, __VDLType_list_2, x.Purpose); err != nil {
+			return err
+		}
+	}
+	if len(x.Message) != 0 {
+		if err := enc.NextFieldValueBytes(2, __VDLType_list_2, x.Message); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *SignRequest) VDLRead(dec vdl.Decoder) error {
+	*x = SignRequest{}
+	if err := dec.StartValue(__VDLType_struct_1); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_1 {
+			index = __VDLType_struct_1.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			switch value, err := dec.ReadValueString(); {
+			case err != nil:
+				return err
+			default:
+				x.Key = value
+			}
+		case 1:
+			if err := dec.ReadValueBytes(-1, &x.Purpose); err != nil {
+				return err
+			}
+		case 2:
+			if err := dec.ReadValueBytes(-1, &x.Message); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+type (
+	// Request represents any single field of the Request union type.
+	//
+	// Request is a request sent by a client to a signing agent.
+	Request interface {
+		// Index returns the field index.
+		Index() int
+		// Interface returns the field value as an interface.
+		Interface() interface{}
+		// Name returns the field name.
+		Name() string
+		// __VDLReflect describes the Request union type.
+		__VDLReflect(__RequestReflect)
+		VDLIsZero() bool
+		VDLWrite(vdl.Encoder) error
+	}
+	// RequestListKeys represents field ListKeys of the Request union type.
+	//
+	// ListKeys asks for the names of the keys held by the agent.  The value
+	// is ignored.
+	RequestListKeys struct{ Value bool }
+	// RequestPublicKey represents field PublicKey of the Request union type.
+	//
+	// PublicKey asks for the DER-encoded PKIX public key of the named key.
+	RequestPublicKey struct{ Value string }
+	// RequestSign represents field Sign of the Request union type.
+	//
+	// Sign asks for a signature, computed as security.Signer.Sign does.
+	RequestSign struct{ Value SignRequest }
+	// __RequestReflect describes the Request union type.
+	__RequestReflect struct {
+		Name  string `vdl:"v.io/v23/security/agent.Request"`
+		Type  Request
+		Union struct {
+			ListKeys  RequestListKeys
+			PublicKey RequestPublicKey
+			Sign      RequestSign
+		}
+	}
+)
+
+func (x RequestListKeys) Index() int                    { return 0 }
+func (x RequestListKeys) Interface() interface{}        { return x.Value }
+func (x RequestListKeys) Name() string                  { return "ListKeys" }
+func (x RequestListKeys) __VDLReflect(__RequestReflect) {}
+
+func (x RequestPublicKey) Index() int                    { return 1 }
+func (x RequestPublicKey) Interface() interface{}        { return x.Value }
+func (x RequestPublicKey) Name() string                  { return "PublicKey" }
+func (x RequestPublicKey) __VDLReflect(__RequestReflect) {}
+
+func (x RequestSign) Index() int                    { return 2 }
+func (x RequestSign) Interface() interface{}        { return x.Value }
+func (x RequestSign) Name() string                  { return "Sign" }
+func (x RequestSign) __VDLReflect(__RequestReflect) {}
+
+func (x RequestListKeys) VDLIsZero() bool {
+	return !x.Value
+}
+
+func (x RequestPublicKey) VDLIsZero() bool {
+	return false
+}
+
+func (x RequestSign) VDLIsZero() bool {
+	return false
+}
+
+func (x RequestListKeys) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_union_3); err != nil {
+		return err
+	}
+	if err := enc.NextFieldValueBool(0, vdl.BoolType, x.Value); err != nil {
+		return err
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x RequestPublicKey) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_union_3); err != nil {
+		return err
+	}
+	if err := enc.NextFieldValueString(1, vdl.StringType, x.Value); err != nil {
+		return err
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x RequestSign) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_union_3); err != nil {
+		return err
+	}
+	if err := enc.NextField(2); err != nil {
+		return err
+	}
+	if err := x.Value.VDLWrite(enc); err != nil {
+		return err
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func VDLReadRequest(dec vdl.Decoder, x *Request) error {
+	if err := dec.StartValue(__VDLType_union_3); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	index, err := dec.NextField()
+	switch {
+	case err != nil:
+		return err
+	case index == -1:
+		return fmt.Errorf("missing field in union %T, from %v", x, decType)
+	}
+	if decType != __VDLType_union_3 {
+		name := decType.Field(index).Name
+		index = __VDLType_union_3.FieldIndexByName(name)
+		if index == -1 {
+			return fmt.Errorf("field %q not in union %T, from %v", name, x, decType)
+		}
+	}
+	switch index {
+	case 0:
+		var field RequestListKeys
+		switch value, err := dec.ReadValueBool(); {
+		case err != nil:
+			return err
+		default:
+			field.Value = value
+		}
+		*x = field
+	case 1:
+		var field RequestPublicKey
+		switch value, err := dec.ReadValueString(); {
+		case err != nil:
+			return err
+		default:
+			field.Value = value
+		}
+		*x = field
+	case 2:
+		var field RequestSign
+		if err := field.Value.VDLRead(dec); err != nil {
+			return err
+		}
+		*x = field
+	}
+	switch index, err := dec.NextField(); {
+	case err != nil:
+		return err
+	case index != -1:
+		return fmt.Errorf("extra field %d in union %T, from %v", index, x, dec.Type())
+	}
+	return dec.FinishValue()
+}
+
+type (
+	// Response represents any single field of the Response union type.
+	//
+	// Response is the reply of a signing agent to a Request.
+	Response interface {
+		// Index returns the field index.
+		Index() int
+		// Interface returns the field value as an interface.
+		Interface() interface{}
+		// Name returns the field name.
+		Name() string
+		// __VDLReflect describes the Response union type.
+		__VDLReflect(__ResponseReflect)
+		VDLIsZero() bool
+		VDLWrite(vdl.Encoder) error
+	}
+	// ResponseError represents field Error of the Response union type.
+	//
+	// Error reports that the Request failed.
+	ResponseError struct{ Value error }
+	// ResponseKeys represents field Keys of the Response union type.
+	//
+	// Keys is the reply to ListKeys.
+	ResponseKeys struct{ Value []string }
+	// ResponsePublicKey represents field PublicKey of the Response union type.
+	//
+	// PublicKey is the reply to PublicKey.
+	ResponsePublicKey struct{ Value []byte }
+	// ResponseSignature represents field Signature of the Response union type.
+	//
+	// Signature is the reply to Sign.
+	ResponseSignature struct{ Value security.Signature }
+	// __ResponseReflect describes the Response union type.
+	__ResponseReflect struct {
+		Name  string `vdl:"v.io/v23/security/agent.Response"`
+		Type  Response
+		Union struct {
+			Error     ResponseError
+			Keys      ResponseKeys
+			PublicKey ResponsePublicKey
+			Signature ResponseSignature
+		}
+	}
+)
+
+func (x ResponseError) Index() int                     { return 0 }
+func (x ResponseError) Interface() interface{}         { return x.Value }
+func (x ResponseError) Name() string                   { return "Error" }
+func (x ResponseError) __VDLReflect(__ResponseReflect) {}
+
+func (x ResponseKeys) Index() int                     { return 1 }
+func (x ResponseKeys) Interface() interface{}         { return x.Value }
+func (x ResponseKeys) Name() string                   { return "Keys" }
+func (x ResponseKeys) __VDLReflect(__ResponseReflect) {}
+
+func (x ResponsePublicKey) Index() int                     { return 2 }
+func (x ResponsePublicKey) Interface() interface{}         { return x.Value }
+func (x ResponsePublicKey) Name() string                   { return "PublicKey" }
+func (x ResponsePublicKey) __VDLReflect(__ResponseReflect) {}
+
+func (x ResponseSignature) Index() int                     { return 3 }
+func (x ResponseSignature) Interface() interface{}         { return x.Value }
+func (x ResponseSignature) Name() string                   { return "Signature" }
+func (x ResponseSignature) __VDLReflect(__ResponseReflect) {}
+
+func (x ResponseError) VDLIsZero() bool {
+	return x.Value == nil
+}
+
+func (x ResponseKeys) VDLIsZero() bool {
+	return false
+}
+
+func (x ResponsePublicKey) VDLIsZero() bool {
+	return false
+}
+
+func (x ResponseSignature) VDLIsZero() bool {
+	return false
+}
+
+func (x ResponseError) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_union_4); err != nil {
+		return err
+	}
+	if err := enc.NextField(0); err != nil {
+		return err
+	}
+	if err := verror.VDLWrite(enc, x.Value); err != nil {
+		return err
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x ResponseKeys) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_union_4); err != nil {
+		return err
+	}
+	if err := enc.NextField(1); err != nil {
+		return err
+	}
+	if err := __VDLWriteAnon_list_1(enc, x.Value); err != nil {
+		return err
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x ResponsePublicKey) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_union_4); err != nil {
+		return err
+	}
+	if err := enc.NextFieldValueBytes(2, __VDLType_list_2, x.Value); err != nil {
+		return err
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x ResponseSignature) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_union_4); err != nil {
+		return err
+	}
+	if err := enc.NextField(3); err != nil {
+		return err
+	}
+	if err := x.Value.VDLWrite(enc); err != nil {
+		return err
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func __VDLWriteAnon_list_1(enc vdl.Encoder, x []string) error {
+	if err := enc.StartValue(__VDLType_list_5); err != nil {
+		return err
+	}
+	if err := enc.SetLenHint(len(x)); err != nil {
+		return err
+	}
+	for _, elem := range x {
+		if err := enc.NextEntryValueString(vdl.StringType, elem); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextEntry(true); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func VDLReadResponse(dec vdl.Decoder, x *Response) error {
+	if err := dec.StartValue(__VDLType_union_4); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	index, err := dec.NextField()
+	switch {
+	case err != nil:
+		return err
+	case index == -1:
+		return fmt.Errorf("missing field in union %T, from %v", x, decType)
+	}
+	if decType != __VDLType_union_4 {
+		name := decType.Field(index).Name
+		index = __VDLType_union_4.FieldIndexByName(name)
+		if index == -1 {
+			return fmt.Errorf("field %q not in union %T, from %v", name, x, decType)
+		}
+	}
+	switch index {
+	case 0:
+		var field ResponseError
+		if err := verror.VDLRead(dec, &field.Value); err != nil {
+			return err
+		}
+		*x = field
+	case 1:
+		var field ResponseKeys
+		if err := __VDLReadAnon_list_1(dec, &field.Value); err != nil {
+			return err
+		}
+		*x = field
+	case 2:
+		var field ResponsePublicKey
+		if err := dec.ReadValueBytes(-1, &field.Value); err != nil {
+			return err
+		}
+		*x = field
+	case 3:
+		var field ResponseSignature
+		if err := field.Value.VDLRead(dec); err != nil {
+			return err
+		}
+		*x = field
+	}
+	switch index, err := dec.NextField(); {
+	case err != nil:
+		return err
+	case index != -1:
+		return fmt.Errorf("extra field %d in union %T, from %v", index, x, dec.Type())
+	}
+	return dec.FinishValue()
+}
+
+func __VDLReadAnon_list_1(dec vdl.Decoder, x *[]string) error {
+	if err := dec.StartValue(__VDLType_list_5); err != nil {
+		return err
+	}
+	if len := dec.LenHint(); len > 0 {
+		*x = make([]string, 0, len)
+	} else {
+		*x = nil
+	}
+	for {
+		switch done, elem, err := dec.NextEntryValueString(); {
+		case err != nil:
+			return err
+		case done:
+			return dec.FinishValue()
+		default:
+			*x = append(*x, elem)
+		}
+	}
+}
+
+//////////////////////////////////////////////////
+// Error definitions
+
+var (
+
+	// UnknownKey indicates that the agent holds no key with the requested
+	// name.
+	ErrUnknownKey = verror.Register("v.io/v23/security/agent.UnknownKey", verror.NoRetry, "{1:}{2:} unknown key {3}{:_}")
+	// UnexpectedResponse indicates that the agent replied to a Request with
+	// a Response of the wrong kind.
+	ErrUnexpectedResponse = verror.Register("v.io/v23/security/agent.UnexpectedResponse", verror.NoRetry, "{1:}{2:} unexpected {3} response, want {4}{:_}")
+	// InvalidSignature indicates that the agent returned a signature that
+	// does not verify with the public key of the requested key.
+	ErrInvalidSignature = verror.Register("v.io/v23/security/agent.InvalidSignature", verror.NoRetry, "{1:}{2:} invalid signature by key {3}{:_}")
+)
+
+// NewErrUnknownKey returns an error with the ErrUnknownKey ID.
+func NewErrUnknownKey(ctx *context.T, key string) error {
+	return verror.New(ErrUnknownKey, ctx, key)
+}
+
+// NewErrUnexpectedResponse returns an error with the ErrUnexpectedResponse ID.
+func NewErrUnexpectedResponse(ctx *context.T, got string, want string) error {
+	return verror.New(ErrUnexpectedResponse, ctx, got, want)
+}
+
+// NewErrInvalidSignature returns an error with the ErrInvalidSignature ID.
+func NewErrInvalidSignature(ctx *context.T, key string) error {
+	return verror.New(ErrInvalidSignature, ctx, key)
+}
+
+// Hold type definitions in package-level variables, for better performance.
+var (
+	__VDLType_struct_1 *vdl.Type
+	__VDLType_list_2   *vdl.Type
+	__VDLType_union_3  *vdl.Type
+	__VDLType_union_4  *vdl.Type
+	__VDLType_list_5   *vdl.Type
+	__VDLType_struct_6 *vdl.Type
+)
+
+var __VDLInitCalled bool
+
+// __VDLInit performs vdl initialization.  It is safe to call multiple times.
+// If you have an init ordering issue, just insert the following line verbatim
+// into your source files in this package, right after the "package foo" clause:
+//
+//    var _ = __VDLInit()
+//
+// The purpose of this function is to ensure that vdl initialization occurs in
+// the right order, and very early in the init sequence.  In particular, vdl
+// registration and package variable initialization needs to occur before
+// functions like vdl.TypeOf will work properly.
+//
+// This function returns a dummy value, so that it can be used to initialize the
+// first var in the file, to take advantage of Go's defined init order.
+func __VDLInit() struct{} {
+	if __VDLInitCalled {
+		return struct{}{}
+	}
+	__VDLInitCalled = true
+
+	// Register types.
+	vdl.Register((*SignRequest)(nil))
+	vdl.Register((*Request)(nil))
+	vdl.Register((*Response)(nil))
+
+	// Initialize type definitions.
+	__VDLType_struct_1 = vdl.TypeOf((*SignRequest)(nil)).Elem()
+	__VDLType_list_2 = vdl.TypeOf((*[]byte)(nil))
+	__VDLType_union_3 = vdl.TypeOf((*Request)(nil))
+	__VDLType_union_4 = vdl.TypeOf((*Response)(nil))
+	__VDLType_list_5 = vdl.TypeOf((*[]string)(nil))
+	__VDLType_struct_6 = vdl.TypeOf((*security.Signature)(nil)).Elem()
+
+	// Set error format strings.
+	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrUnknownKey.ID), "{1:}{2:} unknown key {3}{:_}")
+	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrUnexpectedResponse.ID), "{1:}{2:} unexpected {3} response, want {4}{:_}")
+	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrInvalidSignature.ID), "{1:}{2:} invalid signature by key {3}{:_}")
+
+	return struct{}{}
+}
@@ -0,0 +1,78 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package agent
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func listen(t *testing.T) (string, net.Listener, func()) {
+	dir, err := ioutil.TempDir("", "agent_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "agent.sock")
+	l, err := Listen(path)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return path, l, func() {
+		l.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestOtherUserRejected(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("peer credentials are only supported on linux")
+	}
+	path, l, cleanup := listen(t)
+	defer cleanup()
+	a := NewAgent()
+	a.uid++
+	go a.Serve(l)
+
+	c, err := Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.ListKeys(); err == nil {
+		t.Errorf("the agent served a client of another user")
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { callTimeout = timeout }(callTimeout)
+	callTimeout = 10 * time.Millisecond
+	path, l, cleanup := listen(t)
+	defer cleanup()
+	// The agent accepts connections, but never replies.
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	c, err := Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.ListKeys(); err == nil {
+		t.Errorf("ListKeys succeeded without a reply")
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package agent_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"v.io/v23/security"
+	"v.io/v23/security/agent"
+	"v.io/v23/verror"
+)
+
+// startAgent serves a on a new socket, and returns the path of the socket
+// and a function that stops serving.
+func startAgent(t *testing.T, a *agent.Agent, dir string) (string, func()) {
+	path := filepath.Join(dir, "agent.sock")
+	l, err := agent.Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("got (%v, %v), want a socket only accessible by the user", fi.Mode(), err)
+	}
+	done := make(chan struct{})
+	go func() {
+		a.Serve(l)
+		close(done)
+	}()
+	return path, func() {
+		l.Close()
+		<-done
+	}
+}
+
+func newECDSASigner(t *testing.T) security.Signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return security.NewInMemoryECDSASigner(key)
+}
+
+func newEd25519Signer(t *testing.T) security.Signer {
+	signer, err := security.GenerateEd25519Signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func tempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "agent_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestAgent(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	a := agent.NewAgent()
+	keys := map[string]security.Signer{
+		"ecdsa":   newECDSASigner(t),
+		"ed25519": newEd25519Signer(t),
+	}
+	for name, signer := range keys {
+		a.AddKey(name, signer)
+	}
+	path, stop := startAgent(t, a, dir)
+	defer stop()
+
+	c, err := agent.Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	names, err := c.ListKeys()
+	if want := []string{"ecdsa", "ed25519"}; err != nil || !reflect.DeepEqual(names, want) {
+		t.Errorf("got (%v, %v), want %v", names, err, want)
+	}
+	for name, key := range keys {
+		signer, err := agent.NewSigner(c, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := signer.PublicKey().String(), key.PublicKey().String(); got != want {
+			t.Errorf("%v: got public key %v, want %v", name, got, want)
+		}
+		message := []byte("message")
+		sig, err := signer.Sign([]byte(security.SignatureForMessageSigning), message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sig.Verify(key.PublicKey(), message) || sig.Verify(key.PublicKey(), []byte("other")) {
+			t.Errorf("%v: invalid signature %v", name, sig)
+		}
+	}
+	if _, err := agent.NewSigner(c, "missing"); verror.ErrorID(err) != agent.ErrUnknownKey.ID {
+		t.Errorf("got %v, want %v", err, agent.ErrUnknownKey.ID)
+	}
+}
+
+func TestAgentPrincipal(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	a := agent.NewAgent()
+	a.AddKey("root", newEd25519Signer(t))
+	a.AddKey("user", newECDSASigner(t))
+	path, stop := startAgent(t, a, dir)
+	defer stop()
+
+	c, err := agent.Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	principal := func(name string) security.Principal {
+		signer, err := agent.NewSigner(c, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := security.CreatePrincipal(signer, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	root, user := principal("root"), principal("user")
+	self, err := root.BlessSelf("root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := root.Bless(user.PublicKey(), self, "user", security.UnconstrainedUse())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "root:user"; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(b.PublicKey(), user.PublicKey()) {
+		t.Errorf("got %v, want %v", b.PublicKey(), user.PublicKey())
+	}
+}
+
+func TestAgentReplacedKey(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	a := agent.NewAgent()
+	a.AddKey("key", newECDSASigner(t))
+	path, stop := startAgent(t, a, dir)
+	defer stop()
+
+	c, err := agent.Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	signer, err := agent.NewSigner(c, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.AddKey("key", newEd25519Signer(t))
+	if _, err := signer.Sign(nil, []byte("message")); verror.ErrorID(err) != agent.ErrInvalidSignature.ID {
+		t.Errorf("got %v, want %v", err, agent.ErrInvalidSignature.ID)
+	}
+	a.RemoveKey("key")
+	if _, err := signer.Sign(nil, []byte("message")); verror.ErrorID(err) != agent.ErrUnknownKey.ID {
+		t.Errorf("got %v, want %v", err, agent.ErrUnknownKey.ID)
+	}
+}
+
+func TestClientRedials(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	a := agent.NewAgent()
+	a.AddKey("key", newEd25519Signer(t))
+
+	// Serve connections one at a time, so that the test can break them.
+	path := filepath.Join(dir, "agent.sock")
+	l, err := agent.Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	conns := make(chan net.Conn)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				close(conns)
+				return
+			}
+			conns <- conn
+		}
+	}()
+	serve := func() net.Conn {
+		conn := <-conns
+		go a.ServeConn(conn)
+		return conn
+	}
+
+	c, err := agent.Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	conn := serve()
+	signer, err := agent.NewSigner(c, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if _, err := signer.Sign(nil, []byte("message")); err == nil {
+		t.Errorf("Sign succeeded over a closed connection")
+	}
+	go serve()
+	if _, err := signer.Sign(nil, []byte("message")); err != nil {
+		t.Errorf("Sign failed after the agent came back: %v", err)
+	}
+}
+
+func TestListenDirectory(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	// Missing directories are created for the user only.
+	path := filepath.Join(dir, "sub", "agent.sock")
+	l, err := agent.Listen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	if fi, err := os.Stat(filepath.Dir(path)); err != nil || fi.Mode().Perm() != 0700 {
+		t.Errorf("got (%v, %v), want a directory only accessible by the user", fi.Mode(), err)
+	}
+
+	// Directories that other users can access are rejected.
+	shared := filepath.Join(dir, "shared")
+	if err := os.Mkdir(shared, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(shared, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if l, err := agent.Listen(filepath.Join(shared, "agent.sock")); err == nil {
+		l.Close()
+		t.Errorf("Listen succeeded in a shared directory")
+	}
+}
+
+func TestClientConcurrency(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	a := agent.NewAgent()
+	a.AddKey("key", newEd25519Signer(t))
+	path, stop := startAgent(t, a, dir)
+	defer stop()
+
+	c, err := agent.Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	signer, err := agent.NewSigner(c, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			message := []byte{byte(i)}
+			for j := 0; j < 10; j++ {
+				sig, err := signer.Sign(nil, message)
+				if err != nil || !sig.Verify(signer.PublicKey(), message) {
+					t.Errorf("got (%v, %v)", sig, err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,174 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package agent
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"v.io/v23/security"
+	"v.io/v23/vom"
+)
+
+// callTimeout bounds the time taken by a request and its response, so that
+// an unresponsive agent doesn't block the other requests forever.
+var callTimeout = time.Minute
+
+// Client is a connection to a signing agent.
+//
+// Requests are sent one at a time over a single connection.  If the
+// connection fails, the request fails and the next one dials the agent
+// again, so that clients survive restarts of the agent.
+//
+// Multiple goroutines may invoke methods on a Client simultaneously.
+type Client struct {
+	path string
+	mu   sync.Mutex
+	conn net.Conn     // GUARDED_BY(mu)
+	enc  *vom.Encoder // GUARDED_BY(mu)
+	dec  *vom.Decoder // GUARDED_BY(mu)
+}
+
+// Dial returns a Client connected to the signing agent listening on the Unix
+// socket at path.
+func Dial(path string) (*Client, error) {
+	c := &Client{path: path}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.dialLocked(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ListKeys returns the names of the keys held by the agent, in sorted order.
+func (c *Client) ListKeys() ([]string, error) {
+	resp, err := c.call(RequestListKeys{true})
+	if err != nil {
+		return nil, err
+	}
+	keys, ok := resp.(ResponseKeys)
+	if !ok {
+		return nil, NewErrUnexpectedResponse(nil, resp.Name(), "Keys")
+	}
+	return keys.Value, nil
+}
+
+// PublicKey returns the public key of the named key.
+func (c *Client) PublicKey(key string) (security.PublicKey, error) {
+	resp, err := c.call(RequestPublicKey{key})
+	if err != nil {
+		return nil, err
+	}
+	der, ok := resp.(ResponsePublicKey)
+	if !ok {
+		return nil, NewErrUnexpectedResponse(nil, resp.Name(), "PublicKey")
+	}
+	return security.UnmarshalPublicKey(der.Value)
+}
+
+// Sign returns the signature of message by the named key, for purpose.
+func (c *Client) Sign(key string, purpose, message []byte) (security.Signature, error) {
+	resp, err := c.call(RequestSign{SignRequest{Key: key, Purpose: purpose, Message: message}})
+	if err != nil {
+		return security.Signature{}, err
+	}
+	sig, ok := resp.(ResponseSignature)
+	if !ok {
+		return security.Signature{}, NewErrUnexpectedResponse(nil, resp.Name(), "Signature")
+	}
+	return sig.Value, nil
+}
+
+// Close closes the connection to the agent.  Requests made after Close dial
+// the agent again.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn, c.enc, c.dec = nil, nil, nil
+	return err
+}
+
+// call sends req to the agent and returns its response, or the error that
+// it carries.
+func (c *Client) call(req Request) (Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		if err := c.dialLocked(); err != nil {
+			return nil, err
+		}
+	}
+	var resp Response
+	err := c.conn.SetDeadline(time.Now().Add(callTimeout))
+	if err == nil {
+		err = c.enc.Encode(req)
+	}
+	if err == nil {
+		err = c.dec.Decode(&resp)
+	}
+	if err != nil {
+		// The stream is unusable once a request or a response has been
+		// partially sent or received.
+		c.conn.Close()
+		c.conn, c.enc, c.dec = nil, nil, nil
+		return nil, err
+	}
+	if e, ok := resp.(ResponseError); ok {
+		return nil, e.Value
+	}
+	return resp, nil
+}
+
+func (c *Client) dialLocked() error {
+	conn, err := net.DialTimeout("unix", c.path, callTimeout)
+	if err != nil {
+		return err
+	}
+	c.conn, c.enc, c.dec = conn, vom.NewEncoder(conn), vom.NewDecoder(conn)
+	return nil
+}
+
+// NewSigner returns a security.Signer that signs messages with the named key
+// of the agent that c is connected to.  The private key never leaves the
+// agent.
+//
+// The public key is fetched once, by NewSigner, and every signature returned
+// by the agent is verified against it, so that Sign fails with
+// ErrInvalidSignature rather than returning unusable signatures if the agent
+// replaces the key.
+func NewSigner(c *Client, key string) (security.Signer, error) {
+	pubkey, err := c.PublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &signer{client: c, key: key, pubkey: pubkey}, nil
+}
+
+type signer struct {
+	client *Client
+	key    string
+	pubkey security.PublicKey
+}
+
+func (s *signer) Sign(purpose, message []byte) (security.Signature, error) {
+	sig, err := s.client.Sign(s.key, purpose, message)
+	if err != nil {
+		return security.Signature{}, err
+	}
+	if !sig.Verify(s.pubkey, message) {
+		return security.Signature{}, NewErrInvalidSignature(nil, s.key)
+	}
+	return sig, nil
+}
+
+func (s *signer) PublicKey() security.PublicKey {
+	return s.pubkey
+}
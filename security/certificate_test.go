@@ -45,15 +45,15 @@ func TestCertificateDigest(t *testing.T) {
 		// bugs by counting the expected number of digests that were generated and tested.
 		// - len(certificates) = 3 fields * 2 values + empty cert = 7
 		//   Thus, number of certificate pairs = 7C2 = 21
-		// - len(signatures) = 4 fields * 2 values each + empty = 9
-		//   Thus, number of signature pairs = 9C2 = 36
+		// - len(signatures) = 5 fields * 2 values each + empty = 11
+		//   Thus, number of signature pairs = 11C2 = 55
 		//
 		// Tests:
 		// - digests should be different for each Certificate:      21 hash comparisons
 		// - digests should depend on the chaining of certificates: 21 hash comparisons
-		// - content digests should not depend on the Signature:     8 hash comparisons
-		// - digests should depend on the Signature:                36 hash comparisons
-		if got, want := numtested, 21+21+36+8; got != want {
+		// - content digests should not depend on the Signature:    10 hash comparisons
+		// - digests should depend on the Signature:                55 hash comparisons
+		if got, want := numtested, 21+21+55+10; got != want {
 			t.Fatalf("Executed %d tests, expected %d", got, want)
 		}
 	}()
@@ -265,6 +265,76 @@ func TestChainMixing(t *testing.T) {
 	}
 }
 
+func TestChainMixingKeyTypes(t *testing.T) {
+	var (
+		// An ECDSA root blesses an Ed25519 user, who blesses an ECDSA delegate,
+		// who blesses an Ed25519 delegate.
+		signers = []Signer{
+			newECDSASigner(t, elliptic.P384()),
+			newEd25519Signer(t),
+			newECDSASigner(t, elliptic.P256()),
+			newEd25519Signer(t),
+		}
+		hashes = []Hash{SHA384Hash, SHA512Hash, SHA256Hash}
+		chain  []Certificate
+		err    error
+	)
+	for i, s := range signers {
+		var cert Certificate
+		if cert.PublicKey, err = s.PublicKey().MarshalBinary(); err != nil {
+			t.Fatal(err)
+		}
+		cert.Extension = fmt.Sprintf("e%d", i)
+		signer := s
+		if i > 0 {
+			signer = signers[i-1]
+		}
+		if chain, _, err = chainCertificate(signer, chain, cert); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, h := range hashes {
+		// chain[i+1] is signed by signers[i].
+		if got := chain[i+1].Signature.Hash; got != h {
+			t.Errorf("Certificate #%d: got hash %v, want %v", i+1, got, h)
+		}
+	}
+	for i := 1; i <= 2; i++ {
+		signatureCache.disable()
+		if i == 2 {
+			signatureCache.enable()
+		}
+		// Run twice with the cache enabled to account for caching of certificate verifications.
+		for j := 0; j < i; j++ {
+			key, _, err := validateCertificateChain(chain)
+			if err != nil {
+				t.Fatalf("validateCertificateChain failed: %v", err)
+			}
+			if !reflect.DeepEqual(key, signers[len(signers)-1].PublicKey()) {
+				t.Errorf("Got key %v, want %v", key, signers[len(signers)-1].PublicKey())
+			}
+		}
+	}
+	// A certificate whose signature was altered, even in a way that keeps it
+	// well-formed for the signer's algorithm, must not validate, even after the
+	// original chain has been cached.
+	for i := range chain {
+		bad := append([]Certificate(nil), chain...)
+		sig := bad[i].Signature
+		if len(sig.Ed25519) != 0 {
+			sig.Ed25519 = append([]byte(nil), sig.Ed25519...)
+			sig.Ed25519[0]++
+		} else {
+			sig.R = append([]byte(nil), sig.R...)
+			sig.R[0]++
+		}
+		bad[i].Signature = sig
+		if _, _, err := validateCertificateChain(bad); verror.ErrorID(err) != errBadCertSignature.ID {
+			t.Errorf("Certificate #%d: got error %v, want %v", i, err, errBadCertSignature.ID)
+		}
+	}
+}
+
 func benchmarkDigestsForCertificateChain(b *testing.B, ncerts int) {
 	chain := makeBlessings(b, ncerts).chains[0]
 	b.ResetTimer()
@@ -71,6 +71,37 @@ func TestDischargeSignatureCaching(t *testing.T) {
 	}
 }
 
+func TestDischargeSignatureCachingEd25519(t *testing.T) {
+	var (
+		p1, _       = CreatePrincipal(newEd25519Signer(t), nil, &roots{})
+		p2, _       = CreatePrincipal(newEd25519Signer(t), nil, &roots{})
+		cav         = newCaveat(NewPublicKeyCaveat(p1.PublicKey(), "peoria", ThirdPartyRequirements{}, UnconstrainedUse()))
+		ctx, cancel = context.RootContext()
+		mkCall      = func(d Discharge) Call {
+			return NewCall(&CallParams{
+				RemoteDischarges: map[string]Discharge{cav.ThirdPartyDetails().ID(): d},
+			})
+		}
+	)
+	defer cancel()
+	discharge1, err := p1.MintDischarge(cav, UnconstrainedUse())
+	if err != nil {
+		t.Fatal(err)
+	}
+	discharge2, err := p2.MintDischarge(cav, UnconstrainedUse())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := cav.Validate(ctx, mkCall(discharge1)); err != nil {
+			t.Error(err)
+		}
+		if err := cav.Validate(ctx, mkCall(discharge2)); err == nil {
+			t.Errorf("Caveat that required a discharge from one principal was validated by a discharge created by another!")
+		}
+	}
+}
+
 func BenchmarkDischargeEquality(b *testing.B) {
 	p, err := CreatePrincipal(newSigner(), nil, nil)
 	if err != nil {
@@ -29,6 +29,9 @@ type ecdsaPublicKey struct {
 func (pk *ecdsaPublicKey) MarshalBinary() ([]byte, error) { return x509.MarshalPKIXPublicKey(pk.key) }
 func (pk *ecdsaPublicKey) String() string                 { return publicKeyString(pk) }
 func (pk *ecdsaPublicKey) verify(digest []byte, sig *Signature) bool {
+	if len(sig.Ed25519) != 0 {
+		return false
+	}
 	var r, s big.Int
 	return ecdsa.Verify(pk.key, digest, r.SetBytes(sig.R), s.SetBytes(sig.S))
 }
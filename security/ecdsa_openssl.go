@@ -82,6 +82,9 @@ func (k *opensslECPublicKey) MarshalBinary() ([]byte, error) {
 func (k *opensslECPublicKey) String() string { return publicKeyString(k) }
 func (k *opensslECPublicKey) hash() Hash     { return k.h }
 func (k *opensslECPublicKey) verify(digest []byte, signature *Signature) bool {
+	if len(signature.Ed25519) != 0 {
+		return false
+	}
 	sig := C.ECDSA_SIG_new()
 	sig.r = C.BN_bin2bn(uchar(signature.R), C.int(len(signature.R)), sig.r)
 	sig.s = C.BN_bin2bn(uchar(signature.S), C.int(len(signature.S)), sig.s)
@@ -0,0 +1,122 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package security
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+
+	"v.io/v23/verror"
+)
+
+var (
+	errBadEd25519Key       = verror.Register(pkgPath+".errBadEd25519Key", verror.NoRetry, "{1:}{2:}invalid Ed25519 key of {3} bytes{:_}")
+	errBadEd25519Signature = verror.Register(pkgPath+".errBadEd25519Signature", verror.NoRetry, "{1:}{2:}invalid Ed25519 signature of {3} bytes{:_}")
+)
+
+// ed25519PKIXPrefix is the prefix of the DER-encoded PKIX representation of
+// every Ed25519 public key (RFC 8410), which is followed by the 32 bytes of
+// the key itself.
+var ed25519PKIXPrefix = []byte{0x30, 0x2a, 0x30, 0x05, 0x06, 0x03, 0x2b, 0x65, 0x70, 0x03, 0x21, 0x00}
+
+// NewEd25519PublicKey creates a PublicKey object that uses the Ed25519 algorithm and the provided Ed25519 public key.
+func NewEd25519PublicKey(key ed25519.PublicKey) PublicKey {
+	cpy := make(ed25519.PublicKey, len(key))
+	copy(cpy, key)
+	return &ed25519PublicKey{cpy}
+}
+
+type ed25519PublicKey struct {
+	key ed25519.PublicKey
+}
+
+func (pk *ed25519PublicKey) MarshalBinary() ([]byte, error) {
+	if len(pk.key) != ed25519.PublicKeySize {
+		return nil, verror.New(errBadEd25519Key, nil, len(pk.key))
+	}
+	return append(append([]byte(nil), ed25519PKIXPrefix...), pk.key...), nil
+}
+
+func (pk *ed25519PublicKey) String() string { return publicKeyString(pk) }
+
+// Ed25519 hashes the (already hashed) message with SHA-512 internally, so
+// there is no point in using a weaker hash function for the message digest.
+func (pk *ed25519PublicKey) hash() Hash { return SHA512Hash }
+
+func (pk *ed25519PublicKey) verify(digest []byte, sig *Signature) bool {
+	if len(pk.key) != ed25519.PublicKeySize || sig.Hash != SHA512Hash || len(sig.R) != 0 || len(sig.S) != 0 || len(sig.Ed25519) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(pk.key, digest, sig.Ed25519)
+}
+
+// parseEd25519PublicKey returns the Ed25519 PublicKey whose DER-encoded PKIX
+// representation is der, or nil if der isn't one.
+func parseEd25519PublicKey(der []byte) PublicKey {
+	if len(der) != len(ed25519PKIXPrefix)+ed25519.PublicKeySize || !bytes.HasPrefix(der, ed25519PKIXPrefix) {
+		return nil
+	}
+	return NewEd25519PublicKey(ed25519.PublicKey(der[len(ed25519PKIXPrefix):]))
+}
+
+// NewInMemoryEd25519Signer creates a Signer that uses the provided Ed25519
+// private key to sign messages.  This private key is kept in the clear in the
+// memory of the running process.
+func NewInMemoryEd25519Signer(key ed25519.PrivateKey) (Signer, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, verror.New(errBadEd25519Key, nil, len(key))
+	}
+	sign := func(data []byte) ([]byte, error) {
+		return ed25519.Sign(key, data), nil
+	}
+	return NewEd25519Signer(key.Public().(ed25519.PublicKey), sign), nil
+}
+
+// NewEd25519Signer creates a Signer that uses the provided function to sign
+// messages.  sign must return the 64-byte Ed25519 signature of data.
+func NewEd25519Signer(key ed25519.PublicKey, sign func(data []byte) ([]byte, error)) Signer {
+	return &ed25519Signer{sign: sign, pubkey: NewEd25519PublicKey(key)}
+}
+
+// GenerateEd25519Signer creates a Signer for a new Ed25519 private key, which
+// is kept in the clear in the memory of the running process.  It is meant for
+// short-lived principals, for which Ed25519 keys are cheaper to create and to
+// use than ECDSA ones.
+func GenerateEd25519Signer() (Signer, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewInMemoryEd25519Signer(key)
+}
+
+type ed25519Signer struct {
+	sign   func(data []byte) ([]byte, error)
+	pubkey PublicKey
+}
+
+func (c *ed25519Signer) Sign(purpose, message []byte) (Signature, error) {
+	hash := c.pubkey.hash()
+	if message = messageDigest(hash, purpose, message, c.pubkey); message == nil {
+		return Signature{}, verror.New(errSignCantHash, nil, hash)
+	}
+	sig, err := c.sign(message)
+	if err != nil {
+		return Signature{}, err
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return Signature{}, verror.New(errBadEd25519Signature, nil, len(sig))
+	}
+	return Signature{
+		Purpose: purpose,
+		Hash:    hash,
+		Ed25519: sig,
+	}, nil
+}
+
+func (c *ed25519Signer) PublicKey() PublicKey {
+	return c.pubkey
+}
@@ -15,7 +15,8 @@ var (
 	errUnrecognizedKey = verror.Register(pkgPath+".errUnrecognizedKey", verror.NoRetry, "{1:}{2:}unrecognized PublicKey type({3}){:_}")
 )
 
-// PublicKey represents a public key using an unspecified algorithm.
+// PublicKey represents a public key using an unspecified algorithm, currently
+// either ECDSA or Ed25519.
 //
 // MarshalBinary returns the DER-encoded PKIX representation of the public key,
 // while UnmarshalPublicKey creates a PublicKey object from the marshaled bytes.
@@ -55,7 +56,10 @@ func publicKeyString(pk PublicKey) string {
 }
 
 // UnmarshalPublicKey returns a PublicKey object from the DER-encoded PKIX represntation of it
-// (typically obtianed via PublicKey.MarshalBinary).
+// (typically obtianed via PublicKey.MarshalBinary).  Both ECDSA and Ed25519 keys are recognized.
 func UnmarshalPublicKey(bytes []byte) (PublicKey, error) {
+	if key := parseEd25519PublicKey(bytes); key != nil {
+		return key, nil
+	}
 	return unmarshalPublicKeyImpl(bytes)
 }
@@ -6,8 +6,10 @@ package security
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/x509"
 	"reflect"
 	"testing"
 )
@@ -21,17 +23,39 @@ func mkPublicKey() PublicKey {
 }
 
 func TestPublicKeyMarshaling(t *testing.T) {
-	k1 := mkPublicKey()
-	bytes, err := k1.MarshalBinary()
+	for _, k1 := range []PublicKey{mkPublicKey(), newEd25519Signer(t).PublicKey()} {
+		bytes, err := k1.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		k2, err := UnmarshalPublicKey(bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(k1, k2) {
+			t.Errorf("UnmarshalBinary did not reproduce the key. Before [%v], After [%v]", k1, k2)
+		}
+	}
+}
+
+func TestEd25519PublicKeyIsPKIX(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
 	if err != nil {
 		t.Fatal(err)
 	}
-	k2, err := UnmarshalPublicKey(bytes)
+	want, err := x509.MarshalPKIXPublicKey(pub)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !reflect.DeepEqual(k1, k2) {
-		t.Errorf("UnmarshalBinary did not reproduce the key. Before [%v], After [%v]", k1, k2)
+	got, err := NewEd25519PublicKey(pub).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %x, want %x", got, want)
+	}
+	if _, err := NewEd25519PublicKey(pub[:16]).MarshalBinary(); err == nil {
+		t.Errorf("Invalid Ed25519 key marshaled")
 	}
 }
 
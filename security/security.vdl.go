@@ -455,13 +455,16 @@ func (x *Hash) VDLRead(dec vdl.Decoder) error {
 type Signature struct {
 	// Purpose of the signature. Can be used to prevent type attacks.
 	// (See Section 4.2 of http://www-users.cs.york.ac.uk/~jac/PublishedPapers/reviewV1_1997.pdf for example).
-	// The actual signature (R, S values for ECDSA keys) is produced by signing: Hash(Hash(message), Hash(Purpose)).
+	// The actual signature (R, S values for ECDSA keys, Ed25519 for Ed25519 keys) is produced by signing: Hash(Hash(message), Hash(Purpose)).
 	Purpose []byte
 	// Cryptographic hash function applied to the message before computing the signature.
 	Hash Hash
 	// Pair of integers that make up an ECDSA signature.
 	R []byte
 	S []byte
+	// Ed25519 signature, set iff the signature was produced with an Ed25519
+	// key, in which case R and S are empty and Hash is SHA512.
+	Ed25519 []byte
 }
 
 func (Signature) __VDLReflect(struct {
@@ -482,6 +485,9 @@ func (x Signature) VDLIsZero() bool {
 	if len(x.S) != 0 {
 		return false
 	}
+	if len(x.Ed25519) != 0 {
+		return false
+	}
 	return true
 }
 
@@ -509,6 +515,11 @@ func (x Signature) VDLWrite(enc vdl.Encoder) error {
 			return err
 		}
 	}
+	if len(x.Ed25519) != 0 {
+		if err := enc.NextFieldValueBytes(4, __VDLType_list_4, x.Ed25519); err != nil {
+			return err
+		}
+	}
 	if err := enc.NextField(-1); err != nil {
 		return err
 	}
@@ -558,6 +569,10 @@ func (x *Signature) VDLRead(dec vdl.Decoder) error {
 			if err := dec.ReadValueBytes(-1, &x.S); err != nil {
 				return err
 			}
+		case 4:
+			if err := dec.ReadValueBytes(-1, &x.Ed25519); err != nil {
+				return err
+			}
 		}
 	}
 }
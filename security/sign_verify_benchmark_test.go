@@ -17,9 +17,10 @@ type bmkey struct {
 }
 
 var (
-	ecdsaKey *bmkey
-	message  = []byte("over the mountain and under the bridge")
-	purpose  = []byte("benchmarking")
+	ecdsaKey   *bmkey
+	ed25519Key *bmkey
+	message    = []byte("over the mountain and under the bridge")
+	purpose    = []byte("benchmarking")
 )
 
 func init() {
@@ -36,6 +37,14 @@ func init() {
 		panic(err)
 	}
 	ecdsaKey = &bmkey{signer, signature}
+
+	if signer, err = GenerateEd25519Signer(); err != nil {
+		panic(err)
+	}
+	if signature, err = signer.Sign(purpose, message); err != nil {
+		panic(err)
+	}
+	ed25519Key = &bmkey{signer, signature}
 }
 
 func benchmarkSign(k *bmkey, b *testing.B) {
@@ -63,3 +72,11 @@ func BenchmarkSign_ECDSA(b *testing.B) {
 func BenchmarkVerify_ECDSA(b *testing.B) {
 	benchmarkVerify(ecdsaKey, b)
 }
+
+func BenchmarkSign_Ed25519(b *testing.B) {
+	benchmarkSign(ed25519Key, b)
+}
+
+func BenchmarkVerify_Ed25519(b *testing.B) {
+	benchmarkVerify(ed25519Key, b)
+}
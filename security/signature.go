@@ -30,12 +30,26 @@ func (sig *Signature) digest(hashfn Hash) []byte {
 	}
 	w([]byte(sig.Hash))
 	w(sig.Purpose)
-	w([]byte("ECDSA")) // The signing algorithm
+	w([]byte(sig.algorithm())) // The signing algorithm
 	w(sig.R)
 	w(sig.S)
+	if len(sig.Ed25519) != 0 {
+		w(sig.Ed25519)
+	}
 	return hashfn.sum(fields)
 }
 
+// algorithm returns the name of the signing algorithm that produced sig.
+// Signatures with an Ed25519 field are Ed25519 ones, and all others are
+// ECDSA ones.  The public keys of each algorithm reject signatures that
+// carry fields of the other.
+func (sig *Signature) algorithm() string {
+	if len(sig.Ed25519) != 0 {
+		return "ED25519"
+	}
+	return "ECDSA"
+}
+
 func messageDigest(hash Hash, purpose, message []byte, key PublicKey) []byte {
 	var fields []byte
 	w := func(data []byte) bool {
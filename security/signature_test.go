@@ -7,6 +7,7 @@ package security
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"testing"
@@ -172,3 +173,78 @@ func TestSignaturePurpose(t *testing.T) {
 		}
 	}
 }
+
+func TestEd25519Signature(t *testing.T) {
+	signer := newEd25519Signer(t)
+	message := []byte("test")
+	sig, err := signer.Sign([]byte(SignatureForMessageSigning), message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig.Hash != SHA512Hash || len(sig.R) != 0 || len(sig.S) != 0 || len(sig.Ed25519) != ed25519.SignatureSize {
+		t.Fatalf("Unexpected signature %+v", sig)
+	}
+	if !sig.Verify(signer.PublicKey(), message) {
+		t.Errorf("Signature verification failed")
+	}
+	if sig.Verify(signer.PublicKey(), append(message, 1)) {
+		t.Errorf("Signature of modified message incorrectly verified")
+	}
+	if sig.Verify(newEd25519Signer(t).PublicKey(), message) {
+		t.Errorf("Signature incorrectly verified with another key")
+	}
+	bad := sig
+	bad.Purpose = []byte(SignatureForDischarge)
+	if bad.Verify(signer.PublicKey(), message) {
+		t.Errorf("Signature incorrectly verified for another purpose")
+	}
+	if _, err := NewInMemoryEd25519Signer(ed25519.PrivateKey("short")); err == nil {
+		t.Errorf("NewInMemoryEd25519Signer accepted an invalid key")
+	}
+}
+
+func TestSignatureAlgorithmIsUnambiguous(t *testing.T) {
+	var (
+		ecSigner = newECDSASigner(t, elliptic.P256())
+		edSigner = newEd25519Signer(t)
+		message  = []byte("test")
+	)
+	ecSig, err := ecSigner.Sign(nil, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edSig, err := edSigner.Sign(nil, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Signatures of one algorithm must not verify with keys of the other.
+	if ecSig.Verify(edSigner.PublicKey(), message) || edSig.Verify(ecSigner.PublicKey(), message) {
+		t.Errorf("Signature verified with a key of another algorithm")
+	}
+	// Signatures that carry the fields of both algorithms are invalid.
+	mixed := ecSig
+	mixed.Ed25519 = edSig.Ed25519
+	if mixed.Verify(ecSigner.PublicKey(), message) {
+		t.Errorf("ECDSA signature with an Ed25519 field verified")
+	}
+	mixed = edSig
+	mixed.R, mixed.S = ecSig.R, ecSig.S
+	if mixed.Verify(edSigner.PublicKey(), message) {
+		t.Errorf("Ed25519 signature with R and S fields verified")
+	}
+	// Ed25519 signatures must use SHA512.
+	weak := edSig
+	weak.Hash = SHA256Hash
+	if weak.Verify(edSigner.PublicKey(), message) {
+		t.Errorf("Ed25519 signature with %v verified", weak.Hash)
+	}
+	// Every field contributes to the digest used by the signature caches.
+	digests := map[string]Signature{}
+	for _, sig := range []Signature{ecSig, edSig, mixed, {Purpose: ecSig.Purpose, Hash: ecSig.Hash, Ed25519: ecSig.R}} {
+		d := string(sig.digest(SHA256Hash))
+		if other, exists := digests[d]; exists {
+			t.Errorf("Signatures %+v and %+v have the same digest", sig, other)
+		}
+		digests[d] = sig
+	}
+}
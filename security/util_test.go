@@ -95,6 +95,14 @@ func newECDSASigner(t testing.TB, curve elliptic.Curve) Signer {
 	return NewInMemoryECDSASigner(key)
 }
 
+func newEd25519Signer(t testing.TB) Signer {
+	signer, err := GenerateEd25519Signer()
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+	return signer
+}
+
 func newPrincipal(t testing.TB) Principal {
 	p, err := CreatePrincipal(newECDSASigner(t, elliptic.P256()), nil, &roots{})
 	if err != nil {
@@ -167,7 +167,19 @@ type AppCycleStopClientStream interface {
 		Value() Task
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items []Task) (int, error)
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // AppCycleStopClientCall represents the call returned from AppCycle.Stop.
@@ -196,6 +208,7 @@ func (c *implAppCycleStopClientCall) RecvStream() interface {
 	Advance() bool
 	Value() Task
 	Err() error
+	RecvBatch(items []Task) (int, error)
 } {
 	return implAppCycleStopClientCallRecv{c}
 }
@@ -218,6 +231,14 @@ func (c implAppCycleStopClientCallRecv) Err() error {
 	}
 	return c.c.errRecv
 }
+func (c implAppCycleStopClientCallRecv) RecvBatch(items []Task) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		items[i] = Task{}
+		ptrs[i] = &items[i]
+	}
+	return c.c.RecvBatch(ptrs...)
+}
 func (c *implAppCycleStopClientCall) Finish() (err error) {
 	err = c.ClientCall.Finish()
 	return
@@ -326,7 +347,22 @@ type AppCycleStopServerStream interface {
 		// while sending.  Blocks if there is no buffer space; will unblock when
 		// buffer space is available.
 		Send(item Task) error
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...Task) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item Task) (bool, error)
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // AppCycleStopServerCall represents the context passed to AppCycle.Stop.
@@ -349,6 +385,8 @@ func (s *AppCycleStopServerCallStub) Init(call rpc.StreamServerCall) {
 // SendStream returns the send side of the AppCycle.Stop server stream.
 func (s *AppCycleStopServerCallStub) SendStream() interface {
 	Send(item Task) error
+	SendBatch(items ...Task) error
+	TrySend(item Task) (bool, error)
 } {
 	return implAppCycleStopServerCallSend{s}
 }
@@ -360,6 +398,16 @@ type implAppCycleStopServerCallSend struct {
 func (s implAppCycleStopServerCallSend) Send(item Task) error {
 	return s.s.Send(item)
 }
+func (s implAppCycleStopServerCallSend) SendBatch(items ...Task) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return s.s.SendBatch(batch...)
+}
+func (s implAppCycleStopServerCallSend) TrySend(item Task) (bool, error) {
+	return s.s.TrySend(item)
+}
 
 // Hold type definitions in package-level variables, for better performance.
 var (
@@ -398,6 +398,12 @@ type BuilderBuildClientStream interface {
 		Value() File
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items []File) (int, error)
 	}
 	// SendStream returns the send side of the Builder.Build client stream.
 	SendStream() interface {
@@ -407,6 +413,15 @@ type BuilderBuildClientStream interface {
 		// space; will unblock when buffer space is available or after
 		// the stream has been canceled.
 		Send(item File) error
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...File) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item File) (bool, error)
 		// Close indicates to the server that no more items will be sent;
 		// server Recv calls will receive io.EOF after all sent items.
 		// This is an optional call - e.g. a client might call Close if it
@@ -416,6 +431,12 @@ type BuilderBuildClientStream interface {
 		// blocks if there is no buffer space available.
 		Close() error
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // BuilderBuildClientCall represents the call returned from Builder.Build.
@@ -444,6 +465,7 @@ func (c *implBuilderBuildClientCall) RecvStream() interface {
 	Advance() bool
 	Value() File
 	Err() error
+	RecvBatch(items []File) (int, error)
 } {
 	return implBuilderBuildClientCallRecv{c}
 }
@@ -466,8 +488,18 @@ func (c implBuilderBuildClientCallRecv) Err() error {
 	}
 	return c.c.errRecv
 }
+func (c implBuilderBuildClientCallRecv) RecvBatch(items []File) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		items[i] = File{}
+		ptrs[i] = &items[i]
+	}
+	return c.c.RecvBatch(ptrs...)
+}
 func (c *implBuilderBuildClientCall) SendStream() interface {
 	Send(item File) error
+	SendBatch(items ...File) error
+	TrySend(item File) (bool, error)
 	Close() error
 } {
 	return implBuilderBuildClientCallSend{c}
@@ -480,6 +512,16 @@ type implBuilderBuildClientCallSend struct {
 func (c implBuilderBuildClientCallSend) Send(item File) error {
 	return c.c.Send(item)
 }
+func (c implBuilderBuildClientCallSend) SendBatch(items ...File) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return c.c.SendBatch(batch...)
+}
+func (c implBuilderBuildClientCallSend) TrySend(item File) (bool, error) {
+	return c.c.TrySend(item)
+}
 func (c implBuilderBuildClientCallSend) Close() error {
 	return c.c.CloseSend()
 }
@@ -605,6 +647,12 @@ type BuilderBuildServerStream interface {
 		Value() File
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items []File) (int, error)
 	}
 	// SendStream returns the send side of the Builder.Build server stream.
 	SendStream() interface {
@@ -612,7 +660,22 @@ type BuilderBuildServerStream interface {
 		// while sending.  Blocks if there is no buffer space; will unblock when
 		// buffer space is available.
 		Send(item File) error
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...File) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item File) (bool, error)
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // BuilderBuildServerCall represents the context passed to Builder.Build.
@@ -639,6 +702,7 @@ func (s *BuilderBuildServerCallStub) RecvStream() interface {
 	Advance() bool
 	Value() File
 	Err() error
+	RecvBatch(items []File) (int, error)
 } {
 	return implBuilderBuildServerCallRecv{s}
 }
@@ -661,10 +725,20 @@ func (s implBuilderBuildServerCallRecv) Err() error {
 	}
 	return s.s.errRecv
 }
+func (s implBuilderBuildServerCallRecv) RecvBatch(items []File) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		items[i] = File{}
+		ptrs[i] = &items[i]
+	}
+	return s.s.RecvBatch(ptrs...)
+}
 
 // SendStream returns the send side of the Builder.Build server stream.
 func (s *BuilderBuildServerCallStub) SendStream() interface {
 	Send(item File) error
+	SendBatch(items ...File) error
+	TrySend(item File) (bool, error)
 } {
 	return implBuilderBuildServerCallSend{s}
 }
@@ -676,6 +750,16 @@ type implBuilderBuildServerCallSend struct {
 func (s implBuilderBuildServerCallSend) Send(item File) error {
 	return s.s.Send(item)
 }
+func (s implBuilderBuildServerCallSend) SendBatch(items ...File) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return s.s.SendBatch(batch...)
+}
+func (s implBuilderBuildServerCallSend) TrySend(item File) (bool, error) {
+	return s.s.TrySend(item)
+}
 
 // Hold type definitions in package-level variables, for better performance.
 var (
@@ -1378,6 +1378,12 @@ type ApplicationInstantiateClientStream interface {
 		Value() BlessServerMessage
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items []BlessServerMessage) (int, error)
 	}
 	// SendStream returns the send side of the Application.Instantiate client stream.
 	SendStream() interface {
@@ -1387,6 +1393,15 @@ type ApplicationInstantiateClientStream interface {
 		// space; will unblock when buffer space is available or after
 		// the stream has been canceled.
 		Send(item BlessClientMessage) error
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...BlessClientMessage) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item BlessClientMessage) (bool, error)
 		// Close indicates to the server that no more items will be sent;
 		// server Recv calls will receive io.EOF after all sent items.
 		// This is an optional call - e.g. a client might call Close if it
@@ -1396,6 +1411,12 @@ type ApplicationInstantiateClientStream interface {
 		// blocks if there is no buffer space available.
 		Close() error
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // ApplicationInstantiateClientCall represents the call returned from Application.Instantiate.
@@ -1424,6 +1445,7 @@ func (c *implApplicationInstantiateClientCall) RecvStream() interface {
 	Advance() bool
 	Value() BlessServerMessage
 	Err() error
+	RecvBatch(items []BlessServerMessage) (int, error)
 } {
 	return implApplicationInstantiateClientCallRecv{c}
 }
@@ -1445,8 +1467,17 @@ func (c implApplicationInstantiateClientCallRecv) Err() error {
 	}
 	return c.c.errRecv
 }
+func (c implApplicationInstantiateClientCallRecv) RecvBatch(items []BlessServerMessage) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		ptrs[i] = &items[i]
+	}
+	return c.c.RecvBatch(ptrs...)
+}
 func (c *implApplicationInstantiateClientCall) SendStream() interface {
 	Send(item BlessClientMessage) error
+	SendBatch(items ...BlessClientMessage) error
+	TrySend(item BlessClientMessage) (bool, error)
 	Close() error
 } {
 	return implApplicationInstantiateClientCallSend{c}
@@ -1459,6 +1490,16 @@ type implApplicationInstantiateClientCallSend struct {
 func (c implApplicationInstantiateClientCallSend) Send(item BlessClientMessage) error {
 	return c.c.Send(item)
 }
+func (c implApplicationInstantiateClientCallSend) SendBatch(items ...BlessClientMessage) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return c.c.SendBatch(batch...)
+}
+func (c implApplicationInstantiateClientCallSend) TrySend(item BlessClientMessage) (bool, error) {
+	return c.c.TrySend(item)
+}
 func (c implApplicationInstantiateClientCallSend) Close() error {
 	return c.c.CloseSend()
 }
@@ -2042,6 +2083,12 @@ type ApplicationInstantiateServerStream interface {
 		Value() BlessClientMessage
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items []BlessClientMessage) (int, error)
 	}
 	// SendStream returns the send side of the Application.Instantiate server stream.
 	SendStream() interface {
@@ -2049,7 +2096,22 @@ type ApplicationInstantiateServerStream interface {
 		// while sending.  Blocks if there is no buffer space; will unblock when
 		// buffer space is available.
 		Send(item BlessServerMessage) error
-	}
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...BlessServerMessage) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item BlessServerMessage) (bool, error)
+	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // ApplicationInstantiateServerCall represents the context passed to Application.Instantiate.
@@ -2076,6 +2138,7 @@ func (s *ApplicationInstantiateServerCallStub) RecvStream() interface {
 	Advance() bool
 	Value() BlessClientMessage
 	Err() error
+	RecvBatch(items []BlessClientMessage) (int, error)
 } {
 	return implApplicationInstantiateServerCallRecv{s}
 }
@@ -2097,10 +2160,19 @@ func (s implApplicationInstantiateServerCallRecv) Err() error {
 	}
 	return s.s.errRecv
 }
+func (s implApplicationInstantiateServerCallRecv) RecvBatch(items []BlessClientMessage) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		ptrs[i] = &items[i]
+	}
+	return s.s.RecvBatch(ptrs...)
+}
 
 // SendStream returns the send side of the Application.Instantiate server stream.
 func (s *ApplicationInstantiateServerCallStub) SendStream() interface {
 	Send(item BlessServerMessage) error
+	SendBatch(items ...BlessServerMessage) error
+	TrySend(item BlessServerMessage) (bool, error)
 } {
 	return implApplicationInstantiateServerCallSend{s}
 }
@@ -2112,6 +2184,16 @@ type implApplicationInstantiateServerCallSend struct {
 func (s implApplicationInstantiateServerCallSend) Send(item BlessServerMessage) error {
 	return s.s.Send(item)
 }
+func (s implApplicationInstantiateServerCallSend) SendBatch(items ...BlessServerMessage) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return s.s.SendBatch(batch...)
+}
+func (s implApplicationInstantiateServerCallSend) TrySend(item BlessServerMessage) (bool, error) {
+	return s.s.TrySend(item)
+}
 
 // ClaimableClientMethods is the client interface
 // containing Claimable methods.
@@ -176,7 +176,19 @@ type LogFileReadLogClientStream interface {
 		Value() LogEntry
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items []LogEntry) (int, error)
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // LogFileReadLogClientCall represents the call returned from LogFile.ReadLog.
@@ -205,6 +217,7 @@ func (c *implLogFileReadLogClientCall) RecvStream() interface {
 	Advance() bool
 	Value() LogEntry
 	Err() error
+	RecvBatch(items []LogEntry) (int, error)
 } {
 	return implLogFileReadLogClientCallRecv{c}
 }
@@ -227,6 +240,14 @@ func (c implLogFileReadLogClientCallRecv) Err() error {
 	}
 	return c.c.errRecv
 }
+func (c implLogFileReadLogClientCallRecv) RecvBatch(items []LogEntry) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		items[i] = LogEntry{}
+		ptrs[i] = &items[i]
+	}
+	return c.c.RecvBatch(ptrs...)
+}
 func (c *implLogFileReadLogClientCall) Finish() (o0 int64, err error) {
 	err = c.ClientCall.Finish(&o0)
 	return
@@ -364,7 +385,22 @@ type LogFileReadLogServerStream interface {
 		// while sending.  Blocks if there is no buffer space; will unblock when
 		// buffer space is available.
 		Send(item LogEntry) error
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...LogEntry) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item LogEntry) (bool, error)
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // LogFileReadLogServerCall represents the context passed to LogFile.ReadLog.
@@ -387,6 +423,8 @@ func (s *LogFileReadLogServerCallStub) Init(call rpc.StreamServerCall) {
 // SendStream returns the send side of the LogFile.ReadLog server stream.
 func (s *LogFileReadLogServerCallStub) SendStream() interface {
 	Send(item LogEntry) error
+	SendBatch(items ...LogEntry) error
+	TrySend(item LogEntry) (bool, error)
 } {
 	return implLogFileReadLogServerCallSend{s}
 }
@@ -398,6 +436,16 @@ type implLogFileReadLogServerCallSend struct {
 func (s implLogFileReadLogServerCallSend) Send(item LogEntry) error {
 	return s.s.Send(item)
 }
+func (s implLogFileReadLogServerCallSend) SendBatch(items ...LogEntry) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return s.s.SendBatch(batch...)
+}
+func (s implLogFileReadLogServerCallSend) TrySend(item LogEntry) (bool, error) {
+	return s.s.TrySend(item)
+}
 
 // Hold type definitions in package-level variables, for better performance.
 var (
@@ -0,0 +1,451 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package memtable implements an in-memory mount table, as defined by
+// v.io/v23/services/mounttable.
+//
+// It is a compact reference implementation, meant for tests and for small
+// deployments that need naming without the full mount table server:
+//
+//   mt := memtable.New(access.Permissions{}.Add("root", string(mounttable.Admin)))
+//   ctx, server, err := v23.WithNewDispatchingServer(ctx, "", mt)
+//
+// Every node of the table has access.Permissions, set with SetPermissions,
+// or inherited from its nearest ancestor that has them.  The tags of
+// v.io/v23/services/mounttable are enforced as follows:
+//
+//   - Resolving through a node, e.g. through a and a/b to reach a/b/c,
+//     requires Resolve, Read or Admin on the node.
+//   - Mount and Unmount require Mount or Admin on the mount point.
+//   - Creating nodes, by Mount or SetPermissions on a name that doesn't exist,
+//     requires Create or Admin on the nearest existing ancestor.
+//   - Delete, GetPermissions and SetPermissions require Admin.
+//   - Glob requires Read or Admin on the receiver, and lists the children on
+//     which the caller has any access.
+//
+// Mounted servers that have expired are removed lazily, at the next call, so
+// that they never affect its result.  Nodes that have no servers, no
+// children and no permissions of their own are removed with them.
+package memtable
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/glob"
+	"v.io/v23/naming"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	"v.io/v23/security/access"
+	"v.io/v23/services/mounttable"
+	vdltime "v.io/v23/vdlroot/time"
+	"v.io/v23/verror"
+)
+
+const pkgPath = "v.io/v23/services/mounttable/memtable"
+
+var (
+	errNotRooted      = verror.Register(pkgPath+".errNotRooted", verror.NoRetry, "{1:}{2:} server {3} is not a rooted name{:_}")
+	errFlagMismatch   = verror.Register(pkgPath+".errFlagMismatch", verror.NoRetry, "{1:}{2:} servers at {3} were mounted with different MT or Leaf flags; use Replace{:_}")
+	errCantDeleteRoot = verror.Register(pkgPath+".errCantDeleteRoot", verror.NoRetry, "{1:}{2:} the root of the mount table cannot be deleted{:_}")
+	errNotEmpty       = verror.Register(pkgPath+".errNotEmpty", verror.NoRetry, "{1:}{2:} {3} has children{:_}")
+)
+
+// resolveTags are the tags that allow resolving through a node.
+var resolveTags = []mounttable.Tag{mounttable.Resolve, mounttable.Read, mounttable.Admin}
+
+// MountTable is an in-memory mount table.  It is an rpc.Dispatcher that
+// serves the mounttable.MountTable interface for every name in the table.
+type MountTable struct {
+	mu   sync.Mutex
+	root *node            // GUARDED_BY(mu)
+	now  func() time.Time // GUARDED_BY(mu)
+}
+
+type node struct {
+	children map[string]*node
+	// perms are the permissions set on the node, or nil if they are
+	// inherited.
+	perms   access.Permissions
+	version uint64
+	// servers maps the mounted servers to their deadline, which is zero if
+	// they don't expire.
+	servers  map[string]time.Time
+	mt, leaf bool
+}
+
+func newNode() *node {
+	return &node{children: make(map[string]*node), servers: make(map[string]time.Time)}
+}
+
+// New returns an empty mount table whose root has the given permissions.
+func New(perms access.Permissions) *MountTable {
+	root := newNode()
+	root.perms = perms.Copy()
+	return &MountTable{root: root, now: time.Now}
+}
+
+// SetClock changes the clock used to expire mounted servers, so that tests
+// can control time.
+func (mt *MountTable) SetClock(now func() time.Time) {
+	mt.mu.Lock()
+	mt.now = now
+	mt.mu.Unlock()
+}
+
+// Lookup implements rpc.Dispatcher.  Authorization is done by the methods of
+// the returned object, according to the permissions of the nodes they
+// traverse.
+func (mt *MountTable) Lookup(_ *context.T, suffix string) (interface{}, security.Authorizer, error) {
+	var elems []string
+	for _, e := range strings.Split(suffix, "/") {
+		if e != "" {
+			elems = append(elems, e)
+		}
+	}
+	r := &receiver{mt: mt, name: naming.Join(elems...), elems: elems}
+	return mounttable.MountTableServer(r), security.AllowEveryone(), nil
+}
+
+// pruneLocked removes the expired servers of n and its descendants, and the
+// descendants that are left empty.  It returns whether n is empty.
+// REQUIRES: mt.mu is held.
+func (mt *MountTable) pruneLocked(n *node, now time.Time) bool {
+	for s, deadline := range n.servers {
+		if !deadline.IsZero() && !now.Before(deadline) {
+			delete(n.servers, s)
+		}
+	}
+	for name, c := range n.children {
+		if mt.pruneLocked(c, now) {
+			delete(n.children, name)
+		}
+	}
+	return len(n.servers) == 0 && len(n.children) == 0 && n.perms == nil
+}
+
+// path is the result of walking from the root towards a node.
+type path struct {
+	// nodes are the existing nodes, starting with the root.
+	nodes []*node
+	// perms are the effective permissions of the nodes.
+	perms []access.Permissions
+}
+
+// walkLocked walks from the root along elems, until elems are exhausted or
+// a node is missing.
+// REQUIRES: mt.mu is held.
+func (mt *MountTable) walkLocked(elems []string) path {
+	mt.pruneLocked(mt.root, mt.now())
+	p := path{nodes: []*node{mt.root}, perms: []access.Permissions{mt.root.perms}}
+	n := mt.root
+	for _, e := range elems {
+		c, ok := n.children[e]
+		if !ok {
+			break
+		}
+		perms := c.perms
+		if perms == nil {
+			perms = p.perms[len(p.perms)-1]
+		}
+		p.nodes = append(p.nodes, c)
+		p.perms = append(p.perms, perms)
+		n = c
+	}
+	return p
+}
+
+type receiver struct {
+	mt    *MountTable
+	name  string
+	elems []string
+}
+
+// caller holds the validated blessing names of the client of a call.
+type caller []string
+
+func newCaller(ctx *context.T, call rpc.ServerCall) caller {
+	names, _ := security.RemoteBlessingNames(ctx, call.Security())
+	return caller(names)
+}
+
+// allowed returns whether perms grant the caller any of the tags.
+func (c caller) allowed(perms access.Permissions, tags ...mounttable.Tag) bool {
+	for _, tag := range tags {
+		if acl, ok := perms[string(tag)]; ok && acl.Includes(c...) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedAny returns whether perms grant the caller any tag at all.
+func (c caller) allowedAny(perms access.Permissions) bool {
+	for _, acl := range perms {
+		if acl.Includes(c...) {
+			return true
+		}
+	}
+	return false
+}
+
+// findLocked walks to the receiver, checking that the caller may resolve
+// through the nodes above it.  It returns the path, and whether the receiver
+// exists.
+// REQUIRES: r.mt.mu is held.
+func (r *receiver) findLocked(ctx *context.T, c caller) (path, bool, error) {
+	p := r.mt.walkLocked(r.elems)
+	exists := len(p.nodes) == len(r.elems)+1
+	ancestors := len(p.nodes)
+	if exists {
+		ancestors--
+	}
+	for i := 0; i < ancestors; i++ {
+		if !c.allowed(p.perms[i], resolveTags...) {
+			return p, false, verror.New(verror.ErrNoAccess, ctx, r.name)
+		}
+	}
+	return p, exists, nil
+}
+
+// createLocked creates the missing nodes on the path to the receiver, if the
+// caller may create them, and returns the receiver's node.
+// REQUIRES: r.mt.mu is held.
+func (r *receiver) createLocked(ctx *context.T, c caller, p path) (*node, error) {
+	last := len(p.nodes) - 1
+	if !c.allowed(p.perms[last], mounttable.Create, mounttable.Admin) {
+		return nil, verror.New(verror.ErrNoAccess, ctx, r.name)
+	}
+	n := p.nodes[last]
+	for _, e := range r.elems[last:] {
+		child := newNode()
+		n.children[e] = child
+		n = child
+	}
+	return n, nil
+}
+
+// Mount implements mounttable.MountTableServerMethods.
+func (r *receiver) Mount(ctx *context.T, call rpc.ServerCall, server string, ttl uint32, flags naming.MountFlag) error {
+	if !naming.Rooted(server) {
+		return verror.New(errNotRooted, ctx, server)
+	}
+	c := newCaller(ctx, call)
+	r.mt.mu.Lock()
+	defer r.mt.mu.Unlock()
+	p, exists, err := r.findLocked(ctx, c)
+	if err != nil {
+		return err
+	}
+	var n *node
+	if exists {
+		n = p.nodes[len(p.nodes)-1]
+		if !c.allowed(p.perms[len(p.perms)-1], mounttable.Mount, mounttable.Admin) {
+			return verror.New(verror.ErrNoAccess, ctx, r.name)
+		}
+	} else if n, err = r.createLocked(ctx, c, p); err != nil {
+		return err
+	}
+	isMT, isLeaf := flags&naming.MT != 0, flags&naming.Leaf != 0
+	if flags&naming.Replace != 0 {
+		n.servers = make(map[string]time.Time)
+	} else if len(n.servers) > 0 && (n.mt != isMT || n.leaf != isLeaf) {
+		return verror.New(errFlagMismatch, ctx, r.name)
+	}
+	n.mt, n.leaf = isMT, isLeaf
+	var deadline time.Time
+	if ttl > 0 {
+		deadline = r.mt.now().Add(time.Duration(ttl) * time.Second)
+	}
+	n.servers[server] = deadline
+	return nil
+}
+
+// Unmount implements mounttable.MountTableServerMethods.
+func (r *receiver) Unmount(ctx *context.T, call rpc.ServerCall, server string) error {
+	c := newCaller(ctx, call)
+	r.mt.mu.Lock()
+	defer r.mt.mu.Unlock()
+	p, exists, err := r.findLocked(ctx, c)
+	if err != nil || !exists {
+		// Nothing is mounted on a name that doesn't exist.
+		return err
+	}
+	if !c.allowed(p.perms[len(p.perms)-1], mounttable.Mount, mounttable.Admin) {
+		return verror.New(verror.ErrNoAccess, ctx, r.name)
+	}
+	n := p.nodes[len(p.nodes)-1]
+	if server == "" {
+		n.servers = make(map[string]time.Time)
+	} else {
+		delete(n.servers, server)
+	}
+	r.mt.pruneLocked(r.mt.root, r.mt.now())
+	return nil
+}
+
+// Delete implements mounttable.MountTableServerMethods.
+func (r *receiver) Delete(ctx *context.T, call rpc.ServerCall, deleteSubtree bool) error {
+	if len(r.elems) == 0 {
+		return verror.New(errCantDeleteRoot, ctx)
+	}
+	c := newCaller(ctx, call)
+	r.mt.mu.Lock()
+	defer r.mt.mu.Unlock()
+	p, exists, err := r.findLocked(ctx, c)
+	if err != nil || !exists {
+		return err
+	}
+	if !c.allowed(p.perms[len(p.perms)-1], mounttable.Admin) {
+		return verror.New(verror.ErrNoAccess, ctx, r.name)
+	}
+	n := p.nodes[len(p.nodes)-1]
+	if len(n.children) > 0 && !deleteSubtree {
+		return verror.New(errNotEmpty, ctx, r.name)
+	}
+	delete(p.nodes[len(p.nodes)-2].children, r.elems[len(r.elems)-1])
+	r.mt.pruneLocked(r.mt.root, r.mt.now())
+	return nil
+}
+
+// ResolveStep implements mounttable.MountTableServerMethods.  It returns the
+// servers mounted on the longest prefix of the receiver's name, and the rest
+// of the name.
+func (r *receiver) ResolveStep(ctx *context.T, call rpc.ServerCall) (naming.MountEntry, error) {
+	c := newCaller(ctx, call)
+	r.mt.mu.Lock()
+	defer r.mt.mu.Unlock()
+	p := r.mt.walkLocked(r.elems)
+	for i, n := range p.nodes {
+		if !c.allowed(p.perms[i], resolveTags...) {
+			return naming.MountEntry{}, verror.New(verror.ErrNoAccess, ctx, r.name)
+		}
+		if len(n.servers) == 0 {
+			continue
+		}
+		entry := naming.MountEntry{
+			Name:             naming.Join(r.elems[i:]...),
+			ServesMountTable: n.mt,
+			IsLeaf:           n.leaf,
+		}
+		for s, deadline := range n.servers {
+			entry.Servers = append(entry.Servers, naming.MountedServer{
+				Server:   s,
+				Deadline: vdltime.Deadline{Time: deadline},
+			})
+		}
+		sort.Sort(byServer(entry.Servers))
+		return entry, nil
+	}
+	if len(r.elems) == 0 {
+		return naming.MountEntry{}, verror.New(naming.ErrNoSuchNameRoot, ctx, r.name)
+	}
+	return naming.MountEntry{}, verror.New(naming.ErrNoSuchName, ctx, r.name)
+}
+
+// SetPermissions implements permissions.ObjectServerMethods.  Setting the
+// permissions of a name that doesn't exist creates it.
+func (r *receiver) SetPermissions(ctx *context.T, call rpc.ServerCall, perms access.Permissions, version string) error {
+	c := newCaller(ctx, call)
+	r.mt.mu.Lock()
+	defer r.mt.mu.Unlock()
+	p, exists, err := r.findLocked(ctx, c)
+	if err != nil {
+		return err
+	}
+	var n *node
+	if exists {
+		n = p.nodes[len(p.nodes)-1]
+		if !c.allowed(p.perms[len(p.perms)-1], mounttable.Admin) {
+			return verror.New(verror.ErrNoAccess, ctx, r.name)
+		}
+		if version != "" && version != strconv.FormatUint(n.version, 10) {
+			return verror.New(verror.ErrBadVersion, ctx)
+		}
+	} else {
+		if version != "" {
+			return verror.New(verror.ErrBadVersion, ctx)
+		}
+		if n, err = r.createLocked(ctx, c, p); err != nil {
+			return err
+		}
+	}
+	n.perms = perms.Copy()
+	if n.perms == nil {
+		n.perms = access.Permissions{}
+	}
+	n.version++
+	return nil
+}
+
+// GetPermissions implements permissions.ObjectServerMethods.  It returns the
+// effective permissions of the receiver, which may be inherited.
+func (r *receiver) GetPermissions(ctx *context.T, call rpc.ServerCall) (access.Permissions, string, error) {
+	c := newCaller(ctx, call)
+	r.mt.mu.Lock()
+	defer r.mt.mu.Unlock()
+	p, exists, err := r.findLocked(ctx, c)
+	if err != nil {
+		return nil, "", err
+	}
+	if !exists {
+		return nil, "", verror.New(verror.ErrNoExist, ctx, r.name)
+	}
+	if !c.allowed(p.perms[len(p.perms)-1], mounttable.Admin) {
+		return nil, "", verror.New(verror.ErrNoAccess, ctx, r.name)
+	}
+	n := p.nodes[len(p.nodes)-1]
+	return p.perms[len(p.perms)-1].Copy(), strconv.FormatUint(n.version, 10), nil
+}
+
+// GlobChildren__ implements rpc.ChildrenGlobber.  A name on which servers
+// are mounted has no children in this mount table: they are served by the
+// mounted servers.
+func (r *receiver) GlobChildren__(ctx *context.T, call rpc.GlobChildrenServerCall, m *glob.Element) error {
+	c := newCaller(ctx, call)
+	r.mt.mu.Lock()
+	p, exists, err := r.findLocked(ctx, c)
+	if err == nil && !exists {
+		err = verror.New(verror.ErrNoExist, ctx, r.name)
+	}
+	if err == nil && !c.allowed(p.perms[len(p.perms)-1], mounttable.Read, mounttable.Admin) {
+		err = verror.New(verror.ErrNoAccess, ctx, r.name)
+	}
+	if err != nil {
+		r.mt.mu.Unlock()
+		return err
+	}
+	var children []string
+	if n := p.nodes[len(p.nodes)-1]; len(n.servers) == 0 {
+		for name, child := range n.children {
+			perms := child.perms
+			if perms == nil {
+				perms = p.perms[len(p.perms)-1]
+			}
+			if m.Match(name) && c.allowedAny(perms) {
+				children = append(children, name)
+			}
+		}
+	}
+	r.mt.mu.Unlock()
+
+	sort.Strings(children)
+	sender := call.SendStream()
+	for _, name := range children {
+		if err := sender.Send(naming.GlobChildrenReplyName{Value: name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type byServer []naming.MountedServer
+
+func (s byServer) Len() int           { return len(s) }
+func (s byServer) Less(i, j int) bool { return s[i].Server < s[j].Server }
+func (s byServer) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
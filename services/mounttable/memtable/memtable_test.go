@@ -0,0 +1,24 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package memtable_test
+
+import (
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/rpc"
+	"v.io/v23/security/access"
+	"v.io/v23/services/mounttable/memtable"
+	"v.io/v23/services/mounttable/mounttabletest"
+)
+
+func TestConformance(t *testing.T) {
+	mounttabletest.Run(t, func(_ *context.T, perms access.Permissions, now func() time.Time) rpc.Dispatcher {
+		mt := memtable.New(perms)
+		mt.SetClock(now)
+		return mt
+	})
+}
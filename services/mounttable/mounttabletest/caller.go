@@ -0,0 +1,205 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mounttabletest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+
+	"v.io/v23/context"
+	"v.io/v23/glob"
+	"v.io/v23/naming"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	"v.io/v23/security/access"
+	"v.io/v23/services/mounttable"
+	"v.io/v23/verror"
+)
+
+// Caller calls the objects of a mount table dispatcher directly, as the rpc
+// runtime would after authorizing the calls, on behalf of a principal.  It
+// lets tests use a mount table, such as the in-memory one of
+// v.io/v23/services/mounttable/memtable, without a runtime or a network.
+type Caller struct {
+	ctx       *context.T
+	disp      rpc.Dispatcher
+	server    security.Principal
+	blessings security.Blessings
+}
+
+func newPrincipal() (security.Principal, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return security.CreatePrincipal(security.NewInMemoryECDSASigner(key), nil, trustAll{})
+}
+
+// NewCaller returns a Caller on behalf of a new principal, self-blessed as
+// name.  The server side of the calls recognizes all blessings.
+func NewCaller(ctx *context.T, disp rpc.Dispatcher, name string) (*Caller, error) {
+	server, err := newPrincipal()
+	if err != nil {
+		return nil, err
+	}
+	p, err := newPrincipal()
+	if err != nil {
+		return nil, err
+	}
+	b, err := p.BlessSelf(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Caller{ctx: ctx, disp: disp, server: server, blessings: b}, nil
+}
+
+// lookup returns the mount table object for name, and a call of method on
+// it.
+func (c *Caller) lookup(name, method string) (mounttable.MountTableServerStub, *serverCall, error) {
+	obj, auth, err := c.disp.Lookup(c.ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	call := &serverCall{
+		security: security.NewCall(&security.CallParams{
+			Method:          method,
+			Suffix:          name,
+			LocalPrincipal:  c.server,
+			RemoteBlessings: c.blessings,
+		}),
+		suffix: name,
+	}
+	if auth != nil {
+		if err := auth.Authorize(c.ctx, call.security); err != nil {
+			return nil, nil, err
+		}
+	}
+	stub, ok := obj.(mounttable.MountTableServerStub)
+	if !ok {
+		return nil, nil, verror.New(verror.ErrNotImplemented, c.ctx, "mounttable.MountTable")
+	}
+	return stub, call, nil
+}
+
+// Mount calls MountTable.Mount on name.
+func (c *Caller) Mount(name, server string, ttl uint32, flags naming.MountFlag) error {
+	obj, call, err := c.lookup(name, "Mount")
+	if err != nil {
+		return err
+	}
+	return obj.Mount(c.ctx, call, server, ttl, flags)
+}
+
+// Unmount calls MountTable.Unmount on name.
+func (c *Caller) Unmount(name, server string) error {
+	obj, call, err := c.lookup(name, "Unmount")
+	if err != nil {
+		return err
+	}
+	return obj.Unmount(c.ctx, call, server)
+}
+
+// Delete calls MountTable.Delete on name.
+func (c *Caller) Delete(name string, deleteSubtree bool) error {
+	obj, call, err := c.lookup(name, "Delete")
+	if err != nil {
+		return err
+	}
+	return obj.Delete(c.ctx, call, deleteSubtree)
+}
+
+// ResolveStep calls MountTable.ResolveStep on name.
+func (c *Caller) ResolveStep(name string) (naming.MountEntry, error) {
+	obj, call, err := c.lookup(name, "ResolveStep")
+	if err != nil {
+		return naming.MountEntry{}, err
+	}
+	return obj.ResolveStep(c.ctx, call)
+}
+
+// SetPermissions calls MountTable.SetPermissions on name.
+func (c *Caller) SetPermissions(name string, perms access.Permissions, version string) error {
+	obj, call, err := c.lookup(name, "SetPermissions")
+	if err != nil {
+		return err
+	}
+	return obj.SetPermissions(c.ctx, call, perms, version)
+}
+
+// GetPermissions calls MountTable.GetPermissions on name.
+func (c *Caller) GetPermissions(name string) (access.Permissions, string, error) {
+	obj, call, err := c.lookup(name, "GetPermissions")
+	if err != nil {
+		return nil, "", err
+	}
+	return obj.GetPermissions(c.ctx, call)
+}
+
+// GlobChildren returns the children of name that match pattern, a single
+// element of a glob pattern, as listed by rpc.ChildrenGlobber.GlobChildren__.
+func (c *Caller) GlobChildren(name, pattern string) ([]string, error) {
+	obj, call, err := c.lookup(name, rpc.GlobMethod)
+	if err != nil {
+		return nil, err
+	}
+	var gs *rpc.GlobState
+	if globber, ok := obj.(rpc.Globber); ok {
+		gs = globber.Globber()
+	}
+	if gs == nil || gs.ChildrenGlobber == nil {
+		return nil, verror.New(verror.ErrNotImplemented, c.ctx, "rpc.ChildrenGlobber")
+	}
+	g, err := glob.Parse(pattern)
+	if err != nil {
+		return nil, err
+	}
+	gcall := &globCall{serverCall: call}
+	if err := gs.ChildrenGlobber.GlobChildren__(c.ctx, gcall, g.Head()); err != nil {
+		return nil, err
+	}
+	return gcall.names, nil
+}
+
+// trustAll is a security.BlessingRoots that recognizes all blessings.
+type trustAll struct{}
+
+func (trustAll) Add([]byte, security.BlessingPattern) error              { return nil }
+func (trustAll) Recognized([]byte, string) error                         { return nil }
+func (trustAll) Dump() map[security.BlessingPattern][]security.PublicKey { return nil }
+func (trustAll) DebugString() string                                     { return "trust all roots" }
+
+type serverCall struct {
+	security security.Call
+	suffix   string
+}
+
+func (c *serverCall) Security() security.Call              { return c.security }
+func (c *serverCall) Suffix() string                       { return c.suffix }
+func (c *serverCall) LocalEndpoint() naming.Endpoint       { return naming.Endpoint{} }
+func (c *serverCall) RemoteEndpoint() naming.Endpoint      { return naming.Endpoint{} }
+func (c *serverCall) GrantedBlessings() security.Blessings { return security.Blessings{} }
+func (c *serverCall) Server() rpc.Server                   { return nil }
+
+type globCall struct {
+	*serverCall
+	names []string
+}
+
+func (c *globCall) SendStream() interface {
+	Send(reply naming.GlobChildrenReply) error
+} {
+	return c
+}
+
+func (c *globCall) Send(reply naming.GlobChildrenReply) error {
+	switch v := reply.(type) {
+	case naming.GlobChildrenReplyName:
+		c.names = append(c.names, v.Value)
+	case naming.GlobChildrenReplyError:
+		return verror.New(verror.ErrInternal, nil, v.Value.Error)
+	}
+	return nil
+}
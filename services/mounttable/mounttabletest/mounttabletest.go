@@ -0,0 +1,396 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mounttabletest is a conformance test suite for implementations of
+// v.io/v23/services/mounttable.
+//
+// The suite calls the objects returned by the implementation's
+// rpc.Dispatcher directly, as the rpc runtime would, with calls from
+// principals blessed as "root", "alice" and "bob".  It needs neither a
+// runtime nor a network, so an implementation runs it from a regular test:
+//
+//   func TestConformance(t *testing.T) {
+//     mounttabletest.Run(t, func(ctx *context.T, perms access.Permissions, now func() time.Time) rpc.Dispatcher {
+//       mt := mytable.New(perms)
+//       mt.SetClock(now)
+//       return mt
+//     })
+//   }
+//
+// The suite makes its calls with Caller, which other tests may use to call a
+// mount table without a runtime.
+package mounttabletest
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/naming"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	"v.io/v23/security/access"
+	"v.io/v23/services/mounttable"
+	"v.io/v23/verror"
+)
+
+// Factory returns a dispatcher that serves a new, empty mount table whose
+// root has the given permissions, and that uses now as its clock to expire
+// mounted servers.
+type Factory func(ctx *context.T, perms access.Permissions, now func() time.Time) rpc.Dispatcher
+
+// Run runs the conformance suite against the mount tables returned by f.
+func Run(t *testing.T, f Factory) {
+	for _, test := range []struct {
+		name string
+		fn   func(t *testing.T, e *env)
+	}{
+		{"MountResolve", testMountResolve},
+		{"MountFlags", testMountFlags},
+		{"Unmount", testUnmount},
+		{"Delete", testDelete},
+		{"TTL", testTTL},
+		{"Permissions", testPermissions},
+		{"Inheritance", testInheritance},
+		{"Glob", testGlob},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			e := newEnv(t, f)
+			defer e.cancel()
+			test.fn(t, e)
+		})
+	}
+}
+
+// env is the environment of a test: a mount table whose root grants Admin
+// to root, and Resolve, Read, Create and Mount to everyone, and a fake clock.
+type env struct {
+	t       *testing.T
+	ctx     *context.T
+	cancel  context.CancelFunc
+	callers map[string]*Caller
+
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newEnv(t *testing.T, f Factory) *env {
+	ctx, cancel := context.RootContext()
+	e := &env{
+		t:       t,
+		ctx:     ctx,
+		cancel:  cancel,
+		callers: make(map[string]*Caller),
+		now:     time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	perms := access.Permissions{}.
+		Add("root", string(mounttable.Admin)).
+		Add(security.AllPrincipals, string(mounttable.Resolve), string(mounttable.Read), string(mounttable.Create), string(mounttable.Mount))
+	disp := f(ctx, perms, e.clock)
+	for _, name := range []string{"root", "alice", "bob"} {
+		c, err := NewCaller(ctx, disp, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.callers[name] = c
+	}
+	return e
+}
+
+func (e *env) clock() time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.now
+}
+
+func (e *env) advance(d time.Duration) {
+	e.mu.Lock()
+	e.now = e.now.Add(d)
+	e.mu.Unlock()
+}
+
+func (e *env) mount(user, name, server string, ttl uint32, flags naming.MountFlag) error {
+	return e.callers[user].Mount(name, server, ttl, flags)
+}
+
+func (e *env) unmount(user, name, server string) error {
+	return e.callers[user].Unmount(name, server)
+}
+
+func (e *env) delete(user, name string, subtree bool) error {
+	return e.callers[user].Delete(name, subtree)
+}
+
+func (e *env) resolve(user, name string) (naming.MountEntry, error) {
+	return e.callers[user].ResolveStep(name)
+}
+
+func (e *env) setPermissions(user, name string, perms access.Permissions, version string) error {
+	return e.callers[user].SetPermissions(name, perms, version)
+}
+
+func (e *env) getPermissions(user, name string) (access.Permissions, string, error) {
+	return e.callers[user].GetPermissions(name)
+}
+
+func (e *env) globChildren(user, name, pattern string) ([]string, error) {
+	names, err := e.callers[user].GlobChildren(name, pattern)
+	if verror.ErrorID(err) == verror.ErrNotImplemented.ID {
+		e.t.Fatal(err)
+	}
+	return names, err
+}
+
+// mustResolve checks that name resolves to the given servers and suffix.
+func (e *env) mustResolve(user, name, suffix string, servers ...string) naming.MountEntry {
+	e.t.Helper()
+	entry, err := e.resolve(user, name)
+	if err != nil {
+		e.t.Fatalf("ResolveStep(%q) as %s: %v", name, user, err)
+	}
+	var got []string
+	for _, s := range entry.Servers {
+		got = append(got, s.Server)
+	}
+	if entry.Name != suffix || !sameServers(got, servers) {
+		e.t.Fatalf("ResolveStep(%q) as %s: got %v at %q, want %v at %q", name, user, got, entry.Name, servers, suffix)
+	}
+	return entry
+}
+
+func sameServers(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	set := make(map[string]bool)
+	for _, s := range got {
+		set[s] = true
+	}
+	for _, s := range want {
+		if !set[s] {
+			return false
+		}
+	}
+	return true
+}
+
+func expectError(t *testing.T, what string, err error, id verror.ID) {
+	t.Helper()
+	switch {
+	case err == nil:
+		t.Errorf("%s: got no error, want %v", what, id)
+	case id != "" && verror.ErrorID(err) != id:
+		t.Errorf("%s: got error %v, want %v", what, err, id)
+	}
+}
+
+func check(t *testing.T, what string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+}
+
+func testMountResolve(t *testing.T, e *env) {
+	check(t, "Mount", e.mount("alice", "a/b", "/server1:1", 0, 0))
+	check(t, "Mount", e.mount("alice", "a/b", "/server2:1", 0, 0))
+	e.mustResolve("alice", "a/b", "", "/server1:1", "/server2:1")
+	// The suffix beyond the mount point is returned with the servers.
+	e.mustResolve("bob", "a/b/c/d", "c/d", "/server1:1", "/server2:1")
+	// Mounting the same server again doesn't duplicate it.
+	check(t, "Mount", e.mount("alice", "a/b", "/server1:1", 0, 0))
+	e.mustResolve("alice", "a/b", "", "/server1:1", "/server2:1")
+
+	_, err := e.resolve("alice", "a")
+	expectError(t, "ResolveStep(a)", err, naming.ErrNoSuchName.ID)
+	_, err = e.resolve("alice", "x/y")
+	expectError(t, "ResolveStep(x/y)", err, naming.ErrNoSuchName.ID)
+	expectError(t, "Mount of a relative name", e.mount("alice", "a/c", "server3:1", 0, 0), "")
+}
+
+func testMountFlags(t *testing.T, e *env) {
+	check(t, "Mount", e.mount("alice", "mt", "/mt1:1", 0, naming.MT))
+	entry := e.mustResolve("alice", "mt/x", "x", "/mt1:1")
+	if !entry.ServesMountTable || entry.IsLeaf {
+		t.Errorf("got %+v, want a mount table", entry)
+	}
+	// Mounting a server that isn't a mount table alongside fails, unless it
+	// replaces the existing servers.
+	expectError(t, "Mount with different flags", e.mount("alice", "mt", "/leaf:1", 0, naming.Leaf), "")
+	e.mustResolve("alice", "mt", "", "/mt1:1")
+	check(t, "Mount", e.mount("alice", "mt", "/leaf:1", 0, naming.Leaf|naming.Replace))
+	entry = e.mustResolve("alice", "mt", "", "/leaf:1")
+	if entry.ServesMountTable || !entry.IsLeaf {
+		t.Errorf("got %+v, want a leaf", entry)
+	}
+	check(t, "Mount", e.mount("alice", "mt", "/leaf:2", 0, naming.Leaf))
+	e.mustResolve("alice", "mt", "", "/leaf:1", "/leaf:2")
+	check(t, "Mount", e.mount("alice", "mt", "/leaf:3", 0, naming.Leaf|naming.Replace))
+	e.mustResolve("alice", "mt", "", "/leaf:3")
+}
+
+func testUnmount(t *testing.T, e *env) {
+	check(t, "Mount", e.mount("alice", "a", "/s1:1", 0, 0))
+	check(t, "Mount", e.mount("alice", "a", "/s2:1", 0, 0))
+	check(t, "Unmount", e.unmount("alice", "a", "/s1:1"))
+	e.mustResolve("alice", "a", "", "/s2:1")
+	// Unmounting a server that isn't mounted is not an error.
+	check(t, "Unmount", e.unmount("alice", "a", "/s1:1"))
+	check(t, "Unmount", e.unmount("alice", "nothing/here", ""))
+	check(t, "Mount", e.mount("alice", "a", "/s3:1", 0, 0))
+	check(t, "Unmount", e.unmount("alice", "a", ""))
+	_, err := e.resolve("alice", "a")
+	expectError(t, "ResolveStep after Unmount", err, naming.ErrNoSuchName.ID)
+	// A node that is left empty disappears.
+	_, _, err = e.getPermissions("root", "a")
+	expectError(t, "GetPermissions after Unmount", err, verror.ErrNoExist.ID)
+}
+
+func testDelete(t *testing.T, e *env) {
+	check(t, "Mount", e.mount("alice", "a/b", "/s1:1", 0, 0))
+	check(t, "Mount", e.mount("alice", "a/c", "/s2:1", 0, 0))
+	expectError(t, "Delete by a non-admin", e.delete("alice", "a/b", false), verror.ErrNoAccess.ID)
+	expectError(t, "Delete of a node with children", e.delete("root", "a", false), "")
+	check(t, "Delete", e.delete("root", "a/b", false))
+	_, err := e.resolve("alice", "a/b")
+	expectError(t, "ResolveStep after Delete", err, naming.ErrNoSuchName.ID)
+	e.mustResolve("alice", "a/c", "", "/s2:1")
+	check(t, "Delete", e.delete("root", "a", true))
+	_, err = e.resolve("alice", "a/c")
+	expectError(t, "ResolveStep after Delete", err, naming.ErrNoSuchName.ID)
+	expectError(t, "Delete of the root", e.delete("root", "", true), "")
+}
+
+func testTTL(t *testing.T, e *env) {
+	check(t, "Mount", e.mount("alice", "a", "/short:1", 10, 0))
+	check(t, "Mount", e.mount("alice", "a", "/long:1", 100, 0))
+	check(t, "Mount", e.mount("alice", "a", "/forever:1", 0, 0))
+	check(t, "Mount", e.mount("alice", "b", "/short:1", 10, 0))
+	entry := e.mustResolve("alice", "a", "", "/short:1", "/long:1", "/forever:1")
+	for _, s := range entry.Servers {
+		switch s.Server {
+		case "/forever:1":
+			if !s.Deadline.IsZero() {
+				t.Errorf("got deadline %v for %s, want none", s.Deadline, s.Server)
+			}
+		case "/short:1":
+			if want := e.clock().Add(10 * time.Second); !s.Deadline.Time.Equal(want) {
+				t.Errorf("got deadline %v for %s, want %v", s.Deadline, s.Server, want)
+			}
+		}
+	}
+	e.advance(5 * time.Second)
+	// Mounting again refreshes the ttl.
+	check(t, "Mount", e.mount("alice", "b", "/short:1", 10, 0))
+	e.advance(6 * time.Second)
+	e.mustResolve("alice", "a", "", "/long:1", "/forever:1")
+	e.mustResolve("alice", "b", "", "/short:1")
+	e.advance(100 * time.Second)
+	e.mustResolve("alice", "a", "", "/forever:1")
+	_, err := e.resolve("alice", "b")
+	expectError(t, "ResolveStep of an expired mount", err, naming.ErrNoSuchName.ID)
+	if names, err := e.globChildren("alice", "", "*"); err != nil || !reflect.DeepEqual(names, []string{"a"}) {
+		t.Errorf("got children (%v, %v), want [a]", names, err)
+	}
+}
+
+func testPermissions(t *testing.T, e *env) {
+	perms, version, err := e.getPermissions("root", "")
+	check(t, "GetPermissions", err)
+	_, _, err = e.getPermissions("alice", "")
+	expectError(t, "GetPermissions by a non-admin", err, verror.ErrNoAccess.ID)
+
+	// Only alice may mount on or resolve through private.
+	private := access.Permissions{}.
+		Add("root", string(mounttable.Admin)).
+		Add("alice", string(mounttable.Mount), string(mounttable.Resolve))
+	check(t, "SetPermissions", e.setPermissions("root", "private", private, ""))
+	check(t, "Mount", e.mount("alice", "private", "/s:1", 0, 0))
+	expectError(t, "Mount by bob", e.mount("bob", "private", "/evil:1", 0, 0), verror.ErrNoAccess.ID)
+	e.mustResolve("alice", "private/x", "x", "/s:1")
+	_, err = e.resolve("bob", "private/x")
+	expectError(t, "ResolveStep by bob", err, verror.ErrNoAccess.ID)
+	// Without Create, bob can't create nodes below private either.
+	expectError(t, "Mount below private by bob", e.mount("bob", "private/y", "/evil:1", 0, 0), verror.ErrNoAccess.ID)
+
+	// Versions guard against concurrent changes.
+	_, pversion, err := e.getPermissions("root", "private")
+	check(t, "GetPermissions", err)
+	expectError(t, "SetPermissions with a stale version", e.setPermissions("root", "private", private, pversion+"1"), verror.ErrBadVersion.ID)
+	check(t, "SetPermissions", e.setPermissions("root", "private", private, pversion))
+	_, newVersion, err := e.getPermissions("root", "private")
+	check(t, "GetPermissions", err)
+	if newVersion == pversion {
+		t.Errorf("version %q didn't change", newVersion)
+	}
+	expectError(t, "SetPermissions by a non-admin", e.setPermissions("alice", "private", perms, ""), verror.ErrNoAccess.ID)
+
+	// The root's permissions are unchanged.
+	if got, gotVersion, err := e.getPermissions("root", ""); err != nil || !reflect.DeepEqual(got, perms) || gotVersion != version {
+		t.Errorf("got (%v, %q, %v), want (%v, %q)", got, gotVersion, err, perms, version)
+	}
+}
+
+func testInheritance(t *testing.T, e *env) {
+	restricted := access.Permissions{}.
+		Add("root", string(mounttable.Admin)).
+		Add("alice", string(mounttable.Resolve), string(mounttable.Read), string(mounttable.Create), string(mounttable.Mount))
+	check(t, "SetPermissions", e.setPermissions("root", "team", restricted, ""))
+	// Nodes created below team inherit its permissions.
+	check(t, "Mount", e.mount("alice", "team/a/b", "/s:1", 0, 0))
+	got, _, err := e.getPermissions("root", "team/a/b")
+	check(t, "GetPermissions", err)
+	if !reflect.DeepEqual(got, restricted) {
+		t.Errorf("got inherited permissions %v, want %v", got, restricted)
+	}
+	expectError(t, "Mount by bob", e.mount("bob", "team/a/b", "/evil:1", 0, 0), verror.ErrNoAccess.ID)
+	// Permissions set below override the inherited ones.
+	open := access.Permissions{}.
+		Add("root", string(mounttable.Admin)).
+		Add(security.AllPrincipals, string(mounttable.Resolve), string(mounttable.Mount))
+	check(t, "SetPermissions", e.setPermissions("root", "team/a/b", open, ""))
+	// But bob still can't resolve through team and team/a.
+	expectError(t, "Mount by bob", e.mount("bob", "team/a/b", "/evil:1", 0, 0), verror.ErrNoAccess.ID)
+	check(t, "SetPermissions", e.setPermissions("root", "team", open, ""))
+	check(t, "Mount", e.mount("bob", "team/a/b", "/bob:1", 0, 0))
+	e.mustResolve("bob", "team/a/b", "", "/s:1", "/bob:1")
+}
+
+func testGlob(t *testing.T, e *env) {
+	for _, name := range []string{"a", "b/x", "c", "cc"} {
+		check(t, "Mount", e.mount("alice", name, "/s:1", 0, 0))
+	}
+	hidden := access.Permissions{}.Add("root", string(mounttable.Admin))
+	check(t, "SetPermissions", e.setPermissions("root", "hidden", hidden, ""))
+
+	tests := []struct {
+		user, name, pattern string
+		want                []string
+	}{
+		{"alice", "", "*", []string{"a", "b", "c", "cc"}},
+		{"root", "", "*", []string{"a", "b", "c", "cc", "hidden"}},
+		{"alice", "", "c*", []string{"c", "cc"}},
+		{"alice", "b", "*", []string{"x"}},
+		// The children of a mount point are on the mounted servers.
+		{"alice", "a", "*", nil},
+	}
+	for _, test := range tests {
+		got, err := e.globChildren(test.user, test.name, test.pattern)
+		if err != nil {
+			t.Errorf("GlobChildren(%q, %q) as %s: %v", test.name, test.pattern, test.user, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("GlobChildren(%q, %q) as %s: got %v, want %v", test.name, test.pattern, test.user, got, test.want)
+		}
+	}
+	if _, err := e.globChildren("alice", "nothing", "*"); err == nil {
+		t.Errorf("expected an error for GlobChildren of a name that doesn't exist")
+	}
+	if _, err := e.globChildren("alice", "hidden", "*"); err == nil {
+		t.Errorf("expected an error for GlobChildren without access")
+	}
+}
@@ -107,7 +107,19 @@ type PProfProfileClientStream interface {
 		Value() []byte
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items [][]byte) (int, error)
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // PProfProfileClientCall represents the call returned from PProf.Profile.
@@ -136,6 +148,7 @@ func (c *implPProfProfileClientCall) RecvStream() interface {
 	Advance() bool
 	Value() []byte
 	Err() error
+	RecvBatch(items [][]byte) (int, error)
 } {
 	return implPProfProfileClientCallRecv{c}
 }
@@ -157,6 +170,13 @@ func (c implPProfProfileClientCallRecv) Err() error {
 	}
 	return c.c.errRecv
 }
+func (c implPProfProfileClientCallRecv) RecvBatch(items [][]byte) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		ptrs[i] = &items[i]
+	}
+	return c.c.RecvBatch(ptrs...)
+}
 func (c *implPProfProfileClientCall) Finish() (err error) {
 	err = c.ClientCall.Finish()
 	return
@@ -175,7 +195,19 @@ type PProfCpuProfileClientStream interface {
 		Value() []byte
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items [][]byte) (int, error)
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // PProfCpuProfileClientCall represents the call returned from PProf.CpuProfile.
@@ -204,6 +236,7 @@ func (c *implPProfCpuProfileClientCall) RecvStream() interface {
 	Advance() bool
 	Value() []byte
 	Err() error
+	RecvBatch(items [][]byte) (int, error)
 } {
 	return implPProfCpuProfileClientCallRecv{c}
 }
@@ -225,6 +258,13 @@ func (c implPProfCpuProfileClientCallRecv) Err() error {
 	}
 	return c.c.errRecv
 }
+func (c implPProfCpuProfileClientCallRecv) RecvBatch(items [][]byte) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		ptrs[i] = &items[i]
+	}
+	return c.c.RecvBatch(ptrs...)
+}
 func (c *implPProfCpuProfileClientCall) Finish() (err error) {
 	err = c.ClientCall.Finish()
 	return
@@ -396,7 +436,22 @@ type PProfProfileServerStream interface {
 		// while sending.  Blocks if there is no buffer space; will unblock when
 		// buffer space is available.
 		Send(item []byte) error
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...[]byte) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item []byte) (bool, error)
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // PProfProfileServerCall represents the context passed to PProf.Profile.
@@ -419,6 +474,8 @@ func (s *PProfProfileServerCallStub) Init(call rpc.StreamServerCall) {
 // SendStream returns the send side of the PProf.Profile server stream.
 func (s *PProfProfileServerCallStub) SendStream() interface {
 	Send(item []byte) error
+	SendBatch(items ...[]byte) error
+	TrySend(item []byte) (bool, error)
 } {
 	return implPProfProfileServerCallSend{s}
 }
@@ -430,6 +487,16 @@ type implPProfProfileServerCallSend struct {
 func (s implPProfProfileServerCallSend) Send(item []byte) error {
 	return s.s.Send(item)
 }
+func (s implPProfProfileServerCallSend) SendBatch(items ...[]byte) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return s.s.SendBatch(batch...)
+}
+func (s implPProfProfileServerCallSend) TrySend(item []byte) (bool, error) {
+	return s.s.TrySend(item)
+}
 
 // PProfCpuProfileServerStream is the server stream for PProf.CpuProfile.
 type PProfCpuProfileServerStream interface {
@@ -439,7 +506,22 @@ type PProfCpuProfileServerStream interface {
 		// while sending.  Blocks if there is no buffer space; will unblock when
 		// buffer space is available.
 		Send(item []byte) error
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...[]byte) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item []byte) (bool, error)
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // PProfCpuProfileServerCall represents the context passed to PProf.CpuProfile.
@@ -462,6 +544,8 @@ func (s *PProfCpuProfileServerCallStub) Init(call rpc.StreamServerCall) {
 // SendStream returns the send side of the PProf.CpuProfile server stream.
 func (s *PProfCpuProfileServerCallStub) SendStream() interface {
 	Send(item []byte) error
+	SendBatch(items ...[]byte) error
+	TrySend(item []byte) (bool, error)
 } {
 	return implPProfCpuProfileServerCallSend{s}
 }
@@ -473,6 +557,16 @@ type implPProfCpuProfileServerCallSend struct {
 func (s implPProfCpuProfileServerCallSend) Send(item []byte) error {
 	return s.s.Send(item)
 }
+func (s implPProfCpuProfileServerCallSend) SendBatch(items ...[]byte) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return s.s.SendBatch(batch...)
+}
+func (s implPProfCpuProfileServerCallSend) TrySend(item []byte) (bool, error) {
+	return s.s.TrySend(item)
+}
 
 var __VDLInitCalled bool
 
@@ -529,7 +529,19 @@ type BinaryDownloadClientStream interface {
 		Value() []byte
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items [][]byte) (int, error)
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // BinaryDownloadClientCall represents the call returned from Binary.Download.
@@ -558,6 +570,7 @@ func (c *implBinaryDownloadClientCall) RecvStream() interface {
 	Advance() bool
 	Value() []byte
 	Err() error
+	RecvBatch(items [][]byte) (int, error)
 } {
 	return implBinaryDownloadClientCallRecv{c}
 }
@@ -579,6 +592,13 @@ func (c implBinaryDownloadClientCallRecv) Err() error {
 	}
 	return c.c.errRecv
 }
+func (c implBinaryDownloadClientCallRecv) RecvBatch(items [][]byte) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		ptrs[i] = &items[i]
+	}
+	return c.c.RecvBatch(ptrs...)
+}
 func (c *implBinaryDownloadClientCall) Finish() (err error) {
 	err = c.ClientCall.Finish()
 	return
@@ -594,6 +614,15 @@ type BinaryUploadClientStream interface {
 		// space; will unblock when buffer space is available or after
 		// the stream has been canceled.
 		Send(item []byte) error
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...[]byte) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item []byte) (bool, error)
 		// Close indicates to the server that no more items will be sent;
 		// server Recv calls will receive io.EOF after all sent items.
 		// This is an optional call - e.g. a client might call Close if it
@@ -603,6 +632,12 @@ type BinaryUploadClientStream interface {
 		// blocks if there is no buffer space available.
 		Close() error
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // BinaryUploadClientCall represents the call returned from Binary.Upload.
@@ -627,6 +662,8 @@ type implBinaryUploadClientCall struct {
 
 func (c *implBinaryUploadClientCall) SendStream() interface {
 	Send(item []byte) error
+	SendBatch(items ...[]byte) error
+	TrySend(item []byte) (bool, error)
 	Close() error
 } {
 	return implBinaryUploadClientCallSend{c}
@@ -639,6 +676,16 @@ type implBinaryUploadClientCallSend struct {
 func (c implBinaryUploadClientCallSend) Send(item []byte) error {
 	return c.c.Send(item)
 }
+func (c implBinaryUploadClientCallSend) SendBatch(items ...[]byte) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return c.c.SendBatch(batch...)
+}
+func (c implBinaryUploadClientCallSend) TrySend(item []byte) (bool, error) {
+	return c.c.TrySend(item)
+}
 func (c implBinaryUploadClientCallSend) Close() error {
 	return c.c.CloseSend()
 }
@@ -969,7 +1016,22 @@ type BinaryDownloadServerStream interface {
 		// while sending.  Blocks if there is no buffer space; will unblock when
 		// buffer space is available.
 		Send(item []byte) error
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...[]byte) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item []byte) (bool, error)
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // BinaryDownloadServerCall represents the context passed to Binary.Download.
@@ -992,6 +1054,8 @@ func (s *BinaryDownloadServerCallStub) Init(call rpc.StreamServerCall) {
 // SendStream returns the send side of the Binary.Download server stream.
 func (s *BinaryDownloadServerCallStub) SendStream() interface {
 	Send(item []byte) error
+	SendBatch(items ...[]byte) error
+	TrySend(item []byte) (bool, error)
 } {
 	return implBinaryDownloadServerCallSend{s}
 }
@@ -1003,6 +1067,16 @@ type implBinaryDownloadServerCallSend struct {
 func (s implBinaryDownloadServerCallSend) Send(item []byte) error {
 	return s.s.Send(item)
 }
+func (s implBinaryDownloadServerCallSend) SendBatch(items ...[]byte) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return s.s.SendBatch(batch...)
+}
+func (s implBinaryDownloadServerCallSend) TrySend(item []byte) (bool, error) {
+	return s.s.TrySend(item)
+}
 
 // BinaryUploadServerStream is the server stream for Binary.Upload.
 type BinaryUploadServerStream interface {
@@ -1017,7 +1091,19 @@ type BinaryUploadServerStream interface {
 		Value() []byte
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items [][]byte) (int, error)
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // BinaryUploadServerCall represents the context passed to Binary.Upload.
@@ -1044,6 +1130,7 @@ func (s *BinaryUploadServerCallStub) RecvStream() interface {
 	Advance() bool
 	Value() []byte
 	Err() error
+	RecvBatch(items [][]byte) (int, error)
 } {
 	return implBinaryUploadServerCallRecv{s}
 }
@@ -1065,6 +1152,13 @@ func (s implBinaryUploadServerCallRecv) Err() error {
 	}
 	return s.s.errRecv
 }
+func (s implBinaryUploadServerCallRecv) RecvBatch(items [][]byte) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		ptrs[i] = &items[i]
+	}
+	return s.s.RecvBatch(ptrs...)
+}
 
 // ProfileClientMethods is the client interface
 // containing Profile methods.
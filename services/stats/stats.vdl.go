@@ -23,6 +23,409 @@ import (
 
 var _ = __VDLInit() // Must be first; see __VDLInit comments for details.
 
+//////////////////////////////////////////////////
+// Type definitions
+
+// HistogramBucket is one histogram bucket.
+type HistogramBucket struct {
+	// LowBound is the lower bound of the bucket.
+	LowBound int64
+	// Count is the number of values in the bucket.
+	Count int64
+}
+
+func (HistogramBucket) __VDLReflect(struct {
+	Name string `vdl:"v.io/v23/services/stats.HistogramBucket"`
+}) {
+}
+
+func (x HistogramBucket) VDLIsZero() bool {
+	return x == HistogramBucket{}
+}
+
+func (x HistogramBucket) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_1); err != nil {
+		return err
+	}
+	if x.LowBound != 0 {
+		if err := enc.NextFieldValueInt(0, vdl.Int64Type, x.LowBound); err != nil {
+			return err
+		}
+	}
+	if x.Count != 0 {
+		if err := enc.NextFieldValueInt(1, vdl.Int64Type, x.Count); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *HistogramBucket) VDLRead(dec vdl.Decoder) error {
+	*x = HistogramBucket{}
+	if err := dec.StartValue(__VDLType_struct_1); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_1 {
+			index = __VDLType_struct_1.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			switch value, err := dec.ReadValueInt(64); {
+			case err != nil:
+				return err
+			default:
+				x.LowBound = value
+			}
+		case 1:
+			switch value, err := dec.ReadValueInt(64); {
+			case err != nil:
+				return err
+			default:
+				x.Count = value
+			}
+		}
+	}
+}
+
+// HistogramValue is the value of Histogram objects.
+type HistogramValue struct {
+	// Count is the total number of values added to the histogram.
+	Count int64
+	// Sum is the sum of all the values added to the histogram.
+	Sum int64
+	// Min is the minimum of all the values added to the histogram.
+	Min int64
+	// Max is the maximum of all the values added to the histogram.
+	Max int64
+	// Buckets contains all the buckets of the histogram.
+	Buckets []HistogramBucket
+}
+
+func (HistogramValue) __VDLReflect(struct {
+	Name string `vdl:"v.io/v23/services/stats.HistogramValue"`
+}) {
+}
+
+func (x HistogramValue) VDLIsZero() bool {
+	if x.Count != 0 {
+		return false
+	}
+	if x.Sum != 0 {
+		return false
+	}
+	if x.Min != 0 {
+		return false
+	}
+	if x.Max != 0 {
+		return false
+	}
+	if len(x.Buckets) != 0 {
+		return false
+	}
+	return true
+}
+
+func (x HistogramValue) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_2); err != nil {
+		return err
+	}
+	if x.Count != 0 {
+		if err := enc.NextFieldValueInt(0, vdl.Int64Type, x.Count); err != nil {
+			return err
+		}
+	}
+	if x.Sum != 0 {
+		if err := enc.NextFieldValueInt(1, vdl.Int64Type, x.Sum); err != nil {
+			return err
+		}
+	}
+	if x.Min != 0 {
+		if err := enc.NextFieldValueInt(2, vdl.Int64Type, x.Min); err != nil {
+			return err
+		}
+	}
+	if x.Max != 0 {
+		if err := enc.NextFieldValueInt(3, vdl.Int64Type, x.Max); err != nil {
+			return err
+		}
+	}
+	if len(x.Buckets) != 0 {
+		if err := enc.NextField(4); err != nil {
+			return err
+		}
+		if err := __VDLWriteAnon_list_1(enc, x.Buckets); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func __VDLWriteAnon_list_1(enc vdl.Encoder, x []HistogramBucket) error {
+	if err := enc.StartValue(__VDLType_list_3); err != nil {
+		return err
+	}
+	if err := enc.SetLenHint(len(x)); err != nil {
+		return err
+	}
+	for _, elem := range x {
+		if err := enc.NextEntry(false); err != nil {
+			return err
+		}
+		if err := elem.VDLWrite(enc); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextEntry(true); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *HistogramValue) VDLRead(dec vdl.Decoder) error {
+	*x = HistogramValue{}
+	if err := dec.StartValue(__VDLType_struct_2); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_2 {
+			index = __VDLType_struct_2.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			switch value, err := dec.ReadValueInt(64); {
+			case err != nil:
+				return err
+			default:
+				x.Count = value
+			}
+		case 1:
+			switch value, err := dec.ReadValueInt(64); {
+			case err != nil:
+				return err
+			default:
+				x.Sum = value
+			}
+		case 2:
+			switch value, err := dec.ReadValueInt(64); {
+			case err != nil:
+				return err
+			default:
+				x.Min = value
+			}
+		case 3:
+			switch value, err := dec.ReadValueInt(64); {
+			case err != nil:
+				return err
+			default:
+				x.Max = value
+			}
+		case 4:
+			if err := __VDLReadAnon_list_1(dec, &x.Buckets); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func __VDLReadAnon_list_1(dec vdl.Decoder, x *[]HistogramBucket) error {
+	if err := dec.StartValue(__VDLType_list_3); err != nil {
+		return err
+	}
+	if len := dec.LenHint(); len > 0 {
+		*x = make([]HistogramBucket, 0, len)
+	} else {
+		*x = nil
+	}
+	for {
+		switch done, err := dec.NextEntry(); {
+		case err != nil:
+			return err
+		case done:
+			return dec.FinishValue()
+		default:
+			var elem HistogramBucket
+			if err := elem.VDLRead(dec); err != nil {
+				return err
+			}
+			*x = append(*x, elem)
+		}
+	}
+}
+
+// CounterValue is the value of Counter objects.  A counter only ever
+// increases.
+type CounterValue struct {
+	// Count is the total number of events counted.
+	Count int64
+	// ByKey breaks Count down by an object-specific key, e.g. the verror ID of
+	// failed calls.  It may be empty.
+	ByKey map[string]int64
+}
+
+func (CounterValue) __VDLReflect(struct {
+	Name string `vdl:"v.io/v23/services/stats.CounterValue"`
+}) {
+}
+
+func (x CounterValue) VDLIsZero() bool {
+	if x.Count != 0 {
+		return false
+	}
+	if len(x.ByKey) != 0 {
+		return false
+	}
+	return true
+}
+
+func (x CounterValue) VDLWrite(enc vdl.Encoder) error {
+	if err := enc.StartValue(__VDLType_struct_4); err != nil {
+		return err
+	}
+	if x.Count != 0 {
+		if err := enc.NextFieldValueInt(0, vdl.Int64Type, x.Count); err != nil {
+			return err
+		}
+	}
+	if len(x.ByKey) != 0 {
+		if err := enc.NextField(1); err != nil {
+			return err
+		}
+		if err := __VDLWriteAnon_map_2(enc, x.ByKey); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextField(-1); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func __VDLWriteAnon_map_2(enc vdl.Encoder, x map[string]int64) error {
+	if err := enc.StartValue(__VDLType_map_5); err != nil {
+		return err
+	}
+	if err := enc.SetLenHint(len(x)); err != nil {
+		return err
+	}
+	for key, elem := range x {
+		if err := enc.NextEntryValueString(vdl.StringType, key); err != nil {
+			return err
+		}
+		if err := enc.WriteValueInt(vdl.Int64Type, elem); err != nil {
+			return err
+		}
+	}
+	if err := enc.NextEntry(true); err != nil {
+		return err
+	}
+	return enc.FinishValue()
+}
+
+func (x *CounterValue) VDLRead(dec vdl.Decoder) error {
+	*x = CounterValue{}
+	if err := dec.StartValue(__VDLType_struct_4); err != nil {
+		return err
+	}
+	decType := dec.Type()
+	for {
+		index, err := dec.NextField()
+		switch {
+		case err != nil:
+			return err
+		case index == -1:
+			return dec.FinishValue()
+		}
+		if decType != __VDLType_struct_4 {
+			index = __VDLType_struct_4.FieldIndexByName(decType.Field(index).Name)
+			if index == -1 {
+				if err := dec.SkipValue(); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		switch index {
+		case 0:
+			switch value, err := dec.ReadValueInt(64); {
+			case err != nil:
+				return err
+			default:
+				x.Count = value
+			}
+		case 1:
+			if err := __VDLReadAnon_map_2(dec, &x.ByKey); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func __VDLReadAnon_map_2(dec vdl.Decoder, x *map[string]int64) error {
+	if err := dec.StartValue(__VDLType_map_5); err != nil {
+		return err
+	}
+	var tmpMap map[string]int64
+	if len := dec.LenHint(); len > 0 {
+		tmpMap = make(map[string]int64, len)
+	}
+	for {
+		switch done, key, err := dec.NextEntryValueString(); {
+		case err != nil:
+			return err
+		case done:
+			*x = tmpMap
+			return dec.FinishValue()
+		default:
+			var elem int64
+			switch value, err := dec.ReadValueInt(64); {
+			case err != nil:
+				return err
+			default:
+				elem = value
+			}
+			if tmpMap == nil {
+				tmpMap = make(map[string]int64)
+			}
+			tmpMap[key] = elem
+		}
+	}
+}
+
 //////////////////////////////////////////////////
 // Error definitions
 
@@ -183,6 +586,15 @@ var descStats = rpc.InterfaceDesc{
 	},
 }
 
+// Hold type definitions in package-level variables, for better performance.
+var (
+	__VDLType_struct_1 *vdl.Type
+	__VDLType_struct_2 *vdl.Type
+	__VDLType_list_3   *vdl.Type
+	__VDLType_struct_4 *vdl.Type
+	__VDLType_map_5    *vdl.Type
+)
+
 var __VDLInitCalled bool
 
 // __VDLInit performs vdl initialization.  It is safe to call multiple times.
@@ -204,6 +616,18 @@ func __VDLInit() struct{} {
 	}
 	__VDLInitCalled = true
 
+	// Register types.
+	vdl.Register((*HistogramBucket)(nil))
+	vdl.Register((*HistogramValue)(nil))
+	vdl.Register((*CounterValue)(nil))
+
+	// Initialize type definitions.
+	__VDLType_struct_1 = vdl.TypeOf((*HistogramBucket)(nil)).Elem()
+	__VDLType_struct_2 = vdl.TypeOf((*HistogramValue)(nil)).Elem()
+	__VDLType_list_3 = vdl.TypeOf((*[]HistogramBucket)(nil))
+	__VDLType_struct_4 = vdl.TypeOf((*CounterValue)(nil)).Elem()
+	__VDLType_map_5 = vdl.TypeOf((*map[string]int64)(nil))
+
 	// Set error format strings.
 	i18n.Cat().SetWithBase(i18n.LangID("en"), i18n.MsgID(ErrNoValue.ID), "{1:}{2:} object has no value, suffix: {3}")
 
@@ -0,0 +1,203 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package statsserver serves a tree of stats objects via the stats.Stats
+// interface defined by v.io/v23/services/stats.
+//
+// The objects come from a Source, which names them relative to the root of
+// the stats service.  A process that publishes, for example, the metrics of
+// a Recorder from v.io/v23/rpc/metrics serves them under __debug/stats with:
+//
+//   disp := statsserver.NewDispatcher(recorder, auth)
+package statsserver
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"v.io/v23/context"
+	"v.io/v23/glob"
+	"v.io/v23/naming"
+	"v.io/v23/rpc"
+	"v.io/v23/security"
+	"v.io/v23/services/stats"
+	"v.io/v23/services/watch"
+	"v.io/v23/vdl"
+	"v.io/v23/verror"
+	"v.io/v23/vom"
+)
+
+// WatchInterval is how often WatchGlob calls served by NewDispatcher check
+// for changed values.
+var WatchInterval = time.Second
+
+// Source is a tree of stats objects.  Packages that publish stats objects
+// provide Sources of their own, to be served by NewDispatcher.
+type Source interface {
+	// Names returns the names of all the objects, relative to the root of
+	// the stats service, in sorted order.
+	Names() []string
+	// Value returns the current value of the named object.
+	Value(name string) (interface{}, error)
+}
+
+// NewDispatcher returns a dispatcher that serves the objects of src via the
+// stats.Stats interface.  It is meant to be mounted at the root of the stats
+// service, e.g. __debug/stats, so that the objects appear under their names.
+// The nodes of the naming tree above the objects exist but have no value.
+func NewDispatcher(src Source, auth security.Authorizer) rpc.Dispatcher {
+	return &dispatcher{src, auth}
+}
+
+type dispatcher struct {
+	src  Source
+	auth security.Authorizer
+}
+
+func (d *dispatcher) Lookup(_ *context.T, suffix string) (interface{}, security.Authorizer, error) {
+	return stats.StatsServer(&statsService{d.src, suffix}), d.auth, nil
+}
+
+type statsService struct {
+	src    Source
+	suffix string
+}
+
+func (s *statsService) Value(ctx *context.T, _ rpc.ServerCall) (*vom.RawBytes, error) {
+	v, err := s.src.Value(s.suffix)
+	switch {
+	case err == nil:
+		return vom.RawBytesOf(v), nil
+	case Exists(s.src, s.suffix):
+		return nil, stats.NewErrNoValue(ctx, s.suffix)
+	default:
+		return nil, verror.New(verror.ErrNoExist, ctx, s.suffix)
+	}
+}
+
+func (s *statsService) GlobChildren__(ctx *context.T, call rpc.GlobChildrenServerCall, m *glob.Element) error {
+	names, ok := children(s.src, SplitName(s.suffix))
+	if !ok {
+		return verror.New(verror.ErrNoExist, ctx, s.suffix)
+	}
+	sender := call.SendStream()
+	for _, child := range names {
+		if m.Match(child) {
+			sender.Send(naming.GlobChildrenReplyName{Value: child})
+		}
+	}
+	return nil
+}
+
+// WatchGlob sends the values of all the objects below the receiver that match
+// the pattern, and then sends a change for each object whose value changes
+// until the call is canceled.  Resuming a previous watch is not supported.
+func (s *statsService) WatchGlob(ctx *context.T, call watch.GlobWatcherWatchGlobServerCall, req watch.GlobRequest) error {
+	if len(req.ResumeMarker) > 0 {
+		return verror.New(watch.ErrUnknownResumeMarker, ctx)
+	}
+	m, err := glob.Compile(req.Pattern)
+	if err != nil {
+		return verror.New(verror.ErrBadArg, ctx, req.Pattern)
+	}
+	sender := call.SendStream()
+	last := map[string]interface{}{}
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+	for {
+		var changes []watch.Change
+		current := map[string]interface{}{}
+		prefix := SplitName(s.suffix)
+		for _, name := range s.src.Names() {
+			elems := SplitName(name)
+			if !hasPrefix(elems, prefix) || !m.Match(naming.Join(elems[len(prefix):]...)) {
+				continue
+			}
+			v, err := s.src.Value(name)
+			if err != nil {
+				continue
+			}
+			rel := naming.Join(elems[len(prefix):]...)
+			current[rel] = v
+			if old, ok := last[rel]; !ok || !vdl.DeepEqual(old, v) {
+				changes = append(changes, watch.Change{
+					Name:  rel,
+					State: watch.Exists,
+					Value: vom.RawBytesOf(v),
+				})
+			}
+		}
+		for rel := range last {
+			if _, ok := current[rel]; !ok {
+				changes = append(changes, watch.Change{Name: rel, State: watch.DoesNotExist})
+			}
+		}
+		for i, c := range changes {
+			c.Continued = i < len(changes)-1
+			if err := sender.Send(c); err != nil {
+				return err
+			}
+		}
+		last = current
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// SplitName returns the non-empty elements of the slash-separated name.
+func SplitName(name string) []string {
+	var elems []string
+	for _, e := range strings.Split(naming.Clean(name), "/") {
+		if e != "" {
+			elems = append(elems, e)
+		}
+	}
+	return elems
+}
+
+// Exists returns whether name is an object of src or a node of the naming
+// tree above one.  The root, named "", always exists.
+func Exists(src Source, name string) bool {
+	_, ok := children(src, SplitName(name))
+	return ok
+}
+
+// children returns the sorted names of the immediate children of the node of
+// src identified by elems, and whether that node exists.
+func children(src Source, elems []string) ([]string, bool) {
+	found := len(elems) == 0
+	set := map[string]bool{}
+	for _, name := range src.Names() {
+		e := SplitName(name)
+		if !hasPrefix(e, elems) {
+			continue
+		}
+		found = true
+		if len(e) > len(elems) {
+			set[e[len(elems)]] = true
+		}
+	}
+	children := make([]string, 0, len(set))
+	for c := range set {
+		children = append(children, c)
+	}
+	sort.Strings(children)
+	return children, found
+}
+
+func hasPrefix(elems, prefix []string) bool {
+	if len(elems) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if elems[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
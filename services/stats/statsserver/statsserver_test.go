@@ -0,0 +1,54 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statsserver
+
+import (
+	"reflect"
+	"testing"
+)
+
+type fakeSource []string
+
+func (s fakeSource) Names() []string                   { return s }
+func (s fakeSource) Value(string) (interface{}, error) { return nil, nil }
+
+func TestSplitName(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		elems []string
+	}{
+		{"", nil},
+		{"a", []string{"a"}},
+		{"/a//b/", []string{"a", "b"}},
+	} {
+		if got := SplitName(test.name); !reflect.DeepEqual(got, test.elems) {
+			t.Errorf("SplitName(%q): got %q, want %q", test.name, got, test.elems)
+		}
+	}
+}
+
+func TestChildren(t *testing.T) {
+	src := fakeSource{"a/b/c", "a/b/d", "a/e", "f"}
+	for _, test := range []struct {
+		name     string
+		children []string
+		exists   bool
+	}{
+		{"", []string{"a", "f"}, true},
+		{"a", []string{"b", "e"}, true},
+		{"a/b", []string{"c", "d"}, true},
+		{"a/b/c", []string{}, true},
+		{"a/x", []string{}, false},
+		{"ab", []string{}, false},
+	} {
+		got, ok := children(src, SplitName(test.name))
+		if !reflect.DeepEqual(got, test.children) || ok != test.exists {
+			t.Errorf("children(%q): got %q, %v, want %q, %v", test.name, got, ok, test.children, test.exists)
+		}
+		if got := Exists(src, test.name); got != test.exists {
+			t.Errorf("Exists(%q): got %v, want %v", test.name, got, test.exists)
+		}
+	}
+}
@@ -3555,7 +3555,19 @@ type DatabaseWatcherWatchPatternsClientStream interface {
 		Value() watch.Change
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
-	}
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items []watch.Change) (int, error)
+	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // DatabaseWatcherWatchPatternsClientCall represents the call returned from DatabaseWatcher.WatchPatterns.
@@ -3584,6 +3596,7 @@ func (c *implDatabaseWatcherWatchPatternsClientCall) RecvStream() interface {
 	Advance() bool
 	Value() watch.Change
 	Err() error
+	RecvBatch(items []watch.Change) (int, error)
 } {
 	return implDatabaseWatcherWatchPatternsClientCallRecv{c}
 }
@@ -3606,6 +3619,14 @@ func (c implDatabaseWatcherWatchPatternsClientCallRecv) Err() error {
 	}
 	return c.c.errRecv
 }
+func (c implDatabaseWatcherWatchPatternsClientCallRecv) RecvBatch(items []watch.Change) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		items[i] = watch.Change{}
+		ptrs[i] = &items[i]
+	}
+	return c.c.RecvBatch(ptrs...)
+}
 func (c *implDatabaseWatcherWatchPatternsClientCall) Finish() (err error) {
 	err = c.ClientCall.Finish()
 	return
@@ -3769,7 +3790,22 @@ type DatabaseWatcherWatchPatternsServerStream interface {
 		// while sending.  Blocks if there is no buffer space; will unblock when
 		// buffer space is available.
 		Send(item watch.Change) error
-	}
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...watch.Change) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item watch.Change) (bool, error)
+	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // DatabaseWatcherWatchPatternsServerCall represents the context passed to DatabaseWatcher.WatchPatterns.
@@ -3792,6 +3828,8 @@ func (s *DatabaseWatcherWatchPatternsServerCallStub) Init(call rpc.StreamServerC
 // SendStream returns the send side of the DatabaseWatcher.WatchPatterns server stream.
 func (s *DatabaseWatcherWatchPatternsServerCallStub) SendStream() interface {
 	Send(item watch.Change) error
+	SendBatch(items ...watch.Change) error
+	TrySend(item watch.Change) (bool, error)
 } {
 	return implDatabaseWatcherWatchPatternsServerCallSend{s}
 }
@@ -3803,6 +3841,16 @@ type implDatabaseWatcherWatchPatternsServerCallSend struct {
 func (s implDatabaseWatcherWatchPatternsServerCallSend) Send(item watch.Change) error {
 	return s.s.Send(item)
 }
+func (s implDatabaseWatcherWatchPatternsServerCallSend) SendBatch(items ...watch.Change) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return s.s.SendBatch(batch...)
+}
+func (s implDatabaseWatcherWatchPatternsServerCallSend) TrySend(item watch.Change) (bool, error) {
+	return s.s.TrySend(item)
+}
 
 // SyncgroupManagerClientMethods is the client interface
 // containing SyncgroupManager methods.
@@ -4310,6 +4358,15 @@ type BlobManagerPutBlobClientStream interface {
 		// space; will unblock when buffer space is available or after
 		// the stream has been canceled.
 		Send(item []byte) error
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...[]byte) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item []byte) (bool, error)
 		// Close indicates to the server that no more items will be sent;
 		// server Recv calls will receive io.EOF after all sent items.
 		// This is an optional call - e.g. a client might call Close if it
@@ -4319,6 +4376,12 @@ type BlobManagerPutBlobClientStream interface {
 		// blocks if there is no buffer space available.
 		Close() error
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // BlobManagerPutBlobClientCall represents the call returned from BlobManager.PutBlob.
@@ -4343,6 +4406,8 @@ type implBlobManagerPutBlobClientCall struct {
 
 func (c *implBlobManagerPutBlobClientCall) SendStream() interface {
 	Send(item []byte) error
+	SendBatch(items ...[]byte) error
+	TrySend(item []byte) (bool, error)
 	Close() error
 } {
 	return implBlobManagerPutBlobClientCallSend{c}
@@ -4355,6 +4420,16 @@ type implBlobManagerPutBlobClientCallSend struct {
 func (c implBlobManagerPutBlobClientCallSend) Send(item []byte) error {
 	return c.c.Send(item)
 }
+func (c implBlobManagerPutBlobClientCallSend) SendBatch(items ...[]byte) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return c.c.SendBatch(batch...)
+}
+func (c implBlobManagerPutBlobClientCallSend) TrySend(item []byte) (bool, error) {
+	return c.c.TrySend(item)
+}
 func (c implBlobManagerPutBlobClientCallSend) Close() error {
 	return c.c.CloseSend()
 }
@@ -4376,7 +4451,19 @@ type BlobManagerGetBlobClientStream interface {
 		Value() []byte
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
-	}
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items [][]byte) (int, error)
+	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // BlobManagerGetBlobClientCall represents the call returned from BlobManager.GetBlob.
@@ -4405,6 +4492,7 @@ func (c *implBlobManagerGetBlobClientCall) RecvStream() interface {
 	Advance() bool
 	Value() []byte
 	Err() error
+	RecvBatch(items [][]byte) (int, error)
 } {
 	return implBlobManagerGetBlobClientCallRecv{c}
 }
@@ -4426,6 +4514,13 @@ func (c implBlobManagerGetBlobClientCallRecv) Err() error {
 	}
 	return c.c.errRecv
 }
+func (c implBlobManagerGetBlobClientCallRecv) RecvBatch(items [][]byte) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		ptrs[i] = &items[i]
+	}
+	return c.c.RecvBatch(ptrs...)
+}
 func (c *implBlobManagerGetBlobClientCall) Finish() (err error) {
 	err = c.ClientCall.Finish()
 	return
@@ -4444,7 +4539,19 @@ type BlobManagerFetchBlobClientStream interface {
 		Value() BlobFetchStatus
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
-	}
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items []BlobFetchStatus) (int, error)
+	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // BlobManagerFetchBlobClientCall represents the call returned from BlobManager.FetchBlob.
@@ -4473,6 +4580,7 @@ func (c *implBlobManagerFetchBlobClientCall) RecvStream() interface {
 	Advance() bool
 	Value() BlobFetchStatus
 	Err() error
+	RecvBatch(items []BlobFetchStatus) (int, error)
 } {
 	return implBlobManagerFetchBlobClientCallRecv{c}
 }
@@ -4495,6 +4603,14 @@ func (c implBlobManagerFetchBlobClientCallRecv) Err() error {
 	}
 	return c.c.errRecv
 }
+func (c implBlobManagerFetchBlobClientCallRecv) RecvBatch(items []BlobFetchStatus) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		items[i] = BlobFetchStatus{}
+		ptrs[i] = &items[i]
+	}
+	return c.c.RecvBatch(ptrs...)
+}
 func (c *implBlobManagerFetchBlobClientCall) Finish() (err error) {
 	err = c.ClientCall.Finish()
 	return
@@ -4797,7 +4913,19 @@ type BlobManagerPutBlobServerStream interface {
 		Value() []byte
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
-	}
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items [][]byte) (int, error)
+	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // BlobManagerPutBlobServerCall represents the context passed to BlobManager.PutBlob.
@@ -4824,6 +4952,7 @@ func (s *BlobManagerPutBlobServerCallStub) RecvStream() interface {
 	Advance() bool
 	Value() []byte
 	Err() error
+	RecvBatch(items [][]byte) (int, error)
 } {
 	return implBlobManagerPutBlobServerCallRecv{s}
 }
@@ -4845,6 +4974,13 @@ func (s implBlobManagerPutBlobServerCallRecv) Err() error {
 	}
 	return s.s.errRecv
 }
+func (s implBlobManagerPutBlobServerCallRecv) RecvBatch(items [][]byte) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		ptrs[i] = &items[i]
+	}
+	return s.s.RecvBatch(ptrs...)
+}
 
 // BlobManagerGetBlobServerStream is the server stream for BlobManager.GetBlob.
 type BlobManagerGetBlobServerStream interface {
@@ -4854,7 +4990,22 @@ type BlobManagerGetBlobServerStream interface {
 		// while sending.  Blocks if there is no buffer space; will unblock when
 		// buffer space is available.
 		Send(item []byte) error
-	}
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...[]byte) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item []byte) (bool, error)
+	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // BlobManagerGetBlobServerCall represents the context passed to BlobManager.GetBlob.
@@ -4877,6 +5028,8 @@ func (s *BlobManagerGetBlobServerCallStub) Init(call rpc.StreamServerCall) {
 // SendStream returns the send side of the BlobManager.GetBlob server stream.
 func (s *BlobManagerGetBlobServerCallStub) SendStream() interface {
 	Send(item []byte) error
+	SendBatch(items ...[]byte) error
+	TrySend(item []byte) (bool, error)
 } {
 	return implBlobManagerGetBlobServerCallSend{s}
 }
@@ -4888,6 +5041,16 @@ type implBlobManagerGetBlobServerCallSend struct {
 func (s implBlobManagerGetBlobServerCallSend) Send(item []byte) error {
 	return s.s.Send(item)
 }
+func (s implBlobManagerGetBlobServerCallSend) SendBatch(items ...[]byte) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return s.s.SendBatch(batch...)
+}
+func (s implBlobManagerGetBlobServerCallSend) TrySend(item []byte) (bool, error) {
+	return s.s.TrySend(item)
+}
 
 // BlobManagerFetchBlobServerStream is the server stream for BlobManager.FetchBlob.
 type BlobManagerFetchBlobServerStream interface {
@@ -4897,7 +5060,22 @@ type BlobManagerFetchBlobServerStream interface {
 		// while sending.  Blocks if there is no buffer space; will unblock when
 		// buffer space is available.
 		Send(item BlobFetchStatus) error
-	}
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...BlobFetchStatus) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item BlobFetchStatus) (bool, error)
+	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // BlobManagerFetchBlobServerCall represents the context passed to BlobManager.FetchBlob.
@@ -4920,6 +5098,8 @@ func (s *BlobManagerFetchBlobServerCallStub) Init(call rpc.StreamServerCall) {
 // SendStream returns the send side of the BlobManager.FetchBlob server stream.
 func (s *BlobManagerFetchBlobServerCallStub) SendStream() interface {
 	Send(item BlobFetchStatus) error
+	SendBatch(items ...BlobFetchStatus) error
+	TrySend(item BlobFetchStatus) (bool, error)
 } {
 	return implBlobManagerFetchBlobServerCallSend{s}
 }
@@ -4931,6 +5111,16 @@ type implBlobManagerFetchBlobServerCallSend struct {
 func (s implBlobManagerFetchBlobServerCallSend) Send(item BlobFetchStatus) error {
 	return s.s.Send(item)
 }
+func (s implBlobManagerFetchBlobServerCallSend) SendBatch(items ...BlobFetchStatus) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return s.s.SendBatch(batch...)
+}
+func (s implBlobManagerFetchBlobServerCallSend) TrySend(item BlobFetchStatus) (bool, error) {
+	return s.s.TrySend(item)
+}
 
 // SchemaManagerClientMethods is the client interface
 // containing SchemaManager methods.
@@ -5134,6 +5324,12 @@ type ConflictManagerStartConflictResolverClientStream interface {
 		Value() ConflictInfo
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items []ConflictInfo) (int, error)
 	}
 	// SendStream returns the send side of the ConflictManager.StartConflictResolver client stream.
 	SendStream() interface {
@@ -5143,6 +5339,15 @@ type ConflictManagerStartConflictResolverClientStream interface {
 		// space; will unblock when buffer space is available or after
 		// the stream has been canceled.
 		Send(item ResolutionInfo) error
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...ResolutionInfo) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item ResolutionInfo) (bool, error)
 		// Close indicates to the server that no more items will be sent;
 		// server Recv calls will receive io.EOF after all sent items.
 		// This is an optional call - e.g. a client might call Close if it
@@ -5152,6 +5357,12 @@ type ConflictManagerStartConflictResolverClientStream interface {
 		// blocks if there is no buffer space available.
 		Close() error
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // ConflictManagerStartConflictResolverClientCall represents the call returned from ConflictManager.StartConflictResolver.
@@ -5180,6 +5391,7 @@ func (c *implConflictManagerStartConflictResolverClientCall) RecvStream() interf
 	Advance() bool
 	Value() ConflictInfo
 	Err() error
+	RecvBatch(items []ConflictInfo) (int, error)
 } {
 	return implConflictManagerStartConflictResolverClientCallRecv{c}
 }
@@ -5202,8 +5414,18 @@ func (c implConflictManagerStartConflictResolverClientCallRecv) Err() error {
 	}
 	return c.c.errRecv
 }
+func (c implConflictManagerStartConflictResolverClientCallRecv) RecvBatch(items []ConflictInfo) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		items[i] = ConflictInfo{}
+		ptrs[i] = &items[i]
+	}
+	return c.c.RecvBatch(ptrs...)
+}
 func (c *implConflictManagerStartConflictResolverClientCall) SendStream() interface {
 	Send(item ResolutionInfo) error
+	SendBatch(items ...ResolutionInfo) error
+	TrySend(item ResolutionInfo) (bool, error)
 	Close() error
 } {
 	return implConflictManagerStartConflictResolverClientCallSend{c}
@@ -5216,6 +5438,16 @@ type implConflictManagerStartConflictResolverClientCallSend struct {
 func (c implConflictManagerStartConflictResolverClientCallSend) Send(item ResolutionInfo) error {
 	return c.c.Send(item)
 }
+func (c implConflictManagerStartConflictResolverClientCallSend) SendBatch(items ...ResolutionInfo) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return c.c.SendBatch(batch...)
+}
+func (c implConflictManagerStartConflictResolverClientCallSend) TrySend(item ResolutionInfo) (bool, error) {
+	return c.c.TrySend(item)
+}
 func (c implConflictManagerStartConflictResolverClientCallSend) Close() error {
 	return c.c.CloseSend()
 }
@@ -5352,6 +5584,12 @@ type ConflictManagerStartConflictResolverServerStream interface {
 		Value() ResolutionInfo
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items []ResolutionInfo) (int, error)
 	}
 	// SendStream returns the send side of the ConflictManager.StartConflictResolver server stream.
 	SendStream() interface {
@@ -5359,7 +5597,22 @@ type ConflictManagerStartConflictResolverServerStream interface {
 		// while sending.  Blocks if there is no buffer space; will unblock when
 		// buffer space is available.
 		Send(item ConflictInfo) error
-	}
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...ConflictInfo) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item ConflictInfo) (bool, error)
+	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // ConflictManagerStartConflictResolverServerCall represents the context passed to ConflictManager.StartConflictResolver.
@@ -5386,6 +5639,7 @@ func (s *ConflictManagerStartConflictResolverServerCallStub) RecvStream() interf
 	Advance() bool
 	Value() ResolutionInfo
 	Err() error
+	RecvBatch(items []ResolutionInfo) (int, error)
 } {
 	return implConflictManagerStartConflictResolverServerCallRecv{s}
 }
@@ -5408,10 +5662,20 @@ func (s implConflictManagerStartConflictResolverServerCallRecv) Err() error {
 	}
 	return s.s.errRecv
 }
+func (s implConflictManagerStartConflictResolverServerCallRecv) RecvBatch(items []ResolutionInfo) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		items[i] = ResolutionInfo{}
+		ptrs[i] = &items[i]
+	}
+	return s.s.RecvBatch(ptrs...)
+}
 
 // SendStream returns the send side of the ConflictManager.StartConflictResolver server stream.
 func (s *ConflictManagerStartConflictResolverServerCallStub) SendStream() interface {
 	Send(item ConflictInfo) error
+	SendBatch(items ...ConflictInfo) error
+	TrySend(item ConflictInfo) (bool, error)
 } {
 	return implConflictManagerStartConflictResolverServerCallSend{s}
 }
@@ -5423,6 +5687,16 @@ type implConflictManagerStartConflictResolverServerCallSend struct {
 func (s implConflictManagerStartConflictResolverServerCallSend) Send(item ConflictInfo) error {
 	return s.s.Send(item)
 }
+func (s implConflictManagerStartConflictResolverServerCallSend) SendBatch(items ...ConflictInfo) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return s.s.SendBatch(batch...)
+}
+func (s implConflictManagerStartConflictResolverServerCallSend) TrySend(item ConflictInfo) (bool, error) {
+	return s.s.TrySend(item)
+}
 
 // DatabaseClientMethods is the client interface
 // containing Database methods.
@@ -5696,7 +5970,19 @@ type DatabaseExecClientStream interface {
 		Value() []*vom.RawBytes
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
-	}
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items [][]*vom.RawBytes) (int, error)
+	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // DatabaseExecClientCall represents the call returned from Database.Exec.
@@ -5725,6 +6011,7 @@ func (c *implDatabaseExecClientCall) RecvStream() interface {
 	Advance() bool
 	Value() []*vom.RawBytes
 	Err() error
+	RecvBatch(items [][]*vom.RawBytes) (int, error)
 } {
 	return implDatabaseExecClientCallRecv{c}
 }
@@ -5746,6 +6033,13 @@ func (c implDatabaseExecClientCallRecv) Err() error {
 	}
 	return c.c.errRecv
 }
+func (c implDatabaseExecClientCallRecv) RecvBatch(items [][]*vom.RawBytes) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		ptrs[i] = &items[i]
+	}
+	return c.c.RecvBatch(ptrs...)
+}
 func (c *implDatabaseExecClientCall) Finish() (err error) {
 	err = c.ClientCall.Finish()
 	return
@@ -6307,7 +6601,22 @@ type DatabaseExecServerStream interface {
 		// while sending.  Blocks if there is no buffer space; will unblock when
 		// buffer space is available.
 		Send(item []*vom.RawBytes) error
-	}
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...[]*vom.RawBytes) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item []*vom.RawBytes) (bool, error)
+	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // DatabaseExecServerCall represents the context passed to Database.Exec.
@@ -6330,6 +6639,8 @@ func (s *DatabaseExecServerCallStub) Init(call rpc.StreamServerCall) {
 // SendStream returns the send side of the Database.Exec server stream.
 func (s *DatabaseExecServerCallStub) SendStream() interface {
 	Send(item []*vom.RawBytes) error
+	SendBatch(items ...[]*vom.RawBytes) error
+	TrySend(item []*vom.RawBytes) (bool, error)
 } {
 	return implDatabaseExecServerCallSend{s}
 }
@@ -6341,6 +6652,16 @@ type implDatabaseExecServerCallSend struct {
 func (s implDatabaseExecServerCallSend) Send(item []*vom.RawBytes) error {
 	return s.s.Send(item)
 }
+func (s implDatabaseExecServerCallSend) SendBatch(items ...[]*vom.RawBytes) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return s.s.SendBatch(batch...)
+}
+func (s implDatabaseExecServerCallSend) TrySend(item []*vom.RawBytes) (bool, error) {
+	return s.s.TrySend(item)
+}
 
 // CollectionClientMethods is the client interface
 // containing Collection methods.
@@ -6459,7 +6780,19 @@ type CollectionScanClientStream interface {
 		Value() KeyValue
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
-	}
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items []KeyValue) (int, error)
+	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // CollectionScanClientCall represents the call returned from Collection.Scan.
@@ -6488,6 +6821,7 @@ func (c *implCollectionScanClientCall) RecvStream() interface {
 	Advance() bool
 	Value() KeyValue
 	Err() error
+	RecvBatch(items []KeyValue) (int, error)
 } {
 	return implCollectionScanClientCallRecv{c}
 }
@@ -6510,6 +6844,14 @@ func (c implCollectionScanClientCallRecv) Err() error {
 	}
 	return c.c.errRecv
 }
+func (c implCollectionScanClientCallRecv) RecvBatch(items []KeyValue) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		items[i] = KeyValue{}
+		ptrs[i] = &items[i]
+	}
+	return c.c.RecvBatch(ptrs...)
+}
 func (c *implCollectionScanClientCall) Finish() (err error) {
 	err = c.ClientCall.Finish()
 	return
@@ -6765,7 +7107,22 @@ type CollectionScanServerStream interface {
 		// while sending.  Blocks if there is no buffer space; will unblock when
 		// buffer space is available.
 		Send(item KeyValue) error
-	}
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...KeyValue) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item KeyValue) (bool, error)
+	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // CollectionScanServerCall represents the context passed to Collection.Scan.
@@ -6788,6 +7145,8 @@ func (s *CollectionScanServerCallStub) Init(call rpc.StreamServerCall) {
 // SendStream returns the send side of the Collection.Scan server stream.
 func (s *CollectionScanServerCallStub) SendStream() interface {
 	Send(item KeyValue) error
+	SendBatch(items ...KeyValue) error
+	TrySend(item KeyValue) (bool, error)
 } {
 	return implCollectionScanServerCallSend{s}
 }
@@ -6799,6 +7158,16 @@ type implCollectionScanServerCallSend struct {
 func (s implCollectionScanServerCallSend) Send(item KeyValue) error {
 	return s.s.Send(item)
 }
+func (s implCollectionScanServerCallSend) SendBatch(items ...KeyValue) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return s.s.SendBatch(batch...)
+}
+func (s implCollectionScanServerCallSend) TrySend(item KeyValue) (bool, error) {
+	return s.s.TrySend(item)
+}
 
 // RowClientMethods is the client interface
 // containing Row methods.
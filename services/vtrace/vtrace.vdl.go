@@ -78,7 +78,19 @@ type StoreAllTracesClientStream interface {
 		Value() vtrace.TraceRecord
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items []vtrace.TraceRecord) (int, error)
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // StoreAllTracesClientCall represents the call returned from Store.AllTraces.
@@ -107,6 +119,7 @@ func (c *implStoreAllTracesClientCall) RecvStream() interface {
 	Advance() bool
 	Value() vtrace.TraceRecord
 	Err() error
+	RecvBatch(items []vtrace.TraceRecord) (int, error)
 } {
 	return implStoreAllTracesClientCallRecv{c}
 }
@@ -129,6 +142,14 @@ func (c implStoreAllTracesClientCallRecv) Err() error {
 	}
 	return c.c.errRecv
 }
+func (c implStoreAllTracesClientCallRecv) RecvBatch(items []vtrace.TraceRecord) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		items[i] = vtrace.TraceRecord{}
+		ptrs[i] = &items[i]
+	}
+	return c.c.RecvBatch(ptrs...)
+}
 func (c *implStoreAllTracesClientCall) Finish() (err error) {
 	err = c.ClientCall.Finish()
 	return
@@ -238,7 +259,22 @@ type StoreAllTracesServerStream interface {
 		// while sending.  Blocks if there is no buffer space; will unblock when
 		// buffer space is available.
 		Send(item vtrace.TraceRecord) error
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...vtrace.TraceRecord) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item vtrace.TraceRecord) (bool, error)
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // StoreAllTracesServerCall represents the context passed to Store.AllTraces.
@@ -261,6 +297,8 @@ func (s *StoreAllTracesServerCallStub) Init(call rpc.StreamServerCall) {
 // SendStream returns the send side of the Store.AllTraces server stream.
 func (s *StoreAllTracesServerCallStub) SendStream() interface {
 	Send(item vtrace.TraceRecord) error
+	SendBatch(items ...vtrace.TraceRecord) error
+	TrySend(item vtrace.TraceRecord) (bool, error)
 } {
 	return implStoreAllTracesServerCallSend{s}
 }
@@ -272,6 +310,16 @@ type implStoreAllTracesServerCallSend struct {
 func (s implStoreAllTracesServerCallSend) Send(item vtrace.TraceRecord) error {
 	return s.s.Send(item)
 }
+func (s implStoreAllTracesServerCallSend) SendBatch(items ...vtrace.TraceRecord) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return s.s.SendBatch(batch...)
+}
+func (s implStoreAllTracesServerCallSend) TrySend(item vtrace.TraceRecord) (bool, error) {
+	return s.s.TrySend(item)
+}
 
 var __VDLInitCalled bool
 
@@ -491,7 +491,19 @@ type GlobWatcherWatchGlobClientStream interface {
 		Value() Change
 		// Err returns any error encountered by Advance.  Never blocks.
 		Err() error
+		// RecvBatch fills a prefix of items with the next items in the
+		// stream, blocking until at least one item is available, and returns
+		// the number of items filled.  Returns 0 and io.EOF to indicate the
+		// graceful end of the stream.  Use either Advance or RecvBatch, not
+		// both.
+		RecvBatch(items []Change) (int, error)
 	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the server may send on the stream
+	// before it must wait for the client to receive.
+	SetWindow(size uint64) error
 }
 
 // GlobWatcherWatchGlobClientCall represents the call returned from GlobWatcher.WatchGlob.
@@ -520,6 +532,7 @@ func (c *implGlobWatcherWatchGlobClientCall) RecvStream() interface {
 	Advance() bool
 	Value() Change
 	Err() error
+	RecvBatch(items []Change) (int, error)
 } {
 	return implGlobWatcherWatchGlobClientCallRecv{c}
 }
@@ -542,6 +555,14 @@ func (c implGlobWatcherWatchGlobClientCallRecv) Err() error {
 	}
 	return c.c.errRecv
 }
+func (c implGlobWatcherWatchGlobClientCallRecv) RecvBatch(items []Change) (int, error) {
+	ptrs := make([]interface{}, len(items))
+	for i := range items {
+		items[i] = Change{}
+		ptrs[i] = &items[i]
+	}
+	return c.c.RecvBatch(ptrs...)
+}
 func (c *implGlobWatcherWatchGlobClientCall) Finish() (err error) {
 	err = c.ClientCall.Finish()
 	return
@@ -635,7 +656,22 @@ type GlobWatcherWatchGlobServerStream interface {
 		// while sending.  Blocks if there is no buffer space; will unblock when
 		// buffer space is available.
 		Send(item Change) error
-	}
+		// SendBatch places all of the items onto the output stream, in
+		// order, behind a single header.  Returns errors encountered while
+		// sending.  Blocks until all of the items have been accepted.
+		SendBatch(items ...Change) error
+		// TrySend places the item onto the output stream only if that can
+		// be done without blocking.  Returns false, without sending the
+		// item, if there is no buffer space, i.e. the peer is slow to
+		// receive.
+		TrySend(item Change) (bool, error)
+	}
+	// Window returns the current state of the flow-control windows of the
+	// stream.
+	Window() rpc.StreamWindow
+	// SetWindow sets the number of bytes the client may send on the stream
+	// before it must wait for the server to receive.
+	SetWindow(size uint64) error
 }
 
 // GlobWatcherWatchGlobServerCall represents the context passed to GlobWatcher.WatchGlob.
@@ -658,6 +694,8 @@ func (s *GlobWatcherWatchGlobServerCallStub) Init(call rpc.StreamServerCall) {
 // SendStream returns the send side of the GlobWatcher.WatchGlob server stream.
 func (s *GlobWatcherWatchGlobServerCallStub) SendStream() interface {
 	Send(item Change) error
+	SendBatch(items ...Change) error
+	TrySend(item Change) (bool, error)
 } {
 	return implGlobWatcherWatchGlobServerCallSend{s}
 }
@@ -669,6 +707,16 @@ type implGlobWatcherWatchGlobServerCallSend struct {
 func (s implGlobWatcherWatchGlobServerCallSend) Send(item Change) error {
 	return s.s.Send(item)
 }
+func (s implGlobWatcherWatchGlobServerCallSend) SendBatch(items ...Change) error {
+	batch := make([]interface{}, len(items))
+	for i, item := range items {
+		batch[i] = item
+	}
+	return s.s.SendBatch(batch...)
+}
+func (s implGlobWatcherWatchGlobServerCallSend) TrySend(item Change) (bool, error) {
+	return s.s.TrySend(item)
+}
 
 // Hold type definitions in package-level variables, for better performance.
 var (
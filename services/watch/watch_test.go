@@ -0,0 +1,106 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package watch
+
+import (
+	"io"
+	"reflect"
+	"testing"
+
+	"v.io/v23/rpc"
+)
+
+// fakeStream is an rpc.StreamServerCall whose stream methods send to and
+// receive from sent.
+type fakeStream struct {
+	rpc.StreamServerCall
+	sent   []interface{}
+	window uint64
+}
+
+func (f *fakeStream) SendBatch(items ...interface{}) error {
+	f.sent = append(f.sent, items...)
+	return nil
+}
+
+func (f *fakeStream) TrySend(item interface{}) (bool, error) {
+	f.sent = append(f.sent, item)
+	return true, nil
+}
+
+func (f *fakeStream) RecvBatch(itemptrs ...interface{}) (int, error) {
+	if len(f.sent) == 0 {
+		return 0, io.EOF
+	}
+	n := 0
+	for ; n < len(itemptrs) && n < len(f.sent); n++ {
+		*itemptrs[n].(*Change) = f.sent[n].(Change)
+	}
+	f.sent = f.sent[n:]
+	return n, nil
+}
+
+func (f *fakeStream) Window() rpc.StreamWindow {
+	return rpc.StreamWindow{Recv: f.window}
+}
+
+func (f *fakeStream) SetWindow(size uint64) error {
+	f.window = size
+	return nil
+}
+
+// fakeClientCall is an rpc.ClientCall whose stream methods are those of f.
+type fakeClientCall struct {
+	rpc.ClientCall
+	f *fakeStream
+}
+
+func (c *fakeClientCall) RecvBatch(itemptrs ...interface{}) (int, error) {
+	return c.f.RecvBatch(itemptrs...)
+}
+
+func (c *fakeClientCall) Window() rpc.StreamWindow {
+	return c.f.Window()
+}
+
+func (c *fakeClientCall) SetWindow(size uint64) error {
+	return c.f.SetWindow(size)
+}
+
+func TestStreamBatches(t *testing.T) {
+	f := &fakeStream{}
+	server := &GlobWatcherWatchGlobServerCallStub{}
+	server.Init(f)
+	changes := []Change{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	if err := server.SendStream().SendBatch(changes[:2]...); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := server.SendStream().TrySend(changes[2]); !ok || err != nil {
+		t.Fatalf("got (%v, %v), want (true, nil)", ok, err)
+	}
+
+	var client GlobWatcherWatchGlobClientCall = &implGlobWatcherWatchGlobClientCall{ClientCall: &fakeClientCall{f: f}}
+	if err := client.SetWindow(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := client.Window().Recv, uint64(1<<20); got != want {
+		t.Errorf("got window %v, want %v", got, want)
+	}
+	var got []Change
+	buf := make([]Change, 2)
+	for {
+		n, err := client.RecvStream().RecvBatch(buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, buf[:n]...)
+	}
+	if !reflect.DeepEqual(got, changes) {
+		t.Errorf("got %v, want %v", got, changes)
+	}
+}
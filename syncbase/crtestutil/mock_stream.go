@@ -5,8 +5,10 @@
 package crtestutil
 
 import (
+	"io"
 	"sync"
 
+	"v.io/v23/rpc"
 	wire "v.io/v23/services/syncbase"
 )
 
@@ -65,17 +67,28 @@ func (s *CrStreamImpl) RecvStream() interface {
 	Advance() bool
 	Value() wire.ConflictInfo
 	Err() error
+	RecvBatch(items []wire.ConflictInfo) (int, error)
 } {
 	return recvStreamImpl{s.C}
 }
 
 func (s *CrStreamImpl) SendStream() interface {
 	Send(item wire.ResolutionInfo) error
+	SendBatch(items ...wire.ResolutionInfo) error
+	TrySend(item wire.ResolutionInfo) (bool, error)
 	Close() error
 } {
 	return sendStreamImpl{s.R}
 }
 
+func (s *CrStreamImpl) Window() rpc.StreamWindow {
+	return rpc.StreamWindow{}
+}
+
+func (s *CrStreamImpl) SetWindow(size uint64) error {
+	return nil
+}
+
 func (s *CrStreamImpl) Finish() error {
 	return nil
 }
@@ -93,6 +106,19 @@ func (rs recvStreamImpl) Value() wire.ConflictInfo {
 func (rs recvStreamImpl) Err() error {
 	return rs.c.Err()
 }
+func (rs recvStreamImpl) RecvBatch(items []wire.ConflictInfo) (int, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+	if !rs.c.Advance() {
+		if err := rs.c.Err(); err != nil {
+			return 0, err
+		}
+		return 0, io.EOF
+	}
+	items[0] = rs.c.Value()
+	return 1, nil
+}
 
 type sendStreamImpl struct {
 	r ResolutionStream
@@ -101,6 +127,17 @@ type sendStreamImpl struct {
 func (ss sendStreamImpl) Send(item wire.ResolutionInfo) error {
 	return ss.r.Send(item)
 }
+func (ss sendStreamImpl) SendBatch(items ...wire.ResolutionInfo) error {
+	for _, item := range items {
+		if err := ss.r.Send(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (ss sendStreamImpl) TrySend(item wire.ResolutionInfo) (bool, error) {
+	return true, ss.r.Send(item)
+}
 func (c sendStreamImpl) Close() error {
 	return nil
 }
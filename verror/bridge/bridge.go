@@ -0,0 +1,121 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bridge converts errors to and from the statuses of HTTP responses
+// and gRPC calls, for services that bridge Vanadium RPCs to those systems.
+//
+// The status of each error is looked up in the table maintained by
+// verror.RegisterStatus.  In addition, the complete error, including its ID,
+// action and parameters, is encoded alongside the status, so that an error
+// that is converted to a status and back keeps its identity:
+//
+//   // In an HTTP handler:
+//   bridge.WriteHTTPError(w, err)
+//
+//   // In the HTTP client:
+//   if err := bridge.HTTPResponseError(resp); err != nil {
+//     ...
+//   }
+//
+// Statuses that were not created by this package are converted to the error
+// ID that the status canonically maps to, see verror.FromHTTPStatus and
+// verror.FromGRPCStatus.
+package bridge
+
+import (
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"v.io/v23/vdl"
+	"v.io/v23/verror"
+	"v.io/v23/vom"
+)
+
+// HTTPHeader is the HTTP response header that carries the encoded error.
+const HTTPHeader = "X-Vanadium-Error"
+
+// maxHTTPMessage is the maximum number of bytes of the body of a response
+// without an encoded error that is used as the message of the error.
+const maxHTTPMessage = 4096
+
+// WriteHTTPError replies to an HTTP request with the status that err maps to,
+// the encoded error in HTTPHeader and the message of the error as a plain
+// text body.
+func WriteHTTPError(w http.ResponseWriter, err error) {
+	if details, encErr := encode(err); encErr == nil {
+		w.Header().Set(HTTPHeader, base64.URLEncoding.EncodeToString(details))
+	}
+	http.Error(w, err.Error(), verror.HTTPStatus(err))
+}
+
+// HTTPResponseError returns the error carried by resp, or nil if resp does not
+// indicate an error.  The body of resp is read if it does not carry an encoded
+// error; it is not closed.
+func HTTPResponseError(resp *http.Response) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	if header := resp.Header.Get(HTTPHeader); header != "" {
+		if details, err := base64.URLEncoding.DecodeString(header); err == nil {
+			if err, ok := decode(details); ok {
+				return err
+			}
+		}
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxHTTPMessage))
+	return verror.FromHTTPStatus(resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// ToGRPC returns the gRPC status code and message that err maps to, and the
+// encoded error to send as the details of the status.  It returns
+// verror.GRPCOK for a nil err.
+func ToGRPC(err error) (code verror.GRPCCode, msg string, details []byte) {
+	if err == nil {
+		return verror.GRPCOK, "", nil
+	}
+	details, _ = encode(err)
+	return verror.GRPCStatus(err), err.Error(), details
+}
+
+// FromGRPC returns the error described by a gRPC status with the given code,
+// message and details, as returned by ToGRPC.  It returns nil for
+// verror.GRPCOK.
+func FromGRPC(code verror.GRPCCode, msg string, details []byte) error {
+	if code == verror.GRPCOK {
+		return nil
+	}
+	if len(details) > 0 {
+		if err, ok := decode(details); ok {
+			return err
+		}
+	}
+	return verror.FromGRPCStatus(code, msg)
+}
+
+// encode returns the VOM encoding of err, including its ID, action, message
+// and parameters.
+func encode(err error) ([]byte, error) {
+	var wire vdl.WireError
+	if e := verror.WireFromNative(&wire, err); e != nil {
+		return nil, e
+	}
+	return vom.Encode(wire)
+}
+
+// decode returns the error encoded by encode, or false if data is not a valid
+// encoding.
+func decode(data []byte) (error, bool) {
+	var wire vdl.WireError
+	if err := vom.Decode(data, &wire); err != nil || wire.Id == "" {
+		return nil, false
+	}
+	var e verror.E
+	if err := verror.WireToNative(wire, &e); err != nil {
+		return nil, false
+	}
+	return e, true
+}
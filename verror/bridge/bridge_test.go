@@ -0,0 +1,86 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bridge_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"v.io/v23/verror"
+	"v.io/v23/verror/bridge"
+)
+
+var errCustom = verror.Register("v.io/v23/verror/bridge_test.errCustom", verror.RetryBackoff, "{1:}{2:} custom {3} {4}{:_}")
+
+func TestHTTPRoundTrip(t *testing.T) {
+	for _, want := range []error{
+		verror.New(verror.ErrNoExist, nil, "a/b"),
+		verror.ExplicitNew(errCustom, "en", "component", "op", "x", int64(42)),
+	} {
+		rec := httptest.NewRecorder()
+		bridge.WriteHTTPError(rec, want)
+		if got, want := rec.Code, verror.HTTPStatus(want); got != want {
+			t.Errorf("got status %d, want %d", got, want)
+		}
+		resp := &http.Response{StatusCode: rec.Code, Header: rec.HeaderMap, Body: nopCloser{rec.Body}}
+		got := bridge.HTTPResponseError(resp)
+		checkSame(t, got, want)
+	}
+}
+
+func TestGRPCRoundTrip(t *testing.T) {
+	want := verror.ExplicitNew(errCustom, "en", "component", "op", "x", int64(42))
+	code, msg, details := bridge.ToGRPC(want)
+	if code != verror.GRPCUnknown || msg != want.Error() {
+		t.Errorf("got (%v, %q), want (%v, %q)", code, msg, verror.GRPCUnknown, want.Error())
+	}
+	checkSame(t, bridge.FromGRPC(code, msg, details), want)
+
+	if code, _, _ := bridge.ToGRPC(nil); code != verror.GRPCOK {
+		t.Errorf("got %v, want OK", code)
+	}
+	if err := bridge.FromGRPC(verror.GRPCOK, "", nil); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
+
+func TestForeignStatus(t *testing.T) {
+	// Statuses without an encoded error map to the canonical ID.
+	resp := httptest.NewRecorder()
+	http.Error(resp, "forbidden", http.StatusForbidden)
+	err := bridge.HTTPResponseError(&http.Response{StatusCode: resp.Code, Header: resp.HeaderMap, Body: nopCloser{resp.Body}})
+	if verror.ErrorID(err) != verror.ErrNoAccess.ID || err.Error() != "forbidden" {
+		t.Errorf("got %v (%v), want ErrNoAccess", err, verror.ErrorID(err))
+	}
+	err = bridge.FromGRPC(verror.GRPCDeadlineExceeded, "slow", []byte("garbage"))
+	if verror.ErrorID(err) != verror.ErrTimeout.ID {
+		t.Errorf("got %v, want ErrTimeout", err)
+	}
+	// Errors that aren't verrors travel as ErrUnknown.
+	_, _, details := bridge.ToGRPC(errors.New("plain"))
+	if err := bridge.FromGRPC(verror.GRPCUnknown, "plain", details); verror.ErrorID(err) != verror.ErrUnknown.ID {
+		t.Errorf("got %v, want ErrUnknown", err)
+	}
+}
+
+func checkSame(t *testing.T, got, want error) {
+	if verror.ErrorID(got) != verror.ErrorID(want) || verror.Action(got) != verror.Action(want) {
+		t.Errorf("got %v (%v, %v), want %v (%v, %v)", got, verror.ErrorID(got), verror.Action(got), want, verror.ErrorID(want), verror.Action(want))
+	}
+	if got.Error() != want.Error() {
+		t.Errorf("got message %q, want %q", got.Error(), want.Error())
+	}
+	if g, w := got.(verror.E).ParamList, want.(verror.E).ParamList; !reflect.DeepEqual(g, w) {
+		t.Errorf("got params %#v, want %#v", g, w)
+	}
+}
+
+type nopCloser struct{ *bytes.Buffer }
+
+func (nopCloser) Close() error { return nil }
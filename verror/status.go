@@ -0,0 +1,210 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package verror
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"sync"
+)
+
+// GRPCCode is a gRPC status code, as defined by
+// https://github.com/grpc/grpc/blob/master/doc/statuscodes.md
+type GRPCCode uint32
+
+const (
+	GRPCOK                 GRPCCode = 0
+	GRPCCanceled           GRPCCode = 1
+	GRPCUnknown            GRPCCode = 2
+	GRPCInvalidArgument    GRPCCode = 3
+	GRPCDeadlineExceeded   GRPCCode = 4
+	GRPCNotFound           GRPCCode = 5
+	GRPCAlreadyExists      GRPCCode = 6
+	GRPCPermissionDenied   GRPCCode = 7
+	GRPCResourceExhausted  GRPCCode = 8
+	GRPCFailedPrecondition GRPCCode = 9
+	GRPCAborted            GRPCCode = 10
+	GRPCOutOfRange         GRPCCode = 11
+	GRPCUnimplemented      GRPCCode = 12
+	GRPCInternal           GRPCCode = 13
+	GRPCUnavailable        GRPCCode = 14
+	GRPCDataLoss           GRPCCode = 15
+	GRPCUnauthenticated    GRPCCode = 16
+)
+
+var grpcCodeNames = []string{
+	"OK",
+	"CANCELLED",
+	"UNKNOWN",
+	"INVALID_ARGUMENT",
+	"DEADLINE_EXCEEDED",
+	"NOT_FOUND",
+	"ALREADY_EXISTS",
+	"PERMISSION_DENIED",
+	"RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION",
+	"ABORTED",
+	"OUT_OF_RANGE",
+	"UNIMPLEMENTED",
+	"INTERNAL",
+	"UNAVAILABLE",
+	"DATA_LOSS",
+	"UNAUTHENTICATED",
+}
+
+// String returns the canonical name of the code, e.g. "NOT_FOUND".
+func (c GRPCCode) String() string {
+	if int(c) < len(grpcCodeNames) {
+		return grpcCodeNames[c]
+	}
+	return fmt.Sprintf("CODE(%d)", uint32(c))
+}
+
+// Status is the HTTP status and gRPC code that an error ID maps to when an
+// error is carried over HTTP or gRPC.
+type Status struct {
+	HTTP int
+	GRPC GRPCCode
+}
+
+var (
+	statusMu sync.RWMutex
+	// statusByID holds the status of each ID.  GUARDED_BY(statusMu)
+	statusByID = map[ID]Status{
+		ErrUnknown.ID:           {http.StatusInternalServerError, GRPCUnknown},
+		ErrInternal.ID:          {http.StatusInternalServerError, GRPCInternal},
+		ErrNotImplemented.ID:    {http.StatusNotImplemented, GRPCUnimplemented},
+		ErrEndOfFile.ID:         {http.StatusBadRequest, GRPCOutOfRange},
+		ErrBadArg.ID:            {http.StatusBadRequest, GRPCInvalidArgument},
+		ErrBadState.ID:          {http.StatusPreconditionFailed, GRPCFailedPrecondition},
+		ErrBadVersion.ID:        {http.StatusConflict, GRPCAborted},
+		ErrExist.ID:             {http.StatusConflict, GRPCAlreadyExists},
+		ErrNoExist.ID:           {http.StatusNotFound, GRPCNotFound},
+		ErrUnknownMethod.ID:     {http.StatusNotImplemented, GRPCUnimplemented},
+		ErrUnknownSuffix.ID:     {http.StatusNotFound, GRPCNotFound},
+		ErrNoExistOrNoAccess.ID: {http.StatusNotFound, GRPCNotFound},
+		ErrNoServers.ID:         {http.StatusServiceUnavailable, GRPCUnavailable},
+		ErrNoAccess.ID:          {http.StatusForbidden, GRPCPermissionDenied},
+		ErrNotTrusted.ID:        {http.StatusUnauthorized, GRPCUnauthenticated},
+		ErrAborted.ID:           {http.StatusConflict, GRPCAborted},
+		ErrBadProtocol.ID:       {http.StatusInternalServerError, GRPCInternal},
+		ErrCanceled.ID:          {499, GRPCCanceled}, // 499 is the de facto "client closed request".
+		ErrTimeout.ID:           {http.StatusGatewayTimeout, GRPCDeadlineExceeded},
+	}
+
+	// The IDs that HTTP statuses and gRPC codes map back to, for errors that
+	// don't carry an ID of their own.
+	idByHTTP = map[int]IDAction{
+		http.StatusBadRequest:          ErrBadArg,
+		http.StatusUnauthorized:        ErrNoAccess,
+		http.StatusForbidden:           ErrNoAccess,
+		http.StatusNotFound:            ErrNoExist,
+		http.StatusMethodNotAllowed:    ErrUnknownMethod,
+		http.StatusRequestTimeout:      ErrTimeout,
+		http.StatusConflict:            ErrExist,
+		http.StatusPreconditionFailed:  ErrBadState,
+		499:                            ErrCanceled,
+		http.StatusInternalServerError: ErrInternal,
+		http.StatusNotImplemented:      ErrNotImplemented,
+		http.StatusBadGateway:          ErrNoServers,
+		http.StatusServiceUnavailable:  ErrNoServers,
+		http.StatusGatewayTimeout:      ErrTimeout,
+	}
+	idByGRPC = map[GRPCCode]IDAction{
+		GRPCCanceled:           ErrCanceled,
+		GRPCUnknown:            ErrUnknown,
+		GRPCInvalidArgument:    ErrBadArg,
+		GRPCDeadlineExceeded:   ErrTimeout,
+		GRPCNotFound:           ErrNoExist,
+		GRPCAlreadyExists:      ErrExist,
+		GRPCPermissionDenied:   ErrNoAccess,
+		GRPCFailedPrecondition: ErrBadState,
+		GRPCAborted:            ErrAborted,
+		GRPCOutOfRange:         ErrEndOfFile,
+		GRPCUnimplemented:      ErrNotImplemented,
+		GRPCInternal:           ErrInternal,
+		GRPCUnavailable:        ErrNoServers,
+		GRPCDataLoss:           ErrInternal,
+		GRPCUnauthenticated:    ErrNoAccess,
+	}
+)
+
+// RegisterStatus sets the HTTP status and gRPC code that errors with the
+// given ID map to.  The standard IDs of this package are registered by
+// default; packages that define their own errors may register them to
+// override the status of ErrUnknown that unregistered IDs map to.
+func RegisterStatus(id ID, status Status) {
+	statusMu.Lock()
+	statusByID[id] = status
+	statusMu.Unlock()
+}
+
+// StatusOf returns the status that errors with the given ID map to.
+func StatusOf(id ID) Status {
+	statusMu.RLock()
+	defer statusMu.RUnlock()
+	if status, ok := statusByID[id]; ok {
+		return status
+	}
+	return statusByID[ErrUnknown.ID]
+}
+
+// HTTPStatus returns the HTTP status that err maps to, or http.StatusOK if err
+// is nil.
+func HTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	return StatusOf(ErrorID(err)).HTTP
+}
+
+// GRPCStatus returns the gRPC code that err maps to, or GRPCOK if err is nil.
+func GRPCStatus(err error) GRPCCode {
+	if err == nil {
+		return GRPCOK
+	}
+	return StatusOf(ErrorID(err)).GRPC
+}
+
+// FromHTTPStatus returns the error that corresponds to an HTTP response with
+// the given status and message, or nil if the status does not indicate an
+// error.  It is used for responses that do not carry the verror they were
+// created from; the error has the ID that the status canonically maps to, and
+// msg as its only parameter other than the component and operation names.
+func FromHTTPStatus(status int, msg string) error {
+	if status < 400 {
+		return nil
+	}
+	idAction, ok := idByHTTP[status]
+	if !ok {
+		idAction = ErrUnknown
+	}
+	return fromStatus(idAction, msg)
+}
+
+// FromGRPCStatus returns the error that corresponds to a gRPC status with the
+// given code and message, or nil if the code is GRPCOK.  Like FromHTTPStatus,
+// it is used for statuses that do not carry the verror they were created from.
+func FromGRPCStatus(code GRPCCode, msg string) error {
+	if code == GRPCOK {
+		return nil
+	}
+	idAction, ok := idByGRPC[code]
+	if !ok {
+		idAction = ErrUnknown
+	}
+	return fromStatus(idAction, msg)
+}
+
+func fromStatus(idAction IDAction, msg string) error {
+	stack := make([]uintptr, maxPCs)
+	stack = stack[:runtime.Callers(3, stack)]
+	params := []interface{}{"", ""}
+	if msg != "" {
+		params = append(params, msg)
+	}
+	return E{ID: idAction.ID, Action: idAction.Action, Msg: msg, ParamList: params, stackPCs: stack}
+}
@@ -0,0 +1,67 @@
+// Copyright 2015 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package verror_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"v.io/v23/verror"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		http int
+		grpc verror.GRPCCode
+	}{
+		{nil, http.StatusOK, verror.GRPCOK},
+		{verror.New(verror.ErrNoExist, nil), http.StatusNotFound, verror.GRPCNotFound},
+		{verror.New(verror.ErrNoAccess, nil), http.StatusForbidden, verror.GRPCPermissionDenied},
+		{verror.New(verror.ErrTimeout, nil), http.StatusGatewayTimeout, verror.GRPCDeadlineExceeded},
+		{verror.New(verror.ErrBadArg, nil), http.StatusBadRequest, verror.GRPCInvalidArgument},
+		{verror.New(verror.ErrCanceled, nil), 499, verror.GRPCCanceled},
+		{verror.New(idActionA, nil), http.StatusInternalServerError, verror.GRPCUnknown},
+		{errors.New("plain"), http.StatusInternalServerError, verror.GRPCUnknown},
+	}
+	for _, test := range tests {
+		if got := verror.HTTPStatus(test.err); got != test.http {
+			t.Errorf("%v: got HTTP status %d, want %d", test.err, got, test.http)
+		}
+		if got := verror.GRPCStatus(test.err); got != test.grpc {
+			t.Errorf("%v: got gRPC code %v, want %v", test.err, got, test.grpc)
+		}
+	}
+
+	// IDs from other packages can be registered.
+	verror.RegisterStatus(idActionB.ID, verror.Status{HTTP: http.StatusTooManyRequests, GRPC: verror.GRPCResourceExhausted})
+	if got, want := verror.StatusOf(idActionB.ID), (verror.Status{HTTP: 429, GRPC: verror.GRPCResourceExhausted}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := verror.GRPCResourceExhausted.String(), "RESOURCE_EXHAUSTED"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFromStatus(t *testing.T) {
+	if err := verror.FromHTTPStatus(http.StatusNoContent, ""); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+	if err := verror.FromGRPCStatus(verror.GRPCOK, ""); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+	err := verror.FromHTTPStatus(http.StatusNotFound, "no such page")
+	if verror.ErrorID(err) != verror.ErrNoExist.ID || err.Error() != "no such page" {
+		t.Errorf("got %v (%v), want ErrNoExist", err, verror.ErrorID(err))
+	}
+	if err := verror.FromHTTPStatus(http.StatusTeapot, ""); verror.ErrorID(err) != verror.ErrUnknown.ID {
+		t.Errorf("got %v, want ErrUnknown", err)
+	}
+	err = verror.FromGRPCStatus(verror.GRPCUnavailable, "down")
+	if verror.ErrorID(err) != verror.ErrNoServers.ID || verror.Action(err) != verror.RetryRefetch {
+		t.Errorf("got %v (%v, %v), want ErrNoServers", err, verror.ErrorID(err), verror.Action(err))
+	}
+}